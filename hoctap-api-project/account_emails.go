@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"hoctap-api/config"
+	"hoctap-api/database"
+	"hoctap-api/jobs"
+	"hoctap-api/mailer"
+)
+
+// emailJobKind is the background job sending one rendered email
+const emailJobKind = "email.send"
+
+// accountMailer sends the account emails; nil while SMTP_HOST is not set
+var accountMailer *mailer.Mailer
+
+// Helper function to start the mailer when an SMTP server is configured and report the server
+// as an optional dependency. It returns a function stopping the mailer.
+func startMailer(cfg config.MailConfig) (func(), error) {
+	if cfg.SMTPHost == "" {
+		return func() {}, nil
+	}
+
+	m, err := mailer.New(mailer.Config{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		TLS:      cfg.SMTPTLS,
+		From:     cfg.From,
+		BaseURL:  cfg.BaseURL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	accountMailer = m
+	healthMonitor.Register("smtp", false, m.Ping)
+	log.Printf("✉️ Sending email through %s:%d", cfg.SMTPHost, cfg.SMTPPort)
+	return m.Stop, nil
+}
+
+// Helper function to let the job queue send the account emails, so an email survives a
+// restart and an SMTP outage longer than the mailer's own retries
+func registerEmailJobs(queue *jobs.Queue) {
+	if accountMailer == nil {
+		return
+	}
+
+	queue.Register(emailJobKind, jobs.Options{MaxAttempts: 8, Backoff: 30 * time.Second}, func(ctx context.Context, job *database.Job) error {
+		var msg mailer.Message
+		if err := json.Unmarshal(job.Payload, &msg); err != nil {
+			return jobs.Permanent(fmt.Errorf("invalid email: %v", err))
+		}
+
+		err := accountMailer.Deliver(msg)
+		if mailer.IsPermanent(err) {
+			return jobs.Permanent(err)
+		}
+		return err
+	})
+}
+
+// Helper function to send an account email: rendered now and sent by the job queue, or
+// queued in the mailer when there is no job queue (mock mode)
+func sendAccountEmail(to, name string, data mailer.Data) error {
+	if jobQueue == nil {
+		return accountMailer.Send(to, name, data)
+	}
+
+	msg, err := accountMailer.Render(to, name, data)
+	if err != nil {
+		return err
+	}
+	_, err = jobQueue.Enqueue(context.Background(), emailJobKind, msg)
+	return err
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// Global user activity repository
+var activityRepo *database.ActivityRepository
+
+// Page size limits for GET /users/{id}/activity
+const (
+	defaultActivityPageSize = 20
+	maxActivityPageSize     = 100
+)
+
+// Get a page of a user's activity feed, newest first (optionally ?limit= and ?before= the
+// next_before of the previous page), so support staff can see what happened to an account
+func getUserActivityHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := database.ActivityFilter{UserID: userID, Limit: defaultActivityPageSize}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxActivityPageSize {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_limit_100"), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("before"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || before <= 0 {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_activity_before"), nil)
+			return
+		}
+		filter.Before = before
+	}
+
+	// An unknown user has no feed rather than an empty one
+	if _, err := userRepo.GetUserByID(r.Context(), userID); err != nil {
+		if errors.Is(err, database.ErrUserNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("user_id_not_found", "id", userID), nil)
+		} else {
+			log.Printf("Error getting user %d: %v", userID, err)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("activity_retrieve_failed"), nil)
+		}
+		return
+	}
+
+	// Fetch one extra activity to know whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	activities, err := activityRepo.List(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error getting activity of user %d: %v", userID, err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("activity_retrieve_failed"), nil)
+		return
+	}
+
+	var nextBefore interface{}
+	if len(activities) > limit {
+		activities = activities[:limit]
+		nextBefore = activities[limit-1].ID
+	}
+
+	sendJSONResponseWithMeta(w, http.StatusOK, i18n.M("activity_retrieved"), activities, map[string]interface{}{
+		"limit":       limit,
+		"next_before": nextBefore,
+	})
+}
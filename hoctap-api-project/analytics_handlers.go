@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"hoctap-api/config"
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// analyticsRecentSignups is how many of the newest users the analytics summary lists
+const analyticsRecentSignups = 5
+
+// analyticsSnapshot is the cached analytics summary, computed at most once per
+// ANALYTICS_CACHE_TTL however many dashboards poll it
+var analyticsSnapshot struct {
+	sync.Mutex
+	summary     *analyticsSummary
+	generatedAt time.Time
+}
+
+// analyticsSummary is the document of GET /analytics/summary. Daily active users will be
+// added once requests are authenticated as users.
+type analyticsSummary struct {
+	TotalUsers  int `json:"total_users"`
+	NewUsers7d  int `json:"new_users_7d"`
+	NewUsers30d int `json:"new_users_30d"`
+	// GrowthRate30d is the change in percent of the signups of the last 30 days over the 30
+	// days before, null when there were none then
+	GrowthRate30d *float64       `json:"growth_rate_30d"`
+	RecentSignups []recentSignup `json:"recent_signups"`
+	GeneratedAt   string         `json:"generated_at"`
+}
+
+// recentSignup is a user of the recent signups of the analytics summary
+type recentSignup struct {
+	UUID      string    `json:"uuid"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Get the analytics summary of the dashboard: user totals, new users of the last 7 and 30
+// days, their growth over the 30 days before and the newest users. The summary is shared by
+// all callers for ANALYTICS_CACHE_TTL and sent with a matching Cache-Control.
+func getAnalyticsSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	ttl := config.Current().Analytics.CacheTTL
+
+	analyticsSnapshot.Lock()
+	defer analyticsSnapshot.Unlock()
+
+	if analyticsSnapshot.summary == nil || time.Since(analyticsSnapshot.generatedAt) >= ttl {
+		now := time.Now()
+		summary, err := buildAnalyticsSummary(r.Context(), now)
+		if err != nil {
+			log.Printf("Error getting analytics summary: %v", err)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("analytics_failed"), nil)
+			return
+		}
+
+		analyticsSnapshot.summary = summary
+		analyticsSnapshot.generatedAt = now
+	}
+
+	maxAge := ttl - time.Since(analyticsSnapshot.generatedAt)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int(maxAge.Seconds())))
+	sendJSONResponse(w, http.StatusOK, i18n.M("analytics_retrieved"), analyticsSnapshot.summary)
+}
+
+// Helper function to compute the analytics summary as of now
+func buildAnalyticsSummary(ctx context.Context, now time.Time) (*analyticsSummary, error) {
+	summary := &analyticsSummary{GeneratedAt: now.UTC().Format(time.RFC3339)}
+
+	var err error
+	if summary.TotalUsers, err = userRepo.GetUsersCount(ctx); err != nil {
+		return nil, err
+	}
+
+	counts := map[int]int{}
+	for _, days := range []int{7, 30, 60} {
+		since := now.AddDate(0, 0, -days)
+		if counts[days], err = userRepo.CountUsers(ctx, database.UserFilter{CreatedSince: since}); err != nil {
+			return nil, err
+		}
+	}
+	summary.NewUsers7d, summary.NewUsers30d = counts[7], counts[30]
+	if previous := counts[60] - counts[30]; previous > 0 {
+		rate := float64(counts[30]-previous) / float64(previous) * 100
+		summary.GrowthRate30d = &rate
+	}
+
+	users, err := userRepo.ListUsers(ctx, database.UserFilter{
+		Limit:  analyticsRecentSignups,
+		Fields: []string{"name", "created_at"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	summary.RecentSignups = make([]recentSignup, 0, len(users))
+	for _, user := range users {
+		summary.RecentSignups = append(summary.RecentSignups, recentSignup{UUID: user.UUID, Name: user.Name, CreatedAt: user.CreatedAt})
+	}
+
+	return summary, nil
+}
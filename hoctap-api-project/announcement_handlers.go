@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+
+	"github.com/gorilla/mux"
+)
+
+// Global announcement repository
+var announcementRepo *database.AnnouncementRepository
+
+// announcementInput is the request body for creating an announcement
+type announcementInput struct {
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Audience    string     `json:"audience"`
+	PublishAt   *time.Time `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+}
+
+// Create an announcement, optionally scheduled with a publish window
+func createAnnouncementHandler(w http.ResponseWriter, r *http.Request) {
+	var announcementData announcementInput
+
+	if err := decodeRequestBody(r, &announcementData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+		return
+	}
+
+	// Validation
+	if announcementData.Title == "" || announcementData.Body == "" {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("announcement_title_body_required"), nil)
+		return
+	}
+
+	if announcementData.Audience == "" {
+		announcementData.Audience = database.AudienceAll
+	}
+
+	publishAt := time.Now()
+	if announcementData.PublishAt != nil {
+		publishAt = *announcementData.PublishAt
+	}
+
+	if announcementData.UnpublishAt != nil && !announcementData.UnpublishAt.After(publishAt) {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("announcement_invalid_window"), nil)
+		return
+	}
+
+	announcement, err := announcementRepo.CreateAnnouncement(r.Context(), announcementData.Title, announcementData.Body,
+		announcementData.Audience, publishAt, announcementData.UnpublishAt)
+	if err != nil {
+		log.Printf("Error creating announcement: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("announcement_create_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusCreated, i18n.M("announcement_created"), announcement)
+}
+
+// Get all announcements, including scheduled and expired ones
+func getAnnouncementsHandler(w http.ResponseWriter, r *http.Request) {
+	announcements, err := announcementRepo.GetAllAnnouncements(r.Context())
+	if err != nil {
+		log.Printf("Error getting announcements: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("announcements_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("announcements_retrieved"), announcements)
+}
+
+// Get the announcements currently published for an audience (?audience=, defaults to everyone)
+func getActiveAnnouncementsHandler(w http.ResponseWriter, r *http.Request) {
+	audience := r.URL.Query().Get("audience")
+	if audience == "" {
+		audience = database.AudienceAll
+	}
+
+	announcements, err := announcementRepo.GetActiveAnnouncements(r.Context(), audience)
+	if err != nil {
+		log.Printf("Error getting active announcements: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("announcements_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("active_announcements_retrieved"), announcements)
+}
+
+// Delete an announcement
+func deleteAnnouncementHandler(w http.ResponseWriter, r *http.Request) {
+	announcementID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_announcement_id"), nil)
+		return
+	}
+
+	if err := announcementRepo.DeleteAnnouncement(r.Context(), announcementID); err != nil {
+		log.Printf("Error deleting announcement: %v", err)
+		if errors.Is(err, database.ErrAnnouncementNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("announcement_not_found", "id", announcementID), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("announcement_delete_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("announcement_deleted"), nil)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+)
+
+// embeddedAssets are the dashboard files compiled into the binary, so it runs from any
+// directory and in scratch containers
+//
+//go:embed styles.css script.js templates
+var embeddedAssets embed.FS
+
+var (
+	// dashboardAssets holds the dashboard files: the embedded copies, or a directory given
+	// with serve -assets
+	dashboardAssets fs.FS = embeddedAssets
+	// liveAssets is set with serve -assets, so edited files are never cached
+	liveAssets bool
+)
+
+// assetsMaxAge is how long browsers may reuse an embedded asset before revalidating it
+const assetsMaxAge = "300"
+
+// Serve the dashboard files from dir instead of the embedded copies, for live editing
+func useAssetsDir(dir string) {
+	dashboardAssets = os.DirFS(dir)
+	liveAssets = true
+}
+
+// Handler serving the static dashboard files with their content types. Embedded files get an ETag
+// from their content (they have no modification time) and may be cached for a few minutes;
+// live files are revalidated on every request.
+func newAssetHandler() http.Handler {
+	files := http.FileServer(http.FS(dashboardAssets))
+	etags := map[string]string{}
+	if !liveAssets {
+		for _, name := range []string{"styles.css", "script.js"} {
+			content, _ := fs.ReadFile(dashboardAssets, name)
+			sum := sha256.Sum256(content)
+			etags[name] = `"` + hex.EncodeToString(sum[:8]) + `"`
+		}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Base(r.URL.Path)
+
+		if liveAssets {
+			w.Header().Set("Cache-Control", "no-cache")
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age="+assetsMaxAge)
+			w.Header().Set("ETag", etags[name])
+		}
+		files.ServeHTTP(w, r)
+	})
+}
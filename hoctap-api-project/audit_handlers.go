@@ -0,0 +1,77 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// Global audit log repository
+var auditRepo *database.AuditRepository
+
+// Get audit log entries, filtered by actor, action, entity, entity_id, from, to and limit
+func getAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := database.AuditFilter{
+		Actor:  query.Get("actor"),
+		Action: query.Get("action"),
+		Entity: query.Get("entity"),
+	}
+
+	if value := query.Get("entity_id"); value != "" {
+		entityID, err := strconv.Atoi(value)
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_entity_id"), nil)
+			return
+		}
+		filter.EntityID = entityID
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_limit"), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.From, err = parseTimeParam(query.Get("from")); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_from"), nil)
+		return
+	}
+	if filter.To, err = parseTimeParam(query.Get("to")); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_to"), nil)
+		return
+	}
+
+	entries, err := auditRepo.List(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error getting audit log: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("audit_log_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("audit_log_retrieved"), entries)
+}
+
+// Helper function to audit an admin change that does not go through a repository hook
+func recordAuditEntry(r *http.Request, action, entity string, entityID int, after interface{}) {
+	if err := auditRepo.Record(r.Context(), requestActor(r), action, entity, entityID, nil, after); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+}
+
+// Helper function to parse an optional RFC3339 query parameter
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
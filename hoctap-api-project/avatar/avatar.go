@@ -0,0 +1,106 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	_ "image/png" // registers the PNG decoder
+	"net/http"
+)
+
+// MaxDimension bounds the width and height of an uploaded image, so a small file cannot
+// decode into a huge bitmap
+const MaxDimension = 4096
+
+// ContentType is the type of every processed avatar
+const ContentType = "image/jpeg"
+
+// jpegQuality balances size and artifacts for small avatars
+const jpegQuality = 85
+
+var (
+	// ErrUnsupportedType is returned for uploads that are not JPEG, PNG or GIF images
+	ErrUnsupportedType = errors.New("avatar must be a JPEG, PNG or GIF image")
+	// ErrTooLarge is returned for images wider or taller than MaxDimension
+	ErrTooLarge = fmt.Errorf("avatar must be at most %dx%d pixels", MaxDimension, MaxDimension)
+)
+
+// allowedTypes are the sniffed content types accepted as uploads
+var allowedTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true}
+
+// Process validates an uploaded image and turns it into a size x size JPEG: the largest
+// centered square is cropped, scaled and flattened onto white. The type is sniffed from
+// the content rather than trusted from the upload.
+func Process(data []byte, size int) ([]byte, error) {
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+	if config.Width > MaxDimension || config.Height > MaxDimension {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedType
+	}
+
+	var out bytes.Buffer
+	if err := jpeg.Encode(&out, resize(src, centerSquare(src.Bounds()), size), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return nil, fmt.Errorf("failed to encode avatar: %v", err)
+	}
+	return out.Bytes(), nil
+}
+
+// Helper function to get the largest square centered in bounds
+func centerSquare(bounds image.Rectangle) image.Rectangle {
+	side := min(bounds.Dx(), bounds.Dy())
+	x := bounds.Min.X + (bounds.Dx()-side)/2
+	y := bounds.Min.Y + (bounds.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// Helper function to scale the square area of src to size x size. Each target pixel is the
+// average of the source pixels it covers (a box filter), which keeps downscaled photos
+// smooth; upscaling repeats pixels. Transparent areas come out white.
+func resize(src image.Image, area image.Rectangle, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	side := area.Dx()
+
+	for y := 0; y < size; y++ {
+		y0 := area.Min.Y + y*side/size
+		y1 := max(area.Min.Y+(y+1)*side/size, y0+1)
+
+		for x := 0; x < size; x++ {
+			x0 := area.Min.X + x*side/size
+			x1 := max(area.Min.X+(x+1)*side/size, x0+1)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			// The channels are premultiplied, so adding the missing alpha composites onto white
+			white := 0xffff - a/n
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r/n + white) >> 8),
+				G: uint8((g/n + white) >> 8),
+				B: uint8((b/n + white) >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+
+	"hoctap-api/avatar"
+	"hoctap-api/config"
+	"hoctap-api/i18n"
+	"hoctap-api/webhooks"
+
+	"github.com/gorilla/mux"
+)
+
+// avatarPathPrefix is the URL prefix stored avatars are served under
+const avatarPathPrefix = "/avatars/"
+
+// avatarFormOverhead allows for the multipart framing around the uploaded file
+const avatarFormOverhead = 64 << 10
+
+// Upload a user's avatar as the multipart file field "avatar". The image is validated,
+// cropped and scaled to AVATAR_SIZE, stored under a content-derived name and linked as the
+// user's avatar_url.
+func uploadUserAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	cfg := config.Current().Avatar
+	tooLarge := i18n.M("avatar_too_large", "max", cfg.MaxUploadBytes)
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(cfg.MaxUploadBytes)+avatarFormOverhead)
+	file, _, err := r.FormFile("avatar")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			sendJSONResponse(w, http.StatusRequestEntityTooLarge, tooLarge, nil)
+		} else {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("avatar_field_required"), nil)
+		}
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(cfg.MaxUploadBytes)+1))
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("avatar_unreadable"), nil)
+		return
+	}
+	if len(data) > cfg.MaxUploadBytes {
+		sendJSONResponse(w, http.StatusRequestEntityTooLarge, tooLarge, nil)
+		return
+	}
+
+	processed, err := avatar.Process(data, cfg.Size)
+	switch {
+	case errors.Is(err, avatar.ErrUnsupportedType):
+		sendJSONResponse(w, http.StatusUnsupportedMediaType, i18n.M("avatar_unsupported_format"), nil)
+		return
+	case errors.Is(err, avatar.ErrTooLarge):
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("avatar_dimensions_too_large", "width", avatar.MaxDimension, "height", avatar.MaxDimension), nil)
+		return
+	case err != nil:
+		log.Printf("Error processing avatar: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("avatar_process_failed"), nil)
+		return
+	}
+
+	before, err := userRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		sendUserLookupError(w, userID, err)
+		return
+	}
+
+	name, err := storeAvatar(r.Context(), userID, processed)
+	if err != nil {
+		log.Printf("Error storing avatar: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("avatar_store_failed"), nil)
+		return
+	}
+
+	user, err := userRepo.WithActor(requestActor(r)).SetAvatar(r.Context(), userID, avatarPathPrefix+name)
+	if err != nil {
+		log.Printf("Error updating avatar: %v", err)
+		if before.AvatarURL != avatarPathPrefix+name {
+			removeAvatar(r.Context(), avatarPathPrefix+name)
+		}
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("avatar_update_failed"), nil)
+		return
+	}
+
+	// Re-uploading the same image keeps the same name
+	if before.AvatarURL != user.AvatarURL {
+		removeAvatar(r.Context(), before.AvatarURL)
+	}
+
+	publishUserEvent(webhooks.EventUserUpdated, user)
+	sendJSONResponse(w, http.StatusOK, i18n.M("avatar_updated"), user)
+}
+
+// Redirect to a user's stored avatar image
+func getUserAvatarHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := userRepo.GetUserByID(r.Context(), userID)
+	if err != nil {
+		sendUserLookupError(w, userID, err)
+		return
+	}
+	if user.AvatarURL == "" {
+		sendJSONResponse(w, http.StatusNotFound, i18n.M("avatar_missing"), nil)
+		return
+	}
+
+	if !strings.HasPrefix(user.AvatarURL, avatarPathPrefix) {
+		http.Redirect(w, r, user.AvatarURL, http.StatusFound)
+		return
+	}
+	redirectToStoredObject(w, r, avatarKey(user.AvatarURL))
+}
+
+// Redirect to a signed download URL of a stored avatar. The avatar_url of users stays
+// stable while the signed URLs expire.
+func serveAvatarFileHandler(w http.ResponseWriter, r *http.Request) {
+	redirectToStoredObject(w, r, avatarKey(avatarPathPrefix+mux.Vars(r)["file"]))
+}
+
+// Helper function to store an avatar under a name derived from the user and the content,
+// returning the name
+func storeAvatar(ctx context.Context, userID int, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	name := fmt.Sprintf("%d-%s.jpg", userID, hex.EncodeToString(sum[:8]))
+
+	if err := blobStore.Put(ctx, avatarKey(avatarPathPrefix+name), bytes.NewReader(data), int64(len(data)), avatar.ContentType); err != nil {
+		return "", fmt.Errorf("failed to store avatar: %v", err)
+	}
+	return name, nil
+}
+
+// Helper function to delete a stored avatar that is no longer linked
+func removeAvatar(ctx context.Context, avatarURL string) {
+	if !strings.HasPrefix(avatarURL, avatarPathPrefix) {
+		return
+	}
+	if err := blobStore.Delete(ctx, avatarKey(avatarURL)); err != nil {
+		log.Printf("⚠️ Warning: failed to remove old avatar: %v", err)
+	}
+}
+
+// Helper function to map an avatar_url such as /avatars/3-e7e3ebacf5b49adc.jpg to its
+// storage key
+func avatarKey(avatarURL string) string {
+	return "avatars/" + path.Base(avatarURL)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"hoctap-api/config"
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+
+	"github.com/gorilla/mux"
+)
+
+// bootReport describes how the server started: where the configuration came from, the
+// schema migrations, how long each subsystem took to initialize and the routes served.
+// It is printed as one JSON line once the server is ready and served to admins.
+type bootReport struct {
+	StartedAt     time.Time       `json:"started_at"`
+	ReadyAt       time.Time       `json:"ready_at"`
+	DurationMs    float64         `json:"duration_ms"`
+	Version       string          `json:"version"`
+	Mode          string          `json:"mode"`
+	Listeners     []string        `json:"listeners"`
+	ConfigSources []string        `json:"config_sources"`
+	Migrations    *bootMigrations `json:"migrations,omitempty"`
+	Subsystems    []bootStep      `json:"subsystems"`
+	Routes        []string        `json:"routes"`
+}
+
+// bootMigrations is the schema state after startup; AppliedAtBoot were run by this process
+type bootMigrations struct {
+	SchemaVersion uint                 `json:"schema_version"`
+	Applied       []database.Migration `json:"applied"`
+	AppliedAtBoot []database.Migration `json:"applied_at_boot"`
+}
+
+// bootStep is the initialization time of one subsystem
+type bootStep struct {
+	Name       string  `json:"name"`
+	DurationMs float64 `json:"duration_ms"`
+}
+
+var (
+	// boot is filled in by the startup sequence only
+	boot = bootReport{StartedAt: time.Now()}
+	// bootReportReady is the finished report, published once the server is ready
+	bootReportReady atomic.Pointer[bootReport]
+)
+
+// Helper function to time a startup step; call the returned function when it is done
+func timeBootStep(name string) func() {
+	start := time.Now()
+	return func() {
+		boot.Subsystems = append(boot.Subsystems, bootStep{Name: name, DurationMs: milliseconds(time.Since(start))})
+	}
+}
+
+// Helper function to record the schema migrations, given the version before migrating
+func recordBootMigrations(before uint) {
+	version, _, err := database.MigrationVersion()
+	if err != nil {
+		log.Printf("⚠️ Warning: boot report: %v", err)
+		return
+	}
+	migrations, err := database.Migrations()
+	if err != nil {
+		log.Printf("⚠️ Warning: boot report: %v", err)
+		return
+	}
+
+	report := &bootMigrations{SchemaVersion: version, Applied: []database.Migration{}, AppliedAtBoot: []database.Migration{}}
+	for _, migration := range migrations {
+		if migration.Version <= version {
+			report.Applied = append(report.Applied, migration)
+		}
+		if migration.Version > before && migration.Version <= version {
+			report.AppliedAtBoot = append(report.AppliedAtBoot, migration)
+		}
+	}
+	boot.Migrations = report
+}
+
+// Helper function to complete the report with the config sources and routes, print it as
+// one JSON line on stdout and make it available to GET /api/admin/boot-report
+func publishBootReport(router *mux.Router) {
+	boot.ReadyAt = time.Now()
+	boot.DurationMs = milliseconds(boot.ReadyAt.Sub(boot.StartedAt))
+	boot.Version = apiVersion
+	boot.ConfigSources = config.Sources()
+	boot.Routes = []string{}
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil || route.GetHandler() == nil {
+			return nil
+		}
+		path := muxVariablePattern.ReplaceAllString(template, "{$1}")
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"*"}
+		}
+		boot.Routes = append(boot.Routes, strings.Join(methods, ",")+" "+path)
+		return nil
+	})
+
+	report := boot
+	bootReportReady.Store(&report)
+
+	line, err := json.Marshal(map[string]interface{}{"event": "boot_report", "boot_report": report})
+	if err != nil {
+		log.Printf("⚠️ Warning: failed to encode boot report: %v", err)
+		return
+	}
+	fmt.Println(string(line))
+}
+
+// Get the boot report of this process
+func getBootReportHandler(w http.ResponseWriter, r *http.Request) {
+	report := bootReportReady.Load()
+	if report == nil {
+		w.Header().Set("Retry-After", "5")
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("server_starting"), nil)
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, i18n.M("boot_report_retrieved"), report)
+}
+
+// Helper function to express a duration in fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,108 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"hoctap-api/database"
+)
+
+// Publisher sends messages to a message broker
+type Publisher interface {
+	// Publish sends body to topic. Key identifies the entity the message is about; brokers
+	// that partition topics keep the messages of one key in order.
+	Publish(ctx context.Context, topic, key string, body []byte) error
+	// Ping tells whether the broker is reachable
+	Ping(ctx context.Context) error
+	// Close releases the connection to the broker
+	Close() error
+}
+
+// SchemaVersions is the version of the data schema of every event. Bump the version of an
+// event when its data changes in a way consumers must know about, such as a removed or
+// retyped field; adding a field keeps the version.
+var SchemaVersions = map[string]int{
+	database.EventUserCreated: 1,
+	database.EventUserUpdated: 1,
+	database.EventUserDeleted: 1,
+}
+
+// Envelope is the JSON body of every message
+type Envelope struct {
+	// ID is the outbox event ID, the same when an event is published again
+	ID            string          `json:"id"`
+	Event         string          `json:"event"`
+	SchemaVersion int             `json:"schema_version"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Events publishes outbox events to a broker, each on the topic named by a template
+type Events struct {
+	publisher Publisher
+	topic     string
+}
+
+// NewEvents creates an event publisher. The topic template may use {event} (user.created),
+// {entity} (user), {action} (created) and {version} (the schema version).
+func NewEvents(publisher Publisher, topic string) *Events {
+	return &Events{publisher: publisher, topic: topic}
+}
+
+// Publish sends an outbox event, keyed by the ID of the entity it is about
+func (e *Events) Publish(ctx context.Context, event database.OutboxEvent) error {
+	version, ok := SchemaVersions[event.Event]
+	if !ok {
+		return fmt.Errorf("no schema version for event %s", event.Event)
+	}
+
+	body, err := json.Marshal(Envelope{
+		ID:            event.EventID,
+		Event:         event.Event,
+		SchemaVersion: version,
+		OccurredAt:    event.CreatedAt.UTC(),
+		Data:          event.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %v", event.Event, err)
+	}
+
+	// Every event is about an entity with an ID
+	var entity struct {
+		ID json.Number `json:"id"`
+	}
+	if err := json.Unmarshal(event.Payload, &entity); err != nil {
+		return fmt.Errorf("invalid %s event %s: %v", event.Event, event.EventID, err)
+	}
+
+	topic := Topic(e.topic, event.Event, version)
+	if err := e.publisher.Publish(ctx, topic, entity.ID.String(), body); err != nil {
+		return fmt.Errorf("failed to publish %s to %s: %v", event.Event, topic, err)
+	}
+	return nil
+}
+
+// Ping tells whether the broker is reachable
+func (e *Events) Ping(ctx context.Context) error {
+	return e.publisher.Ping(ctx)
+}
+
+// Close releases the connection to the broker
+func (e *Events) Close() error {
+	return e.publisher.Close()
+}
+
+// Topic names the topic of an event from a template (see NewEvents)
+func Topic(template, event string, version int) string {
+	entity, action, _ := strings.Cut(event, ".")
+	return strings.NewReplacer(
+		"{event}", event,
+		"{entity}", entity,
+		"{action}", action,
+		"{version}", strconv.Itoa(version),
+	).Replace(template)
+}
@@ -0,0 +1,122 @@
+package broker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaContentType is the embedded JSON format of the REST Proxy v2 API
+const kafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// Kafka publishes to Kafka topics through a Kafka REST Proxy (the Confluent REST Proxy or
+// a compatible one such as Redpanda's), so no client library is needed. Messages with the
+// same key go to the same partition.
+type Kafka struct {
+	endpoint *url.URL
+	user     *url.Userinfo
+	client   *http.Client
+}
+
+// NewKafka returns a publisher for a REST Proxy URL such as http://kafka-rest:8082, with
+// optional basic auth credentials in the URL. The proxy is not contacted until the first
+// publish.
+func NewKafka(rawURL string) (*Kafka, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, fmt.Errorf("invalid Kafka REST Proxy URL '%s'", rawURL)
+	}
+
+	user := parsed.User
+	parsed.User = nil
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	return &Kafka{endpoint: parsed, user: user, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Publish produces one record to topic and checks the proxy accepted it
+func (k *Kafka) Publish(ctx context.Context, topic, key string, body []byte) error {
+	records := map[string]interface{}{
+		"records": []map[string]interface{}{{"key": key, "value": json.RawMessage(body)}},
+	}
+	payload, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	resp, err := k.do(ctx, http.MethodPost, "/topics/"+url.PathEscape(topic), payload)
+	if err != nil {
+		return err
+	}
+
+	// The proxy reports per-record failures in a 200 response
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("invalid REST Proxy response: %v", err)
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil || offset.Error != "" {
+			return fmt.Errorf("kafka rejected the record: %s", offset.Error)
+		}
+	}
+	return nil
+}
+
+// Ping tells whether the proxy reaches the cluster by listing the topics
+func (k *Kafka) Ping(ctx context.Context) error {
+	_, err := k.do(ctx, http.MethodGet, "/topics", nil)
+	return err
+}
+
+// Close does nothing; idle connections to the proxy time out on their own
+func (k *Kafka) Close() error {
+	return nil
+}
+
+// do sends a request to the proxy and returns the body of a successful response
+func (k *Kafka) do(ctx context.Context, method, path string, payload []byte) ([]byte, error) {
+	endpoint := *k.endpoint
+	endpoint.Path += path
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if payload != nil {
+		req.Header.Set("Content-Type", kafkaContentType)
+	}
+	if k.user != nil {
+		pass, _ := k.user.Password()
+		req.SetBasicAuth(k.user.Username(), pass)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var proxyErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(body, &proxyErr)
+		return nil, fmt.Errorf("REST Proxy responded with status %d: %s", resp.StatusCode, proxyErr.Message)
+	}
+	return body, nil
+}
@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Backend stores cached values shared by every API instance
+type Backend interface {
+	// Get returns the value of key; found is false when the key is missing or expired
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set stores value under key for ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes keys
+	Delete(ctx context.Context, keys ...string) error
+	// Incr atomically increments the counter at key and returns the new value
+	Incr(ctx context.Context, key string) (int64, error)
+	// Ping checks that the backend answers
+	Ping(ctx context.Context) error
+	// Close releases the backend's connections
+	Close() error
+}
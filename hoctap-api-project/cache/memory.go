@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryShards spreads keys over independently locked maps to limit lock contention
+const memoryShards = 16
+
+// memoryEntry is a cached value; a zero expiry never expires
+type memoryEntry struct {
+	value   []byte
+	expires time.Time
+}
+
+// memoryShard is one locked part of the cache
+type memoryShard struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+}
+
+// memoryBackend is a Backend inside the process, for deployments without Redis. Each
+// instance has its own cache, so writes only invalidate the instance that made them; keep
+// the TTLs short when running several instances.
+type memoryBackend struct {
+	shards      [memoryShards]memoryShard
+	maxPerShard int
+}
+
+// NewMemory creates an in-process backend holding up to maxEntries values. When a shard is
+// full, expired entries are dropped first, then arbitrary ones. Entries without expiry (the
+// list generation counter) are never evicted, so invalidation cannot be undone.
+func NewMemory(maxEntries int) Backend {
+	mb := &memoryBackend{maxPerShard: max(maxEntries/memoryShards, 1)}
+	for i := range mb.shards {
+		mb.shards[i].entries = map[string]memoryEntry{}
+	}
+	return mb
+}
+
+// Helper function to pick the shard of a key
+func (mb *memoryBackend) shard(key string) *memoryShard {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return &mb.shards[hash.Sum32()%memoryShards]
+}
+
+func (mb *memoryBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	shard := mb.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	entry, ok := shard.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !entry.expires.IsZero() && !time.Now().Before(entry.expires) {
+		delete(shard.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (mb *memoryBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	entry := memoryEntry{value: value}
+	if ttl > 0 {
+		entry.expires = time.Now().Add(ttl)
+	}
+
+	shard := mb.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	mb.store(shard, key, entry)
+	return nil
+}
+
+func (mb *memoryBackend) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		shard := mb.shard(key)
+		shard.mu.Lock()
+		delete(shard.entries, key)
+		shard.mu.Unlock()
+	}
+	return nil
+}
+
+func (mb *memoryBackend) Incr(ctx context.Context, key string) (int64, error) {
+	shard := mb.shard(key)
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	// Like Redis, a missing counter starts at zero and keeps no expiry
+	var n int64
+	if entry, ok := shard.entries[key]; ok {
+		var err error
+		if n, err = strconv.ParseInt(string(entry.value), 10, 64); err != nil {
+			return 0, err
+		}
+	}
+	n++
+
+	mb.store(shard, key, memoryEntry{value: []byte(strconv.FormatInt(n, 10))})
+	return n, nil
+}
+
+func (mb *memoryBackend) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (mb *memoryBackend) Close() error {
+	return nil
+}
+
+// Helper function to put an entry in a locked shard, making room when it is full
+func (mb *memoryBackend) store(shard *memoryShard, key string, entry memoryEntry) {
+	if _, exists := shard.entries[key]; !exists && len(shard.entries) >= mb.maxPerShard {
+		now := time.Now()
+		for k, e := range shard.entries {
+			if !e.expires.IsZero() && !now.Before(e.expires) {
+				delete(shard.entries, k)
+			}
+		}
+		// Map iteration order is random, which makes this a random eviction
+		for k, e := range shard.entries {
+			if len(shard.entries) < mb.maxPerShard {
+				break
+			}
+			if !e.expires.IsZero() {
+				delete(shard.entries, k)
+			}
+		}
+	}
+	shard.entries[key] = entry
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisBackend is a Backend on a Redis server
+type redisBackend struct {
+	client *redis.Client
+}
+
+// NewRedis creates a backend for the Redis server at url (redis://[user:password@]host:port/db,
+// or rediss:// for TLS). An unreachable server is only reported: the client reconnects on
+// its own and callers fall back to the database meanwhile.
+func NewRedis(ctx context.Context, url string) (Backend, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %v", err)
+	}
+
+	client := redis.NewClient(options)
+	if err := client.Ping(ctx).Err(); err != nil {
+		log.Printf("⚠️ Warning: Redis at %s is not reachable yet: %v", options.Addr, err)
+	}
+
+	return &redisBackend{client: client}, nil
+}
+
+func (rb *redisBackend) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := rb.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (rb *redisBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return rb.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (rb *redisBackend) Delete(ctx context.Context, keys ...string) error {
+	return rb.client.Del(ctx, keys...).Err()
+}
+
+func (rb *redisBackend) Incr(ctx context.Context, key string) (int64, error) {
+	return rb.client.Incr(ctx, key).Result()
+}
+
+func (rb *redisBackend) Ping(ctx context.Context) error {
+	return rb.client.Ping(ctx).Err()
+}
+
+func (rb *redisBackend) Close() error {
+	return rb.client.Close()
+}
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"hoctap-api/database"
+)
+
+// Cache keys; list results include the list generation, which every write bumps, and a
+// user includes its version, the generation of its last write
+const (
+	keyPrefix         = "hoctap:"
+	listGenerationKey = keyPrefix + "users:generation"
+)
+
+// UserStore caches the reads of a user store: users by ID for userTTL, and lists and counts
+// for listTTL. Writes go to the store and then move the users they wrote to a new version
+// and invalidate every cached list, on all instances sharing the backend. A read that
+// loaded a user before the write can only cache it under the old version, which is no
+// longer read, so it cannot overwrite the newer user. Cache errors are logged and the store
+// is used directly, so an unavailable cache only costs speed.
+type UserStore struct {
+	database.UserStore
+	backend Backend
+	userTTL time.Duration
+	listTTL time.Duration
+}
+
+// NewUserStore wraps store with a cache on backend
+func NewUserStore(store database.UserStore, backend Backend, userTTL, listTTL time.Duration) *UserStore {
+	return &UserStore{UserStore: store, backend: backend, userTTL: userTTL, listTTL: listTTL}
+}
+
+// WithActor keeps the cache around the actor-scoped store
+func (us *UserStore) WithActor(actor database.AuditActor) database.UserStore {
+	scoped := *us
+	scoped.UserStore = us.UserStore.WithActor(actor)
+	return &scoped
+}
+
+// WithTx runs fn in a transaction of the wrapped store. Operations inside it bypass the
+// cache, so reads see the transaction's own writes; once it commits, the users it wrote are
+// evicted and the cached lists invalidated.
+func (us *UserStore) WithTx(ctx context.Context, fn func(tx database.UserStore) error) error {
+	written := map[int]bool{}
+	err := us.UserStore.WithTx(ctx, func(tx database.UserStore) error {
+		return fn(&txWriteRecorder{UserStore: tx, written: written})
+	})
+	if err != nil || len(written) == 0 {
+		return err
+	}
+
+	ids := make([]int, 0, len(written))
+	for id := range written {
+		ids = append(ids, id)
+	}
+	us.invalidate(ctx, ids...)
+	return nil
+}
+
+// GetUserByID returns the cached current version of a user, loading it on a miss
+func (us *UserStore) GetUserByID(ctx context.Context, id int) (*database.User, error) {
+	load := func() (*database.User, error) {
+		return us.UserStore.GetUserByID(ctx, id)
+	}
+
+	version, _, err := us.backend.Get(ctx, userVersionKey(id))
+	if err != nil {
+		log.Printf("⚠️ Warning: cache unavailable: %v", err)
+		return load()
+	}
+	return cached(ctx, us.backend, userKey(id, string(version)), us.userTTL, load)
+}
+
+// GetUserIDByUUID returns the cached ID of a UUID, loading it on a miss. The ID of a UUID
+// never changes, so the entry needs no invalidation; a deleted user is not found by ID.
+func (us *UserStore) GetUserIDByUUID(ctx context.Context, uuid string) (int, error) {
+	return cached(ctx, us.backend, uuidKey(uuid), us.userTTL, func() (int, error) {
+		return us.UserStore.GetUserIDByUUID(ctx, uuid)
+	})
+}
+
+// GetAllUsers returns the cached list of every user
+func (us *UserStore) GetAllUsers(ctx context.Context) ([]database.User, error) {
+	return cachedList(ctx, us, "all", func() ([]database.User, error) {
+		return us.UserStore.GetAllUsers(ctx)
+	})
+}
+
+// ListUsers returns a cached page of users
+func (us *UserStore) ListUsers(ctx context.Context, filter database.UserFilter) ([]database.User, error) {
+	return cachedList(ctx, us, "list:"+filterKey(filter), func() ([]database.User, error) {
+		return us.UserStore.ListUsers(ctx, filter)
+	})
+}
+
+// SearchUsers returns cached typeahead matches of a prefix
+func (us *UserStore) SearchUsers(ctx context.Context, prefix string, limit int) ([]database.User, error) {
+	name := fmt.Sprintf("search:%q:%d", strings.ToLower(prefix), limit)
+	return cachedList(ctx, us, name, func() ([]database.User, error) {
+		return us.UserStore.SearchUsers(ctx, prefix, limit)
+	})
+}
+
+// CountUsers returns the cached count of users matching filter
+func (us *UserStore) CountUsers(ctx context.Context, filter database.UserFilter) (int, error) {
+	filter.Limit, filter.Offset, filter.After, filter.Fields = 0, 0, nil, nil
+	return cachedList(ctx, us, "count:"+filterKey(filter), func() (int, error) {
+		return us.UserStore.CountUsers(ctx, filter)
+	})
+}
+
+// GetUsersCount returns the cached number of users
+func (us *UserStore) GetUsersCount(ctx context.Context) (int, error) {
+	return cachedList(ctx, us, "total", func() (int, error) {
+		return us.UserStore.GetUsersCount(ctx)
+	})
+}
+
+// CountSignups returns the cached signup counts of a time range
+func (us *UserStore) CountSignups(ctx context.Context, from, to time.Time) ([]database.SignupCount, error) {
+	name := fmt.Sprintf("signups:%d:%d", from.UnixNano(), to.UnixNano())
+	return cachedList(ctx, us, name, func() ([]database.SignupCount, error) {
+		return us.UserStore.CountSignups(ctx, from, to)
+	})
+}
+
+// CreateUser creates a user
+func (us *UserStore) CreateUser(ctx context.Context, name, email string) (*database.User, error) {
+	user, err := us.UserStore.CreateUser(ctx, name, email)
+	if err != nil {
+		return nil, err
+	}
+
+	us.invalidate(ctx, user.ID)
+	return user, nil
+}
+
+// UpdateUser updates a user and invalidates its cached version
+func (us *UserStore) UpdateUser(ctx context.Context, id int, name, email string) (*database.User, error) {
+	user, err := us.UserStore.UpdateUser(ctx, id, name, email)
+	if err != nil {
+		return nil, err
+	}
+
+	us.invalidate(ctx, user.ID)
+	return user, nil
+}
+
+// DeleteUser deletes a user and invalidates the cached user
+func (us *UserStore) DeleteUser(ctx context.Context, id int) error {
+	if err := us.UserStore.DeleteUser(ctx, id); err != nil {
+		return err
+	}
+
+	us.invalidate(ctx, id)
+	return nil
+}
+
+// SetLegalHold updates the hold and invalidates the cached user
+func (us *UserStore) SetLegalHold(ctx context.Context, id int, hold bool, reason string) (*database.User, error) {
+	user, err := us.UserStore.SetLegalHold(ctx, id, hold, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	us.invalidate(ctx, user.ID)
+	return user, nil
+}
+
+// SetAvatar updates the avatar URL and invalidates the cached user
+func (us *UserStore) SetAvatar(ctx context.Context, id int, avatarURL string) (*database.User, error) {
+	user, err := us.UserStore.SetAvatar(ctx, id, avatarURL)
+	if err != nil {
+		return nil, err
+	}
+
+	us.invalidate(ctx, user.ID)
+	return user, nil
+}
+
+// Helper function to make every cached list stale by moving to a new generation, and to
+// move the written users to that generation as their version. The unversioned entry of a
+// user read before any write is dropped; one a racing read caches again expires before the
+// version, which is kept for twice the user TTL.
+func (us *UserStore) invalidate(ctx context.Context, ids ...int) {
+	generation, err := us.backend.Incr(ctx, listGenerationKey)
+	if err != nil {
+		log.Printf("⚠️ Warning: failed to invalidate cached user lists: %v", err)
+		return
+	}
+
+	version := []byte(strconv.FormatInt(generation, 10))
+	for _, id := range ids {
+		if err := us.backend.Set(ctx, userVersionKey(id), version, 2*us.userTTL); err != nil {
+			log.Printf("⚠️ Warning: failed to invalidate cached user %d: %v", id, err)
+			continue
+		}
+		if err := us.backend.Delete(ctx, userKey(id, "")); err != nil {
+			log.Printf("⚠️ Warning: failed to evict cached user %d: %v", id, err)
+		}
+	}
+}
+
+// Helper function to cache a list result under the current list generation
+func cachedList[T any](ctx context.Context, us *UserStore, name string, load func() (T, error)) (T, error) {
+	generation, _, err := us.backend.Get(ctx, listGenerationKey)
+	if err != nil {
+		log.Printf("⚠️ Warning: cache unavailable: %v", err)
+		return load()
+	}
+
+	key := fmt.Sprintf("%susers:%s:%s", keyPrefix, generation, name)
+	return cached(ctx, us.backend, key, us.listTTL, load)
+}
+
+// Helper function to return the cached value of key, or load and cache it
+func cached[T any](ctx context.Context, backend Backend, key string, ttl time.Duration, load func() (T, error)) (T, error) {
+	var value T
+
+	data, found, err := backend.Get(ctx, key)
+	if err != nil {
+		log.Printf("⚠️ Warning: cache unavailable: %v", err)
+		return load()
+	}
+	if found && json.Unmarshal(data, &value) == nil {
+		return value, nil
+	}
+
+	value, err = load()
+	if err != nil {
+		return value, err
+	}
+
+	if data, err := json.Marshal(value); err == nil {
+		if err := backend.Set(ctx, key, data, ttl); err != nil {
+			log.Printf("⚠️ Warning: failed to cache %s: %v", key, err)
+		}
+	}
+	return value, nil
+}
+
+// Helper function to build the cache key of a version of a user
+func userKey(id int, version string) string {
+	return keyPrefix + "user:" + strconv.Itoa(id) + ":" + version
+}
+
+// Helper function to build the cache key of the current version of a user
+func userVersionKey(id int) string {
+	return keyPrefix + "user-version:" + strconv.Itoa(id)
+}
+
+// Helper function to build the cache key of the ID of a user UUID
+func uuidKey(uuid string) string {
+	return keyPrefix + "user-uuid:" + strings.ToLower(uuid)
+}
+
+// Helper function to build the cache key part of a list filter
+func filterKey(filter database.UserFilter) string {
+	key := fmt.Sprintf("%d:%d:%q:%s", filter.Limit, filter.Offset, filter.Search, strings.Join(filter.Fields, ","))
+	if filter.After != nil {
+		key += fmt.Sprintf(":%d:%d", filter.After.CreatedAt.UnixNano(), filter.After.ID)
+	}
+	if !filter.CreatedSince.IsZero() {
+		key += fmt.Sprintf(":since:%d", filter.CreatedSince.UnixNano())
+	}
+	return key
+}
+
+// txWriteRecorder notes the users written in a transaction, so UserStore can evict them
+// after the commit
+type txWriteRecorder struct {
+	database.UserStore
+	written map[int]bool
+}
+
+func (r *txWriteRecorder) WithActor(actor database.AuditActor) database.UserStore {
+	return &txWriteRecorder{UserStore: r.UserStore.WithActor(actor), written: r.written}
+}
+
+func (r *txWriteRecorder) WithTx(ctx context.Context, fn func(tx database.UserStore) error) error {
+	return r.UserStore.WithTx(ctx, func(tx database.UserStore) error {
+		return fn(&txWriteRecorder{UserStore: tx, written: r.written})
+	})
+}
+
+func (r *txWriteRecorder) CreateUser(ctx context.Context, name, email string) (*database.User, error) {
+	user, err := r.UserStore.CreateUser(ctx, name, email)
+	if err == nil {
+		r.written[user.ID] = true
+	}
+	return user, err
+}
+
+func (r *txWriteRecorder) UpdateUser(ctx context.Context, id int, name, email string) (*database.User, error) {
+	r.written[id] = true
+	return r.UserStore.UpdateUser(ctx, id, name, email)
+}
+
+func (r *txWriteRecorder) DeleteUser(ctx context.Context, id int) error {
+	r.written[id] = true
+	return r.UserStore.DeleteUser(ctx, id)
+}
+
+func (r *txWriteRecorder) SetLegalHold(ctx context.Context, id int, hold bool, reason string) (*database.User, error) {
+	r.written[id] = true
+	return r.UserStore.SetLegalHold(ctx, id, hold, reason)
+}
+
+func (r *txWriteRecorder) SetAvatar(ctx context.Context, id int, avatarURL string) (*database.User, error) {
+	r.written[id] = true
+	return r.UserStore.SetAvatar(ctx, id, avatarURL)
+}
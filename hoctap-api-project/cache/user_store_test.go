@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/database/mocks"
+
+	"go.uber.org/mock/gomock"
+)
+
+// TestUserStoreReadRacingWrite checks that a read which loaded a user before an update
+// cannot leave the old user in the cache once the update is done
+func TestUserStoreReadRacingWrite(t *testing.T) {
+	ctx := context.Background()
+	store := mocks.NewMockUserStore(gomock.NewController(t))
+	users := NewUserStore(store, NewMemory(100), time.Minute, time.Minute)
+
+	before := &database.User{ID: 7, Name: "Lan", Email: "lan@example.com"}
+	after := &database.User{ID: 7, Name: "Lan Nguyen", Email: "lan@example.com"}
+
+	// The first read loads the old user and is held until the update is done
+	loaded, release := make(chan struct{}), make(chan struct{})
+	gomock.InOrder(
+		store.EXPECT().GetUserByID(gomock.Any(), 7).DoAndReturn(func(context.Context, int) (*database.User, error) {
+			close(loaded)
+			<-release
+			return before, nil
+		}),
+		store.EXPECT().GetUserByID(gomock.Any(), 7).Return(after, nil),
+	)
+	store.EXPECT().UpdateUser(gomock.Any(), 7, after.Name, after.Email).Return(after, nil)
+
+	read := make(chan *database.User)
+	go func() {
+		user, _ := users.GetUserByID(ctx, 7)
+		read <- user
+	}()
+
+	<-loaded
+	if _, err := users.UpdateUser(ctx, 7, after.Name, after.Email); err != nil {
+		t.Fatalf("UpdateUser failed: %v", err)
+	}
+	close(release)
+	if user := <-read; user.Name != before.Name {
+		t.Fatalf("racing read = %q, want %q", user.Name, before.Name)
+	}
+
+	for i := 0; i < 2; i++ {
+		user, err := users.GetUserByID(ctx, 7)
+		if err != nil {
+			t.Fatalf("GetUserByID failed: %v", err)
+		}
+		if user.Name != after.Name {
+			t.Errorf("GetUserByID = %q, want %q", user.Name, after.Name)
+		}
+	}
+}
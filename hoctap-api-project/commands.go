@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"hoctap-api/database"
+
+	"github.com/gorilla/mux"
+)
+
+// command is a `hoctap-api` subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+// Subcommands. Without one the server is started, as `hoctap-api serve` would.
+var commands = []command{
+	{"serve", "Run the API server (-migrate, -seed)", runServe},
+	{"migrate", "Manage the schema: up | down [steps] | version | force <version>", runMigrate},
+	{"seed", "Insert the initial users when the users table is empty", runSeed},
+	{"routes", "List the HTTP routes", runRoutes},
+	{"smoketest", "Check health, auth and user CRUD of a deployment (--base-url=...)", runSmokeTest},
+}
+
+const migrateUsage = "usage: hoctap-api migrate up | down [steps] | version | force <version>"
+
+// Dispatch the command line to a subcommand
+func runCommand(args []string) error {
+	if len(args) == 0 {
+		return runServe(nil)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+
+	printUsage()
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		return nil
+	}
+	return fmt.Errorf("unknown command '%s'", args[0])
+}
+
+// Print the list of subcommands
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "usage: hoctap-api <command> [arguments]")
+	fmt.Fprintln(os.Stderr)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// Run `hoctap-api migrate ...` against the configured database
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	if err := database.InitDB(); err != nil {
+		return err
+	}
+	defer database.CloseDB()
+
+	switch args[0] {
+	case "up":
+		return migrateAll()
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			value, err := strconv.Atoi(args[1])
+			if err != nil || value <= 0 {
+				return fmt.Errorf("invalid number of steps '%s'", args[1])
+			}
+			steps = value
+		}
+		if err := database.MigrateDown(steps); err != nil {
+			return err
+		}
+		log.Printf("✅ Rolled back %d migration(s)", steps)
+		return nil
+	case "version":
+		version, dirty, err := database.MigrationVersion()
+		if err != nil {
+			return err
+		}
+		log.Printf("📦 Schema version: %d (dirty: %t)", version, dirty)
+		return nil
+	case "force":
+		if len(args) < 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.Atoi(args[1])
+		if err != nil || version < 0 {
+			return fmt.Errorf("invalid version '%s'", args[1])
+		}
+		if err := database.ForceMigrationVersion(version); err != nil {
+			return err
+		}
+		log.Printf("✅ Schema version forced to %d", version)
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// Run `hoctap-api seed`
+func runSeed(args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: hoctap-api seed")
+	}
+
+	if err := database.InitDB(); err != nil {
+		return err
+	}
+	defer database.CloseDB()
+
+	return seedUsers(database.NewUserRepository())
+}
+
+// Helper function to insert the initial users
+func seedUsers(users *database.UserRepository) error {
+	log.Println("🌱 Seeding initial users...")
+	if err := users.SeedUsers(context.Background()); err != nil {
+		return fmt.Errorf("failed to seed users: %v", err)
+	}
+
+	log.Println("✅ Initial users seeded successfully")
+	return nil
+}
+
+// Run `hoctap-api routes`, which prints the route table without touching the database
+func runRoutes(args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: hoctap-api routes")
+	}
+
+	out := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(out, "METHOD\tPATH")
+
+	err := newRouter().Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		// Subrouter mounts have no handler of their own
+		if route.GetHandler() == nil {
+			return nil
+		}
+
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{"*"}
+		}
+
+		fmt.Fprintf(out, "%s\t%s\n", strings.Join(methods, ","), template)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return out.Flush()
+}
@@ -0,0 +1,76 @@
+package concurrency
+
+import "sync"
+
+// Limiter caps how many requests each client may have in flight at once. Unlike a rate
+// limit it does not care how many requests a client makes, only how many run together, so
+// a client streaming several large exports cannot hold every database connection.
+type Limiter struct {
+	mu       sync.Mutex
+	limit    int
+	inFlight map[string]int
+	rejected int64
+}
+
+// New creates a limiter of limit concurrent requests per client; limit <= 0 disables it
+func New(limit int) *Limiter {
+	return &Limiter{limit: limit, inFlight: make(map[string]int)}
+}
+
+// SetLimit changes the concurrent requests allowed per client. Requests already running
+// keep their slot; a lower limit applies as they finish.
+func (l *Limiter) SetLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+}
+
+// Acquire takes a slot for client, reporting false when the client is at its limit. Every
+// successful Acquire must be followed by a Release.
+func (l *Limiter) Acquire(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit > 0 && l.inFlight[client] >= l.limit {
+		l.rejected++
+		return false
+	}
+	l.inFlight[client]++
+	return true
+}
+
+// Release frees a slot taken by Acquire
+func (l *Limiter) Release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Idle clients are forgotten, so the map only holds clients with requests running
+	if l.inFlight[client] <= 1 {
+		delete(l.inFlight, client)
+		return
+	}
+	l.inFlight[client]--
+}
+
+// Stats is a snapshot of the limiter for monitoring
+type Stats struct {
+	Limit    int   `json:"limit"`
+	Clients  int   `json:"clients"`
+	InFlight int   `json:"in_flight"`
+	Busiest  int   `json:"busiest"`
+	Rejected int64 `json:"rejected"`
+}
+
+// Stats returns the clients with requests running, their total, the most any one client
+// runs and how many requests were rejected since startup
+func (l *Limiter) Stats() Stats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := Stats{Limit: l.limit, Clients: len(l.inFlight), Rejected: l.rejected}
+	for _, n := range l.inFlight {
+		stats.InFlight += n
+		stats.Busiest = max(stats.Busiest, n)
+	}
+	return stats
+}
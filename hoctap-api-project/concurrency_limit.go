@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"hoctap-api/concurrency"
+	"hoctap-api/config"
+	"hoctap-api/i18n"
+)
+
+// errorCodeConcurrencyLimit marks the 429 of a client over API_CONCURRENCY_PER_CLIENT, so
+// clients can tell it from a quota and retry as soon as one of their requests finishes
+const errorCodeConcurrencyLimit = "concurrency_limit_exceeded"
+
+// concurrencyLimiter caps the requests each client runs at once, separately from the quotas
+var concurrencyLimiter *concurrency.Limiter
+
+// Middleware rejecting API and GraphQL requests with a 429 while the client already runs
+// API_CONCURRENCY_PER_CLIENT of them. Pages, health checks and static files are not limited.
+func limitConcurrentRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/graphql" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		client := concurrencyClient(r)
+		if !concurrencyLimiter.Acquire(client) {
+			w.Header().Set("Retry-After", "1")
+			sendJSONErrorWithCode(w, http.StatusTooManyRequests, errorCodeConcurrencyLimit, i18n.M("concurrency_limit_exceeded"))
+			return
+		}
+		defer concurrencyLimiter.Release(client)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Helper function to identify the client a request counts against: the API key or admin
+// token it carries, its client certificate, or else its IP. Only credentials that are
+// actually configured count, so inventing keys does not get a client more slots.
+func concurrencyClient(r *http.Request) string {
+	provided := r.Header.Get("X-API-Key")
+	if provided == "" {
+		provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+
+	if provided != "" {
+		cfg := config.Current()
+		for _, key := range cfg.PublicStats.APIKeys {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(key)) == 1 {
+				return "key:" + key
+			}
+		}
+		if token := cfg.Admin.APIToken; token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1 {
+			return "admin"
+		}
+	}
+	if identity, ok := clientIdentity(r.Context()); ok {
+		return "service:" + identity
+	}
+	return "ip:" + clientIP(r)
+}
@@ -0,0 +1,907 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hoctap-api/scheduler"
+	"hoctap-api/secrets"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the typed application configuration. Values are layered, later sources winning:
+// the `default` tags, the YAML file (CONFIG_FILE, default config.yaml), config.env and the
+// process environment. Fields tagged `secret:"true"` may hold a secret reference (see
+// secrets.Resolve) that is fetched once every source is applied. Fields tagged `reload:"true"`
+// are updated on SIGHUP and when secrets are refetched; changes to any other field are
+// reported and need a restart.
+type Config struct {
+	Environment string `yaml:"environment" env:"ENVIRONMENT" default:"development"`
+
+	Server      ServerConfig      `yaml:"server"`
+	Database    DatabaseConfig    `yaml:"database"`
+	Admin       AdminConfig       `yaml:"admin"`
+	Events      EventsConfig      `yaml:"events"`
+	Experiments ExperimentsConfig `yaml:"experiments"`
+	Retention   RetentionConfig   `yaml:"retention"`
+	API         APIConfig         `yaml:"api"`
+	Secrets     SecretsConfig     `yaml:"secrets"`
+	Mock        MockConfig        `yaml:"mock"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Health      HealthConfig      `yaml:"health"`
+	PublicStats PublicStatsConfig `yaml:"public_stats"`
+	Analytics   AnalyticsConfig   `yaml:"analytics"`
+	MTLS        MTLSConfig        `yaml:"mtls"`
+	Avatar      AvatarConfig      `yaml:"avatar"`
+	Email       EmailConfig       `yaml:"email"`
+	Storage     StorageConfig     `yaml:"storage"`
+	Watchdog    WatchdogConfig    `yaml:"watchdog"`
+	Mail        MailConfig        `yaml:"mail"`
+	Jobs        JobsConfig        `yaml:"jobs"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Broker      BrokerConfig      `yaml:"broker"`
+	Scheduler   SchedulerConfig   `yaml:"scheduler"`
+}
+
+// ServerConfig holds the listeners
+type ServerConfig struct {
+	// Port is the TCP port of the API, or "off" to serve only on ListenSocket
+	Port     string `yaml:"port" env:"SERVER_PORT" default:"8080"`
+	GRPCPort string `yaml:"grpc_port" env:"GRPC_PORT"`
+	// ListenSocket serves the API on a unix socket too, in plain HTTP for a local proxy;
+	// ListenSocketMode is the octal file mode of the socket
+	ListenSocket     string `yaml:"listen_socket" env:"LISTEN_SOCKET"`
+	ListenSocketMode string `yaml:"listen_socket_mode" env:"LISTEN_SOCKET_MODE" default:"0660"`
+	// AdminPort moves the ops endpoints and pprof to a second listener that can be firewalled
+	AdminPort string `yaml:"admin_port" env:"ADMIN_PORT"`
+	// TLSCert and TLSKey serve Port over HTTPS with a certificate from files
+	TLSCert string `yaml:"tls_cert" env:"TLS_CERT"`
+	TLSKey  string `yaml:"tls_key" env:"TLS_KEY"`
+	// AutocertDomains serve Port over HTTPS with Let's Encrypt certificates for these hosts,
+	// kept in AutocertCacheDir across restarts
+	AutocertDomains  []string `yaml:"autocert_domains" env:"AUTOCERT_DOMAINS"`
+	AutocertEmail    string   `yaml:"autocert_email" env:"AUTOCERT_EMAIL"`
+	AutocertCacheDir string   `yaml:"autocert_cache_dir" env:"AUTOCERT_CACHE_DIR" default:"autocert-cache"`
+	// HTTPRedirectPort, with TLS, is a plain HTTP listener redirecting to HTTPS. It also
+	// answers the Let's Encrypt HTTP challenges, which arrive on port 80.
+	HTTPRedirectPort string `yaml:"http_redirect_port" env:"HTTP_REDIRECT_PORT"`
+	// ShutdownTimeout is how long active requests may run after SIGINT/SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" default:"30s"`
+	// PreStopDelay keeps serving normally after SIGINT/SIGTERM while /lb-health already
+	// reports draining, giving load balancers time to stop routing before the drain starts
+	PreStopDelay time.Duration `yaml:"pre_stop_delay" env:"PRE_STOP_DELAY" default:"0s"`
+	// TrustedProxies are the addresses or CIDR ranges of the proxies in front of the API. Only
+	// their X-Forwarded-For header is believed; other clients are identified by their address.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" reload:"true"`
+}
+
+// ListensTCP reports whether the API listens on a TCP port
+func (s ServerConfig) ListensTCP() bool {
+	return s.Port != "off"
+}
+
+// TrustsProxy reports whether ip is in TrustedProxies
+func (s ServerConfig) TrustsProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+
+	for _, entry := range s.TrustedProxies {
+		if prefix, err := parseProxyPrefix(entry); err == nil && prefix.Contains(addr.Unmap()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Helper function to parse a TRUSTED_PROXIES entry, an address or a CIDR range
+func parseProxyPrefix(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		return netip.ParsePrefix(entry)
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// TLSEnabled reports whether Port serves HTTPS
+func (s ServerConfig) TLSEnabled() bool {
+	return s.TLSCert != "" || len(s.AutocertDomains) > 0
+}
+
+// DatabaseConfig holds the connection, failover and caching settings. Port and User default
+// per driver (3306/root for MySQL, 5432/postgres for PostgreSQL) when left empty. User and
+// Password are read whenever a connection is opened, so rotated credentials apply live.
+type DatabaseConfig struct {
+	Driver                string        `yaml:"driver" env:"DB_DRIVER" default:"mysql"`
+	Host                  string        `yaml:"host" env:"DB_HOST" default:"localhost"`
+	Port                  string        `yaml:"port" env:"DB_PORT"`
+	User                  string        `yaml:"user" env:"DB_USER" secret:"true" reload:"true"`
+	Password              string        `yaml:"password" env:"DB_PASSWORD" secret:"true" reload:"true"`
+	Name                  string        `yaml:"name" env:"DB_NAME" default:"hoctap_api"`
+	SSLMode               string        `yaml:"sslmode" env:"DB_SSLMODE" default:"disable"`
+	SecondaryHost         string        `yaml:"secondary_host" env:"DB_SECONDARY_HOST"`
+	SecondaryPort         string        `yaml:"secondary_port" env:"DB_SECONDARY_PORT"`
+	FailoverCheckInterval time.Duration `yaml:"failover_check_interval" env:"DB_FAILOVER_CHECK_INTERVAL" default:"5s"`
+	FailoverThreshold     int           `yaml:"failover_threshold" env:"DB_FAILOVER_THRESHOLD" default:"3"`
+	AutoMigrate           bool          `yaml:"auto_migrate" env:"DB_AUTO_MIGRATE" default:"true"`
+	NegativeCacheTTL      time.Duration `yaml:"negative_cache_ttl" env:"NEGATIVE_CACHE_TTL" default:"30s"`
+	ConnMaxLifetime       time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" default:"30m"`
+	ConnectAttempts       int           `yaml:"connect_attempts" env:"DB_CONNECT_ATTEMPTS" default:"10"`
+	ConnectMaxWait        time.Duration `yaml:"connect_max_wait" env:"DB_CONNECT_MAX_WAIT" default:"30s"`
+	BreakerThreshold      int           `yaml:"breaker_threshold" env:"DB_BREAKER_THRESHOLD" default:"5"`
+	BreakerProbeInterval  time.Duration `yaml:"breaker_probe_interval" env:"DB_BREAKER_PROBE_INTERVAL" default:"2s"`
+	ReplicaDSNs           []string      `yaml:"replica_dsns" env:"DB_REPLICA_DSN"`
+	ReplicaCheckInterval  time.Duration `yaml:"replica_check_interval" env:"DB_REPLICA_CHECK_INTERVAL" default:"5s"`
+}
+
+// AdminConfig holds the admin API settings
+type AdminConfig struct {
+	APIToken string `yaml:"api_token" env:"ADMIN_API_TOKEN" secret:"true" reload:"true"`
+}
+
+// EventsConfig holds the analytics event pipeline settings
+type EventsConfig struct {
+	SampleRate     float64 `yaml:"sample_rate" env:"EVENTS_SAMPLE_RATE" default:"1.0" reload:"true"`
+	QuotaPerMinute int     `yaml:"quota_per_minute" env:"EVENTS_QUOTA_PER_MINUTE" default:"600" reload:"true"`
+}
+
+// ExperimentsConfig holds the A/B experiment settings
+type ExperimentsConfig struct {
+	File string `yaml:"file" env:"EXPERIMENTS_FILE" default:"experiments.json"`
+}
+
+// RetentionConfig holds the data retention settings
+type RetentionConfig struct {
+	Interval time.Duration `yaml:"interval" env:"RETENTION_INTERVAL" default:"24h"`
+}
+
+// APIConfig holds the API lifecycle settings
+type APIConfig struct {
+	LegacyDeprecatedAt string `yaml:"legacy_deprecated_at" env:"API_LEGACY_DEPRECATED_AT" default:"2026-10-17"`
+	LegacySunset       string `yaml:"legacy_sunset" env:"API_LEGACY_SUNSET" default:"2027-04-30"`
+	// Numeric user IDs in URLs are deprecated in favor of UUIDs, and rejected after the sunset
+	NumericUserIDDeprecatedAt string `yaml:"numeric_user_id_deprecated_at" env:"API_NUMERIC_USER_ID_DEPRECATED_AT" default:"2026-10-17"`
+	NumericUserIDSunset       string `yaml:"numeric_user_id_sunset" env:"API_NUMERIC_USER_ID_SUNSET" default:"2027-04-30"`
+	// IdempotencyTTL is how long the response to a POST with an Idempotency-Key is replayed
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl" env:"API_IDEMPOTENCY_TTL" default:"24h" reload:"true"`
+	// IdempotencyMaxBodyBytes caps the body of a POST with an Idempotency-Key, which is read
+	// whole to fingerprint it before the handler runs
+	IdempotencyMaxBodyBytes int `yaml:"idempotency_max_body_bytes" env:"API_IDEMPOTENCY_MAX_BODY_BYTES" default:"8388608" reload:"true"`
+	// ConcurrencyPerClient is how many API requests one API key, client certificate or IP
+	// may run at once; 0 disables the limit
+	ConcurrencyPerClient int `yaml:"concurrency_per_client" env:"API_CONCURRENCY_PER_CLIENT" default:"8" reload:"true"`
+}
+
+// APILifecycle holds the API lifecycle dates, parsed
+type APILifecycle struct {
+	LegacyDeprecatedAt        time.Time
+	LegacySunset              time.Time
+	NumericUserIDDeprecatedAt time.Time
+	NumericUserIDSunset       time.Time
+}
+
+// Lifecycle parses the YYYY-MM-DD lifecycle dates, reporting every invalid one
+func (a APIConfig) Lifecycle() (APILifecycle, error) {
+	var lifecycle APILifecycle
+	var problems []string
+	for _, date := range []struct {
+		name   string
+		value  string
+		parsed *time.Time
+	}{
+		{"API_LEGACY_DEPRECATED_AT", a.LegacyDeprecatedAt, &lifecycle.LegacyDeprecatedAt},
+		{"API_LEGACY_SUNSET", a.LegacySunset, &lifecycle.LegacySunset},
+		{"API_NUMERIC_USER_ID_DEPRECATED_AT", a.NumericUserIDDeprecatedAt, &lifecycle.NumericUserIDDeprecatedAt},
+		{"API_NUMERIC_USER_ID_SUNSET", a.NumericUserIDSunset, &lifecycle.NumericUserIDSunset},
+	} {
+		parsed, err := time.Parse(time.DateOnly, date.value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be a YYYY-MM-DD date, got '%s'", date.name, date.value))
+			continue
+		}
+		*date.parsed = parsed
+	}
+
+	if len(problems) > 0 {
+		return APILifecycle{}, errors.New(strings.Join(problems, "; "))
+	}
+	return lifecycle, nil
+}
+
+// SecretsConfig holds the secret backend settings
+type SecretsConfig struct {
+	RefreshInterval time.Duration `yaml:"refresh_interval" env:"SECRETS_REFRESH_INTERVAL" default:"1h"`
+}
+
+// CacheConfig holds the user read cache settings. Backend is "redis", "memory" or empty, which
+// means Redis when RedisURL is set and no cache otherwise.
+type CacheConfig struct {
+	Backend    string        `yaml:"backend" env:"CACHE_BACKEND"`
+	RedisURL   string        `yaml:"redis_url" env:"REDIS_URL" secret:"true"`
+	MaxEntries int           `yaml:"max_entries" env:"CACHE_MAX_ENTRIES" default:"10000"`
+	UserTTL    time.Duration `yaml:"user_ttl" env:"CACHE_USER_TTL" default:"5m"`
+	ListTTL    time.Duration `yaml:"list_ttl" env:"CACHE_LIST_TTL" default:"30s"`
+}
+
+// MockConfig holds the settings of `serve -mock`
+type MockConfig struct {
+	Users     int           `yaml:"users" env:"MOCK_USERS" default:"50"`
+	Latency   time.Duration `yaml:"latency" env:"MOCK_LATENCY"`
+	ErrorRate float64       `yaml:"error_rate" env:"MOCK_ERROR_RATE"`
+}
+
+// HealthConfig holds the dependency checks of /health
+type HealthConfig struct {
+	// CacheTTL is how long a dependency report is reused, so health polling does not load them
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"HEALTH_CACHE_TTL" default:"5s"`
+}
+
+// PublicStatsConfig holds the key-authenticated stats API for partners and marketing sites
+type PublicStatsConfig struct {
+	// APIKeys are the accepted keys; the API is disabled without any
+	APIKeys      []string      `yaml:"api_keys" env:"PUBLIC_STATS_API_KEYS" reload:"true"`
+	QuotaPerHour int           `yaml:"quota_per_hour" env:"PUBLIC_STATS_QUOTA_PER_HOUR" default:"1000" reload:"true"`
+	CacheTTL     time.Duration `yaml:"cache_ttl" env:"PUBLIC_STATS_CACHE_TTL" default:"5m"`
+}
+
+// AnalyticsConfig holds the analytics summary of the dashboard
+type AnalyticsConfig struct {
+	CacheTTL time.Duration `yaml:"cache_ttl" env:"ANALYTICS_CACHE_TTL" default:"1m"`
+}
+
+// AvatarConfig holds the user avatar uploads
+type AvatarConfig struct {
+	MaxUploadBytes int `yaml:"max_upload_bytes" env:"AVATAR_MAX_UPLOAD_BYTES" default:"5242880"`
+	// Size is the width and height avatars are scaled to
+	Size int `yaml:"size" env:"AVATAR_SIZE" default:"256"`
+}
+
+// EmailConfig holds the validation of user email addresses
+type EmailConfig struct {
+	// CheckMX rejects addresses whose domain does not receive mail, looked up in DNS
+	CheckMX   bool          `yaml:"check_mx" env:"EMAIL_CHECK_MX" default:"false" reload:"true"`
+	MXTimeout time.Duration `yaml:"mx_timeout" env:"EMAIL_MX_TIMEOUT" default:"3s" reload:"true"`
+}
+
+// StorageConfig holds the object storage of uploaded files. Backend is "local" (files
+// below Dir) or "s3" (AWS S3, MinIO or another S3-compatible server).
+type StorageConfig struct {
+	Backend string `yaml:"backend" env:"STORAGE_BACKEND" default:"local"`
+	Dir     string `yaml:"dir" env:"STORAGE_DIR" default:"uploads"`
+	// SigningKey signs the download URLs of the local backend; a random key is used when
+	// empty, which invalidates handed-out URLs on restart
+	SigningKey string `yaml:"signing_key" env:"STORAGE_SIGNING_KEY" secret:"true"`
+	// URLTTL is how long signed download URLs stay valid
+	URLTTL time.Duration `yaml:"url_ttl" env:"STORAGE_URL_TTL" default:"1h"`
+	// S3Endpoint is the server URL, e.g. http://minio:9000; empty means AWS S3 in S3Region
+	S3Endpoint  string `yaml:"s3_endpoint" env:"STORAGE_S3_ENDPOINT"`
+	S3Region    string `yaml:"s3_region" env:"STORAGE_S3_REGION" default:"us-east-1"`
+	S3Bucket    string `yaml:"s3_bucket" env:"STORAGE_S3_BUCKET"`
+	S3AccessKey string `yaml:"s3_access_key" env:"STORAGE_S3_ACCESS_KEY" secret:"true"`
+	S3SecretKey string `yaml:"s3_secret_key" env:"STORAGE_S3_SECRET_KEY" secret:"true"`
+	// S3PathStyle addresses the bucket in the path instead of the host name, as MinIO needs
+	S3PathStyle bool `yaml:"s3_path_style" env:"STORAGE_S3_PATH_STYLE"`
+}
+
+// WatchdogConfig holds the resource leak watchdog
+type WatchdogConfig struct {
+	// Interval between samples of goroutines, database connections and file descriptors;
+	// 0 disables the watchdog
+	Interval time.Duration `yaml:"interval" env:"WATCHDOG_INTERVAL" default:"30s"`
+	// Window is how many consecutive samples must grow before a leak is reported
+	Window int `yaml:"window" env:"WATCHDOG_WINDOW" default:"10"`
+}
+
+// MailConfig holds outgoing email over SMTP; no email is sent while SMTPHost is empty
+type MailConfig struct {
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" default:"587"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	// SMTPTLS is starttls, tls (implicit TLS, usually port 465) or none (local relays only)
+	SMTPTLS string `yaml:"smtp_tls" env:"SMTP_TLS" default:"starttls"`
+	From    string `yaml:"from" env:"MAIL_FROM" default:"HocTap <no-reply@hoctap.local>"`
+	// BaseURL is the public URL of the application that links in emails point to
+	BaseURL string `yaml:"base_url" env:"MAIL_BASE_URL" default:"http://localhost:8080"`
+}
+
+// JobsConfig holds the background job queue
+type JobsConfig struct {
+	// Workers is how many jobs this instance runs at once
+	Workers int `yaml:"workers" env:"JOBS_WORKERS" default:"4"`
+	// PollInterval is how often idle workers look for due jobs enqueued by other instances
+	PollInterval time.Duration `yaml:"poll_interval" env:"JOBS_POLL_INTERVAL" default:"1s"`
+	// Lease is how long a job may run before it is presumed lost and claimed again
+	Lease time.Duration `yaml:"lease" env:"JOBS_LEASE" default:"5m"`
+}
+
+// OutboxConfig holds the relay publishing the events recorded in the outbox
+type OutboxConfig struct {
+	// PollInterval is how often the relay looks for events recorded by other instances
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" default:"1s"`
+	// Lease is how long a relay may take to publish the events it claimed before another takes them
+	Lease time.Duration `yaml:"lease" env:"OUTBOX_LEASE" default:"1m"`
+	// MaxAttempts is how many failed publish attempts move an event to the dead letters
+	MaxAttempts int `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS" default:"10"`
+	// Retention is how long published events are kept before the purge_outbox task deletes them
+	Retention time.Duration `yaml:"retention" env:"OUTBOX_RETENTION" default:"168h"`
+}
+
+// BrokerConfig holds the message broker the user events are published to. Backend is
+// "nats", "kafka" (through a Kafka REST Proxy) or empty to publish to no broker.
+type BrokerConfig struct {
+	Backend string `yaml:"backend" env:"BROKER_BACKEND"`
+	// URL is the NATS server (nats://, tls://) or the REST Proxy (http://, https://), with
+	// optional credentials
+	URL string `yaml:"url" env:"BROKER_URL" secret:"true"`
+	// Topic names the topic of an event from {event}, {entity}, {action} and {version}
+	Topic string `yaml:"topic" env:"BROKER_TOPIC" default:"hoctap.{event}.v{version}"`
+}
+
+// SchedulerConfig holds the cron expressions of the recurring tasks, which run on one
+// instance at a time; an empty expression disables a task
+type SchedulerConfig struct {
+	// PurgeIdempotencyKeys deletes the idempotency keys whose responses are no longer replayed
+	PurgeIdempotencyKeys string `yaml:"purge_idempotency_keys" env:"SCHEDULE_PURGE_IDEMPOTENCY_KEYS" default:"0 * * * *"`
+	// PurgeOutbox deletes the outbox events published longer ago than OUTBOX_RETENTION
+	PurgeOutbox string `yaml:"purge_outbox" env:"SCHEDULE_PURGE_OUTBOX" default:"15 3 * * *"`
+}
+
+// Tasks returns the cron expression of every task by name, empty for disabled tasks
+func (s SchedulerConfig) Tasks() map[string]string {
+	return map[string]string{
+		"purge_idempotency_keys": s.PurgeIdempotencyKeys,
+		"purge_outbox":           s.PurgeOutbox,
+	}
+}
+
+// MTLSConfig holds client certificate authentication for service-to-service calls
+type MTLSConfig struct {
+	// ClientCA is the PEM bundle of accepted client certificate CAs, inline or as a file path
+	ClientCA string `yaml:"client_ca" env:"MTLS_CLIENT_CA"`
+	// Listeners requiring a client certificate: api and/or admin
+	Listeners []string `yaml:"listeners" env:"MTLS_LISTENERS"`
+	// AdminIdentities are the certificate common names accepted on admin endpoints
+	// without the admin token
+	AdminIdentities []string `yaml:"admin_identities" env:"MTLS_ADMIN_IDENTITIES" reload:"true"`
+}
+
+// Requires reports whether listener ("api" or "admin") requires client certificates
+func (m MTLSConfig) Requires(listener string) bool {
+	return slices.Contains(m.Listeners, listener)
+}
+
+// envFile is the dotenv file layered between the YAML file and the environment
+const envFile = "config.env"
+
+var (
+	current  atomic.Pointer[Config]
+	sources  atomic.Pointer[[]string]
+	reloadMu sync.Mutex
+)
+
+// Current returns the active configuration. Before Init it returns the defaults layered
+// with the environment, so packages can be used without an explicit Init.
+func Current() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+
+	cfg, _, err := load()
+	if err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+	current.CompareAndSwap(nil, cfg)
+	return current.Load()
+}
+
+// Init loads and validates the configuration and makes it current
+func Init() (*Config, error) {
+	cfg, loadedFrom, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	current.Store(cfg)
+	sources.Store(&loadedFrom)
+	return cfg, nil
+}
+
+// Sources lists where Init found configuration values, in increasing priority: defaults,
+// the YAML file, config.env, environment and secrets
+func Sources() []string {
+	if loadedFrom := sources.Load(); loadedFrom != nil {
+		return *loadedFrom
+	}
+	return nil
+}
+
+// Reload loads the configuration again and applies the fields tagged reload:"true". It
+// returns the previous and the new configuration; other changed fields are logged and kept.
+func Reload() (*Config, *Config, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	loaded, _, err := load()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := loaded.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	previous := Current()
+	next := *previous
+	merge(reflect.ValueOf(&next).Elem(), reflect.ValueOf(loaded).Elem())
+
+	current.Store(&next)
+	return previous, &next, nil
+}
+
+// Validate checks required fields and value ranges
+func (c *Config) Validate() error {
+	var problems []string
+
+	if !c.Server.ListensTCP() && c.Server.ListenSocket == "" {
+		problems = append(problems, "SERVER_PORT=off requires LISTEN_SOCKET")
+	}
+	if !c.Server.ListensTCP() && c.Server.TLSEnabled() {
+		problems = append(problems, "TLS requires SERVER_PORT; LISTEN_SOCKET speaks plain HTTP")
+	}
+	if _, err := strconv.ParseUint(c.Server.ListenSocketMode, 8, 32); err != nil {
+		problems = append(problems, fmt.Sprintf("LISTEN_SOCKET_MODE must be an octal file mode, got '%s'", c.Server.ListenSocketMode))
+	}
+	if c.Server.ListensTCP() {
+		if _, err := strconv.Atoi(c.Server.Port); err != nil {
+			problems = append(problems, fmt.Sprintf("SERVER_PORT must be a port number or off, got '%s'", c.Server.Port))
+		}
+	}
+	for name, port := range map[string]string{
+		"GRPC_PORT":          c.Server.GRPCPort,
+		"ADMIN_PORT":         c.Server.AdminPort,
+		"HTTP_REDIRECT_PORT": c.Server.HTTPRedirectPort,
+	} {
+		if port == "" {
+			continue
+		}
+		if _, err := strconv.Atoi(port); err != nil {
+			problems = append(problems, fmt.Sprintf("%s must be a port number, got '%s'", name, port))
+		}
+	}
+
+	if (c.Server.TLSCert == "") != (c.Server.TLSKey == "") {
+		problems = append(problems, "TLS_CERT and TLS_KEY must be set together")
+	}
+	if c.Server.TLSCert != "" && len(c.Server.AutocertDomains) > 0 {
+		problems = append(problems, "TLS_CERT/TLS_KEY and AUTOCERT_DOMAINS are mutually exclusive")
+	}
+	if c.Server.HTTPRedirectPort != "" && !c.Server.TLSEnabled() {
+		problems = append(problems, "HTTP_REDIRECT_PORT requires TLS_CERT/TLS_KEY or AUTOCERT_DOMAINS")
+	}
+
+	for _, listener := range c.MTLS.Listeners {
+		if listener != "api" && listener != "admin" {
+			problems = append(problems, fmt.Sprintf("MTLS_LISTENERS entries must be api or admin, got '%s'", listener))
+		}
+	}
+	if (c.MTLS.ClientCA == "") != (len(c.MTLS.Listeners) == 0) {
+		problems = append(problems, "MTLS_CLIENT_CA and MTLS_LISTENERS must be set together")
+	}
+	if len(c.MTLS.Listeners) > 0 && !c.Server.TLSEnabled() {
+		problems = append(problems, "MTLS_LISTENERS requires TLS_CERT/TLS_KEY or AUTOCERT_DOMAINS")
+	}
+	// The socket serves the same router in plain HTTP, which would bypass the certificates
+	if c.MTLS.Requires("api") && c.Server.ListenSocket != "" {
+		problems = append(problems, "MTLS_LISTENERS=api cannot be combined with LISTEN_SOCKET, which has no client certificates")
+	}
+	if c.MTLS.Requires("admin") && c.Server.AdminPort == "" {
+		problems = append(problems, "MTLS_LISTENERS=admin requires ADMIN_PORT")
+	}
+
+	for _, entry := range c.Server.TrustedProxies {
+		if _, err := parseProxyPrefix(entry); err != nil {
+			problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES entries must be IP addresses or CIDR ranges, got '%s'", entry))
+		}
+	}
+
+	if c.Server.ShutdownTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT must be positive")
+	}
+	if c.Server.PreStopDelay < 0 {
+		problems = append(problems, "PRE_STOP_DELAY must not be negative")
+	}
+
+	switch strings.ToLower(c.Database.Driver) {
+	case "mysql", "postgres", "postgresql", "pgx":
+	default:
+		problems = append(problems, fmt.Sprintf("DB_DRIVER must be mysql or postgres, got '%s'", c.Database.Driver))
+	}
+	if c.Database.Host == "" {
+		problems = append(problems, "DB_HOST is required")
+	}
+	if c.Database.Name == "" {
+		problems = append(problems, "DB_NAME is required")
+	}
+	if c.Database.FailoverCheckInterval <= 0 {
+		problems = append(problems, "DB_FAILOVER_CHECK_INTERVAL must be positive")
+	}
+	if c.Database.FailoverThreshold <= 0 {
+		problems = append(problems, "DB_FAILOVER_THRESHOLD must be positive")
+	}
+	if c.Database.NegativeCacheTTL < 0 {
+		problems = append(problems, "NEGATIVE_CACHE_TTL must not be negative")
+	}
+
+	if c.Environment == "production" && c.Admin.APIToken == "" {
+		problems = append(problems, "ADMIN_API_TOKEN is required in production")
+	}
+
+	if c.Events.SampleRate < 0 || c.Events.SampleRate > 1 {
+		problems = append(problems, "EVENTS_SAMPLE_RATE must be between 0 and 1")
+	}
+	if c.Events.QuotaPerMinute < 0 {
+		problems = append(problems, "EVENTS_QUOTA_PER_MINUTE must not be negative")
+	}
+	if c.API.IdempotencyMaxBodyBytes <= 0 {
+		problems = append(problems, "API_IDEMPOTENCY_MAX_BODY_BYTES must be positive")
+	}
+	if c.API.ConcurrencyPerClient < 0 {
+		problems = append(problems, "API_CONCURRENCY_PER_CLIENT must not be negative")
+	}
+	if c.Database.ConnectAttempts <= 0 {
+		problems = append(problems, "DB_CONNECT_ATTEMPTS must be positive")
+	}
+	if c.Database.ConnectMaxWait <= 0 {
+		problems = append(problems, "DB_CONNECT_MAX_WAIT must be positive")
+	}
+	if c.Database.BreakerThreshold <= 0 {
+		problems = append(problems, "DB_BREAKER_THRESHOLD must be positive")
+	}
+	if c.Database.BreakerProbeInterval <= 0 {
+		problems = append(problems, "DB_BREAKER_PROBE_INTERVAL must be positive")
+	}
+	if c.Database.ReplicaCheckInterval <= 0 {
+		problems = append(problems, "DB_REPLICA_CHECK_INTERVAL must be positive")
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		problems = append(problems, "DB_CONN_MAX_LIFETIME must not be negative")
+	}
+	if c.Secrets.RefreshInterval <= 0 {
+		problems = append(problems, "SECRETS_REFRESH_INTERVAL must be positive")
+	}
+	switch c.Cache.Backend {
+	case "", "memory":
+	case "redis":
+		if c.Cache.RedisURL == "" {
+			problems = append(problems, "REDIS_URL is required with CACHE_BACKEND=redis")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("CACHE_BACKEND must be redis or memory, got '%s'", c.Cache.Backend))
+	}
+	if c.Cache.MaxEntries <= 0 {
+		problems = append(problems, "CACHE_MAX_ENTRIES must be positive")
+	}
+	if c.Cache.UserTTL <= 0 || c.Cache.ListTTL <= 0 {
+		problems = append(problems, "CACHE_USER_TTL and CACHE_LIST_TTL must be positive")
+	}
+	if c.Mock.Users < 0 {
+		problems = append(problems, "MOCK_USERS must not be negative")
+	}
+	if c.Mock.Latency < 0 {
+		problems = append(problems, "MOCK_LATENCY must not be negative")
+	}
+	if c.Mock.ErrorRate < 0 || c.Mock.ErrorRate > 1 {
+		problems = append(problems, "MOCK_ERROR_RATE must be between 0 and 1")
+	}
+	if c.Avatar.MaxUploadBytes <= 0 {
+		problems = append(problems, "AVATAR_MAX_UPLOAD_BYTES must be positive")
+	}
+	if c.Avatar.Size < 16 || c.Avatar.Size > 1024 {
+		problems = append(problems, "AVATAR_SIZE must be between 16 and 1024")
+	}
+	if c.Email.MXTimeout <= 0 {
+		problems = append(problems, "EMAIL_MX_TIMEOUT must be positive")
+	}
+	switch c.Storage.Backend {
+	case "local":
+	case "s3":
+		if c.Storage.S3Bucket == "" {
+			problems = append(problems, "STORAGE_S3_BUCKET is required with STORAGE_BACKEND=s3")
+		}
+		if (c.Storage.S3AccessKey == "") != (c.Storage.S3SecretKey == "") {
+			problems = append(problems, "STORAGE_S3_ACCESS_KEY and STORAGE_S3_SECRET_KEY must be set together")
+		}
+		// S3 rejects presigned URLs valid for longer than 7 days
+		if c.Storage.URLTTL > 7*24*time.Hour {
+			problems = append(problems, "STORAGE_URL_TTL must be at most 168h with STORAGE_BACKEND=s3")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("STORAGE_BACKEND must be local or s3, got '%s'", c.Storage.Backend))
+	}
+	if c.Storage.URLTTL < time.Minute {
+		problems = append(problems, "STORAGE_URL_TTL must be at least 1m")
+	}
+	if c.Watchdog.Interval < 0 {
+		problems = append(problems, "WATCHDOG_INTERVAL must not be negative")
+	}
+	if c.Watchdog.Window < 3 {
+		problems = append(problems, "WATCHDOG_WINDOW must be at least 3")
+	}
+	if c.Mail.SMTPHost != "" {
+		if c.Mail.SMTPPort <= 0 || c.Mail.SMTPPort > 65535 {
+			problems = append(problems, "SMTP_PORT must be between 1 and 65535")
+		}
+		switch c.Mail.SMTPTLS {
+		case "starttls", "tls", "none":
+		default:
+			problems = append(problems, fmt.Sprintf("SMTP_TLS must be starttls, tls or none, got '%s'", c.Mail.SMTPTLS))
+		}
+	}
+	if c.Retention.Interval <= 0 {
+		problems = append(problems, "RETENTION_INTERVAL must be positive")
+	}
+	if c.Jobs.Workers <= 0 {
+		problems = append(problems, "JOBS_WORKERS must be positive")
+	}
+	if c.Jobs.PollInterval <= 0 {
+		problems = append(problems, "JOBS_POLL_INTERVAL must be positive")
+	}
+	if c.Jobs.Lease <= 0 {
+		problems = append(problems, "JOBS_LEASE must be positive")
+	}
+	if c.Outbox.PollInterval <= 0 {
+		problems = append(problems, "OUTBOX_POLL_INTERVAL must be positive")
+	}
+	if c.Outbox.Lease <= 0 {
+		problems = append(problems, "OUTBOX_LEASE must be positive")
+	}
+	if c.Outbox.MaxAttempts <= 0 {
+		problems = append(problems, "OUTBOX_MAX_ATTEMPTS must be positive")
+	}
+	if c.Outbox.Retention <= 0 {
+		problems = append(problems, "OUTBOX_RETENTION must be positive")
+	}
+	switch c.Broker.Backend {
+	case "":
+	case "nats", "kafka":
+		if c.Broker.URL == "" {
+			problems = append(problems, fmt.Sprintf("BROKER_URL is required with BROKER_BACKEND=%s", c.Broker.Backend))
+		}
+		// Events must not share a topic
+		topic := c.Broker.Topic
+		if !strings.Contains(topic, "{event}") && !(strings.Contains(topic, "{entity}") && strings.Contains(topic, "{action}")) {
+			problems = append(problems, "BROKER_TOPIC must contain {event}, or {entity} and {action}")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("BROKER_BACKEND must be nats, kafka or empty, got '%s'", c.Broker.Backend))
+	}
+	for name, expr := range c.Scheduler.Tasks() {
+		if expr == "" {
+			continue
+		}
+		if schedule, err := scheduler.Parse(expr); err != nil {
+			problems = append(problems, fmt.Sprintf("schedule of %s: %v", name, err))
+		} else if schedule.Next(time.Now()).IsZero() {
+			problems = append(problems, fmt.Sprintf("schedule of %s: '%s' never matches", name, expr))
+		}
+	}
+
+	if _, err := c.API.Lifecycle(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Helper function to build a configuration from every source, also returning the sources
+// that were present
+func load() (*Config, []string, error) {
+	cfg := &Config{}
+	loadedFrom := []string{"defaults"}
+	if err := applyDefaults(reflect.ValueOf(cfg).Elem()); err != nil {
+		return cfg, loadedFrom, err
+	}
+
+	file := os.Getenv("CONFIG_FILE")
+	if file == "" {
+		file = "config.yaml"
+	}
+	if data, err := os.ReadFile(file); err == nil {
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return cfg, loadedFrom, fmt.Errorf("failed to parse %s: %v", file, err)
+		}
+		loadedFrom = append(loadedFrom, file)
+	} else if !errors.Is(err, os.ErrNotExist) || os.Getenv("CONFIG_FILE") != "" {
+		return cfg, loadedFrom, fmt.Errorf("failed to read %s: %v", file, err)
+	}
+
+	dotenv, err := godotenv.Read(envFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return cfg, loadedFrom, fmt.Errorf("failed to read %s: %v", envFile, err)
+	}
+
+	var fromDotenv, fromEnvironment bool
+	lookup := func(key string) (string, bool) {
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			fromEnvironment = true
+			return value, true
+		}
+		value, ok := dotenv[key]
+		if ok && value != "" {
+			fromDotenv = true
+		}
+		return value, ok && value != ""
+	}
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), lookup); err != nil {
+		return cfg, loadedFrom, err
+	}
+	if fromDotenv {
+		loadedFrom = append(loadedFrom, envFile)
+	}
+	if fromEnvironment {
+		loadedFrom = append(loadedFrom, "environment")
+	}
+
+	resolved, err := resolveSecrets(reflect.ValueOf(cfg).Elem())
+	if err != nil {
+		return cfg, loadedFrom, err
+	}
+	if resolved > 0 {
+		loadedFrom = append(loadedFrom, "secrets")
+	}
+
+	return cfg, loadedFrom, nil
+}
+
+// Helper function to walk the leaf fields of a config struct
+func walk(v reflect.Value, fn func(field reflect.StructField, value reflect.Value) error) error {
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			if err := walk(value, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper function to set the `default` tag values
+func applyDefaults(v reflect.Value) error {
+	return walk(v, func(field reflect.StructField, value reflect.Value) error {
+		if def, ok := field.Tag.Lookup("default"); ok {
+			return setValue(value, def, field.Name)
+		}
+		return nil
+	})
+}
+
+// Helper function to override fields from their `env` variables
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	return walk(v, func(field reflect.StructField, value reflect.Value) error {
+		if raw, ok := lookup(field.Tag.Get("env")); ok {
+			return setValue(value, raw, field.Tag.Get("env"))
+		}
+		return nil
+	})
+}
+
+// Helper function to replace secret references with the secrets they point at, returning
+// how many were resolved
+func resolveSecrets(v reflect.Value) (int, error) {
+	var resolved int
+	err := walk(v, func(field reflect.StructField, value reflect.Value) error {
+		if field.Tag.Get("secret") != "true" {
+			return nil
+		}
+		secret, ok, err := secrets.Resolve(value.String())
+		if err != nil {
+			return fmt.Errorf("%s: %v", field.Tag.Get("env"), err)
+		}
+		if ok {
+			value.SetString(secret)
+			resolved++
+		}
+		return nil
+	})
+	return resolved, err
+}
+
+// Helper function to parse raw into a leaf field
+func setValue(value reflect.Value, raw, name string) error {
+	if value.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be a duration such as 30s or 1h, got '%s'", name, raw)
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported config field type %s for %s", value.Type(), name)
+		}
+		// Lists are comma-separated in the environment
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		value.Set(reflect.ValueOf(items))
+	case reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got '%s'", name, raw)
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%s must be a number, got '%s'", name, raw)
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s must be true or false, got '%s'", name, raw)
+		}
+		value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported config field type %s for %s", value.Type(), name)
+	}
+	return nil
+}
+
+// Helper function to copy the reloadable fields of src into dst and report the others
+func merge(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			merge(dst.Field(i), src.Field(i))
+			continue
+		}
+		if reflect.DeepEqual(dst.Field(i).Interface(), src.Field(i).Interface()) {
+			continue
+		}
+
+		if field.Tag.Get("reload") == "true" {
+			dst.Field(i).Set(src.Field(i))
+			log.Printf("🔄 Reloaded %s", field.Tag.Get("env"))
+		} else {
+			log.Printf("⚠️ Warning: %s changed; restart to apply it", field.Tag.Get("env"))
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/health"
+)
+
+// dashboardRecentUsers is how many users the dashboard renders on the server
+const dashboardRecentUsers = 12
+
+// dashboardEndpoints are the endpoints offered in the API testing section
+var dashboardEndpoints = []string{"/health", "/api/v1/users", "/api/v1/users/1"}
+
+// dashboardTemplates caches the parsed embedded templates; live templates (serve -assets)
+// are parsed on every request so edits show up on reload
+var dashboardTemplates struct {
+	once sync.Once
+	tmpl *template.Template
+	err  error
+}
+
+// dashboardHealth is the API status shown in the header
+type dashboardHealth struct {
+	Status    string // status-badge class: online, degraded or offline
+	Label     string
+	CheckedAt time.Time
+}
+
+// dashboardPage is the data the dashboard templates render on first paint
+type dashboardPage struct {
+	Title       string
+	Health      *dashboardHealth
+	UsersLoaded bool
+	UserCount   int
+	RecentUsers []database.User
+	Endpoints   []string
+}
+
+// Helper function to parse the layout, page and partial templates from the dashboard files
+func parseDashboardTemplates(fsys fs.FS) (*template.Template, error) {
+	return template.ParseFS(fsys, "templates/*.html", "templates/partials/*.html")
+}
+
+// Helper function to get the dashboard templates
+func loadDashboardTemplates() (*template.Template, error) {
+	if liveAssets {
+		return parseDashboardTemplates(dashboardAssets)
+	}
+
+	dashboardTemplates.once.Do(func() {
+		dashboardTemplates.tmpl, dashboardTemplates.err = parseDashboardTemplates(dashboardAssets)
+	})
+	return dashboardTemplates.tmpl, dashboardTemplates.err
+}
+
+// Render the dashboard with the user count, the most recent users and the API health, so
+// the first paint needs no API calls. Sections whose data cannot be loaded fall back to
+// the loading state and are filled in by script.js.
+func dashboardHandler(w http.ResponseWriter, r *http.Request) {
+	tmpl, err := loadDashboardTemplates()
+	if err != nil {
+		log.Printf("Error parsing dashboard templates: %v", err)
+		http.Error(w, "Failed to render dashboard", http.StatusInternalServerError)
+		return
+	}
+
+	page := dashboardPage{Title: "HocTap API Dashboard", Endpoints: dashboardEndpoints}
+
+	report := healthMonitor.Report(r.Context())
+	page.Health = &dashboardHealth{Status: "online", Label: "API Online", CheckedAt: report.CheckedAt}
+	switch report.Status {
+	case health.StatusDegraded:
+		page.Health.Status, page.Health.Label = "degraded", "API Degraded"
+	case health.StatusUnhealthy:
+		page.Health.Status, page.Health.Label = "offline", "API Unhealthy"
+	}
+
+	count, err := userRepo.GetUsersCount(r.Context())
+	if err == nil {
+		page.RecentUsers, err = userRepo.ListUsers(r.Context(), database.UserFilter{Limit: dashboardRecentUsers})
+	}
+	if err != nil {
+		log.Printf("⚠️ Warning: dashboard rendered without users: %v", err)
+	} else {
+		page.UsersLoaded = true
+		page.UserCount = count
+	}
+
+	// Render fully before writing, so a template error is not sent as half a page
+	var body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&body, "layout", page); err != nil {
+		log.Printf("Error rendering dashboard: %v", err)
+		http.Error(w, "Failed to render dashboard", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Write(body.Bytes())
+}
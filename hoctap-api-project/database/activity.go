@@ -0,0 +1,119 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Activity types of the user activity feed. Logins, enrollments and quiz results will be
+// recorded once those features exist.
+const (
+	ActivityAccountCreated  = "account_created"
+	ActivityProfileUpdated  = "profile_updated"
+	ActivityAvatarChanged   = "avatar_changed"
+	ActivityAvatarRemoved   = "avatar_removed"
+	ActivityLegalHoldPlaced = "legal_hold_placed"
+	ActivityLegalHoldLifted = "legal_hold_lifted"
+)
+
+// Activity is a significant event in the life of a user account, as shown to support staff
+type Activity struct {
+	ID        int64           `json:"id"`
+	UserID    int             `json:"user_id"`
+	Type      string          `json:"type"`
+	Actor     string          `json:"actor"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ActivityFilter selects a page of a user's activities, newest first
+type ActivityFilter struct {
+	UserID int
+	// Before returns activities older than this ID, for paging; 0 starts at the newest
+	Before int64
+	Limit  int
+}
+
+// ActivityRepository handles user activity database operations
+type ActivityRepository struct {
+	db dbtx
+}
+
+// NewActivityRepository creates a new activity repository
+func NewActivityRepository() *ActivityRepository {
+	return &ActivityRepository{db: DB}
+}
+
+// Record stores an activity of a user. details is marshalled to JSON and may be nil.
+func (ar *ActivityRepository) Record(ctx context.Context, userID int, activityType string, actor AuditActor, details interface{}) error {
+	detailsJSON, err := marshalAuditState(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode activity details: %v", err)
+	}
+
+	name := actor.Name
+	if name == "" {
+		name = "system"
+	}
+
+	query := `INSERT INTO activities (user_id, type, actor, details) VALUES (?, ?, ?, ?)`
+
+	if _, err := ar.db.ExecContext(ctx, rebind(query), userID, activityType, name, detailsJSON); err != nil {
+		return fmt.Errorf("failed to record activity: %v", err)
+	}
+
+	return nil
+}
+
+// List retrieves a page of a user's activities, newest first
+func (ar *ActivityRepository) List(ctx context.Context, filter ActivityFilter) ([]Activity, error) {
+	query := `SELECT id, user_id, type, actor, details, created_at FROM activities WHERE user_id = ?`
+	args := []interface{}{filter.UserID}
+
+	if filter.Before > 0 {
+		query += ` AND id < ?`
+		args = append(args, filter.Before)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	var activities []Activity
+	err := readFrom(ctx, ar.db, func(db dbtx) error {
+		rows, err := db.QueryContext(ctx, rebind(query), args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		activities = []Activity{}
+		for rows.Next() {
+			var a Activity
+			var details []byte
+			if err := rows.Scan(&a.ID, &a.UserID, &a.Type, &a.Actor, &details, &a.CreatedAt); err != nil {
+				return err
+			}
+			a.Details = details
+			activities = append(activities, a)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activities: %v", err)
+	}
+
+	return activities, nil
+}
+
+// recordActivity adds an activity to a user's feed. Unlike audit entries, which are required,
+// failures are logged rather than returned, so a successful change is never reported as failed.
+func recordActivity(ctx context.Context, ar *ActivityRepository, userID int, activityType string, actor AuditActor, details interface{}) {
+	if ar == nil {
+		return
+	}
+	if err := ar.Record(context.WithoutCancel(ctx), userID, activityType, actor, details); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// AudienceAll is the audience of announcements shown to everyone
+const AudienceAll = "all"
+
+// Announcement represents an in-app announcement with an optional publish window
+type Announcement struct {
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Body        string     `json:"body"`
+	Audience    string     `json:"audience"`
+	PublishAt   time.Time  `json:"publish_at"`
+	UnpublishAt *time.Time `json:"unpublish_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ErrAnnouncementNotFound matches (with errors.Is) the error of a missing announcement
+var ErrAnnouncementNotFound = errors.New("announcement not found")
+
+// AnnouncementRepository handles announcement database operations
+type AnnouncementRepository struct {
+	db *sql.DB
+}
+
+// NewAnnouncementRepository creates a new announcement repository
+func NewAnnouncementRepository() *AnnouncementRepository {
+	return &AnnouncementRepository{db: DB}
+}
+
+// CreateAnnouncement stores a new announcement
+func (ar *AnnouncementRepository) CreateAnnouncement(ctx context.Context, title, body, audience string, publishAt time.Time, unpublishAt *time.Time) (*Announcement, error) {
+	query := `INSERT INTO announcements (title, body, audience, publish_at, unpublish_at) VALUES (?, ?, ?, ?, ?)`
+
+	id, err := insertReturningID(ctx, ar.db, query, title, body, audience, publishAt, unpublishAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create announcement: %v", err)
+	}
+
+	return ar.GetAnnouncementByID(ctx, int(id))
+}
+
+// GetAnnouncementByID retrieves an announcement by ID
+func (ar *AnnouncementRepository) GetAnnouncementByID(ctx context.Context, id int) (*Announcement, error) {
+	query := `SELECT id, title, body, audience, publish_at, unpublish_at, created_at, updated_at FROM announcements WHERE id = ?`
+
+	var a Announcement
+	err := ar.db.QueryRowContext(ctx, rebind(query), id).Scan(
+		&a.ID, &a.Title, &a.Body, &a.Audience, &a.PublishAt, &a.UnpublishAt, &a.CreatedAt, &a.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sentinelErrorf(ErrAnnouncementNotFound, "announcement with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get announcement: %v", err)
+	}
+
+	return &a, nil
+}
+
+// GetAllAnnouncements retrieves every announcement, including scheduled and expired ones
+func (ar *AnnouncementRepository) GetAllAnnouncements(ctx context.Context) ([]Announcement, error) {
+	query := `SELECT id, title, body, audience, publish_at, unpublish_at, created_at, updated_at
+		FROM announcements ORDER BY publish_at DESC`
+
+	return ar.queryAnnouncements(ctx, query)
+}
+
+// GetActiveAnnouncements retrieves announcements whose publish window contains the current time,
+// addressed to the given audience or to everyone
+func (ar *AnnouncementRepository) GetActiveAnnouncements(ctx context.Context, audience string) ([]Announcement, error) {
+	query := `SELECT id, title, body, audience, publish_at, unpublish_at, created_at, updated_at
+		FROM announcements
+		WHERE publish_at <= CURRENT_TIMESTAMP
+		AND (unpublish_at IS NULL OR unpublish_at > CURRENT_TIMESTAMP)
+		AND audience IN (?, ?)
+		ORDER BY publish_at DESC`
+
+	return ar.queryAnnouncements(ctx, query, AudienceAll, audience)
+}
+
+// DeleteAnnouncement removes an announcement by ID
+func (ar *AnnouncementRepository) DeleteAnnouncement(ctx context.Context, id int) error {
+	result, err := ar.db.ExecContext(ctx, rebind(`DELETE FROM announcements WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete announcement: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return sentinelErrorf(ErrAnnouncementNotFound, "announcement with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// Helper function to run an announcement list query
+func (ar *AnnouncementRepository) queryAnnouncements(ctx context.Context, query string, args ...interface{}) ([]Announcement, error) {
+	rows, err := ar.db.QueryContext(ctx, rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query announcements: %v", err)
+	}
+	defer rows.Close()
+
+	announcements := []Announcement{}
+	for rows.Next() {
+		var a Announcement
+		err := rows.Scan(&a.ID, &a.Title, &a.Body, &a.Audience, &a.PublishAt, &a.UnpublishAt, &a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan announcement: %v", err)
+		}
+		announcements = append(announcements, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return announcements, nil
+}
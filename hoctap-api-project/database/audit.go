@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Audit actions recorded for mutations
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// AuditEntry represents a single record in the audit log
+type AuditEntry struct {
+	ID        int             `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityID  int             `json:"entity_id"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	IP        string          `json:"ip"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditActor identifies who performed a change and from where
+type AuditActor struct {
+	Name string
+	IP   string
+}
+
+// AuditFilter narrows down audit log queries; zero values are ignored
+type AuditFilter struct {
+	Actor    string
+	Action   string
+	Entity   string
+	EntityID int
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// AuditRepository handles audit log database operations
+type AuditRepository struct {
+	db dbtx
+}
+
+// NewAuditRepository creates a new audit repository
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{db: DB}
+}
+
+// Record stores a change in the audit log. before and after are marshalled to JSON
+// and may be nil (e.g. no before state on create, no after state on delete).
+func (ar *AuditRepository) Record(ctx context.Context, actor AuditActor, action, entity string, entityID int, before, after interface{}) error {
+	beforeJSON, err := marshalAuditState(before)
+	if err != nil {
+		return fmt.Errorf("failed to encode before state: %v", err)
+	}
+	afterJSON, err := marshalAuditState(after)
+	if err != nil {
+		return fmt.Errorf("failed to encode after state: %v", err)
+	}
+
+	name := actor.Name
+	if name == "" {
+		name = "system"
+	}
+
+	query := `INSERT INTO audit_log (actor, action, entity, entity_id, before_data, after_data, ip) VALUES (?, ?, ?, ?, ?, ?, ?)`
+
+	if _, err := ar.db.ExecContext(ctx, rebind(query), name, action, entity, entityID, beforeJSON, afterJSON, actor.IP); err != nil {
+		return fmt.Errorf("failed to record audit entry: %v", err)
+	}
+
+	return nil
+}
+
+// List retrieves audit entries matching the filter, newest first
+func (ar *AuditRepository) List(ctx context.Context, filter AuditFilter) ([]AuditEntry, error) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.Actor != "" {
+		conditions = append(conditions, "actor = ?")
+		args = append(args, filter.Actor)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Entity != "" {
+		conditions = append(conditions, "entity = ?")
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityID > 0 {
+		conditions = append(conditions, "entity_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at <= ?")
+		args = append(args, filter.To)
+	}
+
+	query := `SELECT id, actor, action, entity, entity_id, before_data, after_data, ip, created_at FROM audit_log`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	rows, err := ar.db.QueryContext(ctx, rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %v", err)
+	}
+	defer rows.Close()
+
+	entries := []AuditEntry{}
+	for rows.Next() {
+		var entry AuditEntry
+		var before, after []byte
+		err := rows.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.Entity, &entry.EntityID,
+			&before, &after, &entry.IP, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %v", err)
+		}
+		entry.Before = before
+		entry.After = after
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return entries, nil
+}
+
+// Helper function to encode an audit state snapshot, keeping NULL for nil
+func marshalAuditState(state interface{}) (interface{}, error) {
+	if state == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// recordAudit writes the audit entry of a repository mutation. It runs in the transaction of
+// the mutation, so a change that cannot be audited is rolled back rather than made unaudited.
+func recordAudit(ctx context.Context, ar *AuditRepository, actor AuditActor, action, entity string, entityID int, before, after interface{}) error {
+	if ar == nil {
+		return nil
+	}
+	return ar.Record(ctx, actor, action, entity, entityID, before, after)
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrDatabaseUnavailable is returned instead of connecting while the circuit breaker is open
+var ErrDatabaseUnavailable = errors.New("database is unavailable")
+
+// breakerProbeTimeout bounds a single recovery probe
+const breakerProbeTimeout = 2 * time.Second
+
+// circuitBreaker wraps a connector. After threshold consecutive failed connection attempts
+// it opens: new connections fail at once with ErrDatabaseUnavailable instead of waiting on
+// a server that is down, and a background probe connects every interval until the server is
+// back. database/sql already replaces broken pooled connections, so once the probe succeeds
+// requests reconnect on their own. Failures only count once the breaker is armed, after
+// the first successful connection, so the startup retries report the real error.
+type circuitBreaker struct {
+	connector driver.Connector
+	threshold int32
+	interval  time.Duration
+
+	armed    atomic.Bool
+	failures atomic.Int32
+	open     atomic.Bool
+
+	mu       sync.Mutex
+	openedAt time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// breaker is set by InitDB
+var breaker *circuitBreaker
+
+// Helper function to wrap connector in a circuit breaker
+func newCircuitBreaker(connector driver.Connector, threshold int, interval time.Duration) *circuitBreaker {
+	return &circuitBreaker{connector: connector, threshold: int32(threshold), interval: interval, stop: make(chan struct{})}
+}
+
+// Connect opens a connection unless the circuit is open
+func (cb *circuitBreaker) Connect(ctx context.Context) (driver.Conn, error) {
+	if cb.open.Load() {
+		return nil, ErrDatabaseUnavailable
+	}
+
+	conn, err := cb.connector.Connect(ctx)
+	if err != nil {
+		// A canceled request says nothing about the server
+		if cb.armed.Load() && ctx.Err() == nil && cb.failures.Add(1) >= cb.threshold {
+			cb.trip(err)
+		}
+		return nil, err
+	}
+
+	cb.failures.Store(0)
+	return conn, nil
+}
+
+// Helper function to start counting failures, once the server was reached
+func (cb *circuitBreaker) arm() {
+	cb.failures.Store(0)
+	cb.armed.Store(true)
+}
+
+// Driver returns the underlying driver
+func (cb *circuitBreaker) Driver() driver.Driver {
+	return cb.connector.Driver()
+}
+
+// Helper function to open the circuit and start probing for recovery
+func (cb *circuitBreaker) trip(cause error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.open.Load() || cb.done != nil {
+		return
+	}
+	select {
+	case <-cb.stop:
+		return
+	default:
+	}
+
+	cb.openedAt = time.Now()
+	cb.open.Store(true)
+	cb.done = make(chan struct{})
+	log.Printf("🔌 Database circuit opened after %d failed connection attempts: %v", cb.threshold, cause)
+
+	go cb.probe()
+}
+
+// Helper function to connect every interval until the server answers, then close the circuit
+func (cb *circuitBreaker) probe() {
+	ticker := time.NewTicker(cb.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cb.stop:
+			close(cb.done)
+			return
+		case <-ticker.C:
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), breakerProbeTimeout)
+		conn, err := cb.connector.Connect(ctx)
+		cancel()
+		if err != nil {
+			continue
+		}
+		conn.Close()
+
+		cb.mu.Lock()
+		outage := time.Since(cb.openedAt)
+		cb.failures.Store(0)
+		cb.open.Store(false)
+		close(cb.done)
+		cb.done = nil
+		cb.mu.Unlock()
+
+		log.Printf("✅ Database circuit closed; server reachable again after %s", outage.Round(time.Second))
+		return
+	}
+}
+
+// Helper function to stop the recovery probe; closing again does nothing
+func (cb *circuitBreaker) close() {
+	cb.mu.Lock()
+	cb.stopOnce.Do(func() { close(cb.stop) })
+	done := cb.done
+	cb.mu.Unlock()
+
+	if done != nil {
+		<-done
+	}
+}
+
+// Available reports whether the circuit breaker lets connections through. It is true before
+// InitDB, so callers only short-circuit during a detected outage.
+func Available() bool {
+	return breaker == nil || !breaker.open.Load()
+}
@@ -2,90 +2,122 @@ package database
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"log"
-	"os"
+	"math/rand/v2"
+	"time"
 
-	_ "github.com/go-sql-driver/mysql"
-	"github.com/joho/godotenv"
+	"hoctap-api/config"
 )
 
 var DB *sql.DB
 
-// Initialize database connection
+// primary connects to the primary server, set by InitDB
+var primary *serverConnector
+
+// Initialize database connection. The schema is managed separately with MigrateUp.
 func InitDB() error {
-	// Load environment variables
-	if err := godotenv.Load("config.env"); err != nil {
-		log.Printf("Warning: Could not load config.env file: %v", err)
-		log.Println("Using system environment variables or defaults")
-	}
+	cfg := config.Current().Database
 
-	// Get database configuration from environment
-	dbHost := getEnv("DB_HOST", "localhost")
-	dbPort := getEnv("DB_PORT", "3306")
-	dbUser := getEnv("DB_USER", "root")
-	dbPassword := getEnv("DB_PASSWORD", "")
-	dbName := getEnv("DB_NAME", "hoctap_api")
+	var err error
+	if dialect, err = parseDialect(cfg.Driver); err != nil {
+		return err
+	}
 
-	// Create DSN (Data Source Name)
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
-		dbUser, dbPassword, dbHost, dbPort, dbName)
+	defaultPort := "3306"
+	dbLabel := "MySQL"
+	if dialect == DialectPostgres {
+		defaultPort, dbLabel = "5432", "PostgreSQL"
+	}
 
-	// Open database connection
-	var err error
-	DB, err = sql.Open("mysql", dsn)
-	if err != nil {
-		return fmt.Errorf("failed to open database connection: %v", err)
+	dbPort := valueOr(cfg.Port, defaultPort)
+	primary = newServerConnector(cfg.Host, dbPort)
+
+	// Open database connection, with failover to a standby when one is configured
+	var connector driver.Connector = primary
+	if secondaryHost := cfg.SecondaryHost; secondaryHost != "" {
+		secondaryPort := valueOr(cfg.SecondaryPort, dbPort)
+		connector, err = openWithFailover(
+			&failoverEndpoint{name: "primary", server: primary},
+			&failoverEndpoint{name: "secondary", server: newServerConnector(secondaryHost, secondaryPort)},
+		)
+		if err != nil {
+			return fmt.Errorf("failed to open database connection: %v", err)
+		}
 	}
 
-	// Test connection
-	if err = DB.Ping(); err != nil {
+	// Fail fast with ErrDatabaseUnavailable while the server is down
+	breaker = newCircuitBreaker(connector, cfg.BreakerThreshold, cfg.BreakerProbeInterval)
+	DB = sql.OpenDB(breaker)
+
+	// Test connection, waiting for a server that is still starting. The breaker only counts
+	// failures from then on, so it cannot trip during these retries.
+	if err = pingWithRetry(cfg.ConnectAttempts, cfg.ConnectMaxWait); err != nil {
 		return fmt.Errorf("failed to ping database: %v", err)
 	}
+	breaker.arm()
 
 	// Set connection pool settings
 	DB.SetMaxOpenConns(25)
 	DB.SetMaxIdleConns(10)
+	DB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	log.Printf("✅ Connected to MySQL database: %s@%s:%s/%s", dbUser, dbHost, dbPort, dbName)
-
-	// Create tables if they don't exist
-	if err := createTables(); err != nil {
-		return fmt.Errorf("failed to create tables: %v", err)
+	// Spread user reads over the replicas
+	if len(cfg.ReplicaDSNs) > 0 {
+		if replicas, err = openReplicas(cfg.ReplicaDSNs, cfg.ReplicaCheckInterval); err != nil {
+			return err
+		}
 	}
 
+	log.Printf("✅ Connected to %s database: %s@%s/%s", dbLabel, databaseUser(), primary.Address(), cfg.Name)
+
 	return nil
 }
 
-// Helper function to get environment variable with fallback
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Initial wait between connection attempts; it doubles up to DB_CONNECT_MAX_WAIT
+const initialConnectBackoff = 500 * time.Millisecond
+
+// Helper function to ping DB up to attempts times with exponential backoff. Each wait is
+// jittered between half and all of the backoff, so replicas started together spread out.
+func pingWithRetry(attempts int, maxWait time.Duration) error {
+	backoff := initialConnectBackoff
+	for attempt := 1; ; attempt++ {
+		err := DB.Ping()
+		if err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("giving up after %d attempts: %v", attempt, err)
+		}
+
+		backoff = min(backoff, maxWait)
+		wait := backoff/2 + rand.N(backoff/2+1)
+		log.Printf("⏳ Database not ready (attempt %d/%d): %v; retrying in %s",
+			attempt, attempts, err, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+		backoff *= 2
 	}
-	return fallback
 }
 
-// Create database tables
-func createTables() error {
-	createUsersTable := `
-	CREATE TABLE IF NOT EXISTS users (
-		id INT AUTO_INCREMENT PRIMARY KEY,
-		name VARCHAR(255) NOT NULL,
-		email VARCHAR(255) NOT NULL UNIQUE,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
-	) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;`
-
-	if _, err := DB.Exec(createUsersTable); err != nil {
-		return fmt.Errorf("failed to create users table: %v", err)
+// Helper function to fall back to a default for an unset value
+func valueOr(value, fallback string) string {
+	if value != "" {
+		return value
 	}
-
-	log.Println("✅ Database tables created/verified successfully")
-	return nil
+	return fallback
 }
 
 // Close database connection
 func CloseDB() {
+	if breaker != nil {
+		breaker.close()
+	}
+	if replicas != nil {
+		replicas.close()
+		replicas = nil
+	}
+	stopFailover()
 	if DB != nil {
 		DB.Close()
 		log.Println("📝 Database connection closed")
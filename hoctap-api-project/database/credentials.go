@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+
+	"hoctap-api/config"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// serverConnector opens connections to one server with the credentials of the current
+// config, so credentials rotated by a secret backend apply to every new connection. Pooled
+// connections keep their credentials until DB_CONN_MAX_LIFETIME retires them.
+type serverConnector struct {
+	driver driver.Driver
+	host   string
+	port   string
+
+	mu   sync.Mutex
+	dsn  string
+	base driver.Connector
+}
+
+// Helper function to create a connector for host:port with the driver of the dialect
+func newServerConnector(host, port string) *serverConnector {
+	var drv driver.Driver = &mysql.MySQLDriver{}
+	if dialect == DialectPostgres {
+		drv = stdlib.GetDefaultDriver()
+	}
+	return &serverConnector{driver: drv, host: host, port: port}
+}
+
+// Connect opens a connection with the current credentials
+func (sc *serverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	base, err := sc.connector()
+	if err != nil {
+		return nil, err
+	}
+	return base.Connect(ctx)
+}
+
+// Driver returns the underlying driver
+func (sc *serverConnector) Driver() driver.Driver {
+	return sc.driver
+}
+
+// Address returns host:port of the server
+func (sc *serverConnector) Address() string {
+	return net.JoinHostPort(sc.host, sc.port)
+}
+
+// DSN returns the DSN of the server with the current credentials
+func (sc *serverConnector) DSN() string {
+	return dsnFor(sc.host, sc.port)
+}
+
+// Helper function to return the driver connector for the current DSN, rebuilding it when
+// the credentials changed
+func (sc *serverConnector) connector() (driver.Connector, error) {
+	dsn := sc.DSN()
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.base == nil || dsn != sc.dsn {
+		base, err := sc.driver.(driver.DriverContext).OpenConnector(dsn)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DSN for %s: %v", sc.Address(), err)
+		}
+		sc.dsn, sc.base = dsn, base
+	}
+	return sc.base, nil
+}
+
+// Helper function to return the database user, defaulting per dialect
+func databaseUser() string {
+	if dialect == DialectPostgres {
+		return valueOr(config.Current().Database.User, "postgres")
+	}
+	return valueOr(config.Current().Database.User, "root")
+}
+
+// Create DSN (Data Source Name) for a server
+func dsnFor(host, port string) string {
+	cfg := config.Current().Database
+
+	if dialect == DialectPostgres {
+		return (&url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(databaseUser(), cfg.Password),
+			Host:     net.JoinHostPort(host, port),
+			Path:     "/" + cfg.Name,
+			RawQuery: "sslmode=" + url.QueryEscape(cfg.SSLMode) + "&timezone=UTC",
+		}).String()
+	}
+	// interpolateParams sends parameterized queries in one round trip instead of prepare/execute/close.
+	// Sessions run in UTC, matching loc, so timestamps do not depend on the server's time zone.
+	return fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=UTC&time_zone=%%27%%2B00%%3A00%%27&interpolateParams=true",
+		databaseUser(), cfg.Password, host, port, cfg.Name)
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Dialect is the SQL flavor of the connected database
+type Dialect string
+
+// Supported dialects, selected with DB_DRIVER
+const (
+	DialectMySQL    Dialect = "mysql"
+	DialectPostgres Dialect = "postgres"
+)
+
+// dialect is set by InitDB. Repository queries are written with MySQL-style `?`
+// placeholders and passed through rebind.
+var dialect = DialectMySQL
+
+// CurrentDialect returns the dialect of the open connection
+func CurrentDialect() Dialect {
+	return dialect
+}
+
+// Helper function to parse DB_DRIVER
+func parseDialect(driver string) (Dialect, error) {
+	switch strings.ToLower(driver) {
+	case "", "mysql":
+		return DialectMySQL, nil
+	case "postgres", "postgresql", "pgx":
+		return DialectPostgres, nil
+	default:
+		return "", fmt.Errorf("unsupported DB_DRIVER '%s' (use mysql or postgres)", driver)
+	}
+}
+
+// Helper function to rewrite `?` placeholders for the current dialect (`$1, $2, ...` on
+// PostgreSQL). Question marks inside quoted literals are left alone.
+func rebind(query string) string {
+	if dialect != DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	var quote rune
+	n := 0
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Helper function to run an INSERT and return the generated id
+// (LastInsertId on MySQL, RETURNING id on PostgreSQL)
+func insertReturningID(ctx context.Context, db dbtx, query string, args ...interface{}) (int64, error) {
+	if dialect == DialectPostgres {
+		var id int64
+		err := db.QueryRowContext(ctx, rebind(query)+" RETURNING id", args...).Scan(&id)
+		return id, err
+	}
+
+	result, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// Helper function to return the case-insensitive LIKE operator
+func likeIgnoreCase() string {
+	if dialect == DialectPostgres {
+		return "ILIKE"
+	}
+	return "LIKE"
+}
+
+// likeEscaper escapes the LIKE wildcards of a literal, for the default `\` escape character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Helper function to return the number of the slot of seconds length a timestamp column
+// falls in, counted from the Unix epoch
+func epochSlot(column string, seconds int) string {
+	if dialect == DialectPostgres {
+		return fmt.Sprintf("FLOOR(EXTRACT(EPOCH FROM %s) / %d)::BIGINT", column, seconds)
+	}
+	return fmt.Sprintf("UNIX_TIMESTAMP(%s) DIV %d", column, seconds)
+}
+
+// Helper function to detect a unique constraint violation
+// (MySQL error 1062, PostgreSQL SQLSTATE 23505)
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == 1062
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	return false
+}
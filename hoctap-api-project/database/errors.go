@@ -0,0 +1,24 @@
+package database
+
+import "fmt"
+
+// sentinelError is an error with a detailed message, such as "user with ID 7 not found",
+// that matches a sentinel error such as ErrUserNotFound with errors.Is
+type sentinelError struct {
+	sentinel error
+	message  string
+}
+
+func (e *sentinelError) Error() string {
+	return e.message
+}
+
+// Is makes sentinelError match its sentinel
+func (e *sentinelError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// Helper function to format an error like fmt.Errorf that matches sentinel with errors.Is
+func sentinelErrorf(sentinel error, format string, args ...interface{}) error {
+	return &sentinelError{sentinel: sentinel, message: fmt.Sprintf(format, args...)}
+}
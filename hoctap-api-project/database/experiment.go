@@ -0,0 +1,35 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// ExperimentRepository records experiment exposures for analysis
+type ExperimentRepository struct {
+	db *sql.DB
+}
+
+// NewExperimentRepository creates a new experiment repository
+func NewExperimentRepository() *ExperimentRepository {
+	return &ExperimentRepository{db: DB}
+}
+
+// RecordExposure logs that a user was shown a variant. The first exposure time is kept
+// and subsequent exposures bump the counter.
+func (er *ExperimentRepository) RecordExposure(ctx context.Context, userID int, experiment, variant string) error {
+	query := `INSERT INTO experiment_exposures (user_id, experiment, variant) VALUES (?, ?, ?)
+		ON DUPLICATE KEY UPDATE variant = VALUES(variant), exposures = exposures + 1, last_exposed_at = CURRENT_TIMESTAMP`
+	if dialect == DialectPostgres {
+		query = `INSERT INTO experiment_exposures (user_id, experiment, variant) VALUES (?, ?, ?)
+		ON CONFLICT (experiment, user_id) DO UPDATE SET variant = EXCLUDED.variant,
+			exposures = experiment_exposures.exposures + 1, last_exposed_at = CURRENT_TIMESTAMP`
+	}
+
+	if _, err := er.db.ExecContext(ctx, rebind(query), userID, experiment, variant); err != nil {
+		return fmt.Errorf("failed to record experiment exposure: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,440 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hoctap-api/config"
+)
+
+// Failover timeouts; the check interval and threshold come from the database config
+const (
+	failoverProbeTimeout = 2 * time.Second
+	failoverDrainTimeout = 5 * time.Second
+)
+
+// Failover errors
+var (
+	ErrWritesFenced     = errors.New("database writes are fenced during failover")
+	ErrFailoverDisabled = errors.New("database failover is not configured (set DB_SECONDARY_HOST)")
+)
+
+// FailoverEndpointStatus is the last observed state of one database server
+type FailoverEndpointStatus struct {
+	Name      string     `json:"name"`
+	Address   string     `json:"address"`
+	Active    bool       `json:"active"`
+	Healthy   bool       `json:"healthy"`
+	Writable  bool       `json:"writable"`
+	LastError string     `json:"last_error,omitempty"`
+	CheckedAt *time.Time `json:"checked_at"`
+}
+
+// FailoverStatus describes the failover state of the database connection
+type FailoverStatus struct {
+	Enabled          bool                     `json:"enabled"`
+	Fenced           bool                     `json:"fenced"`
+	Endpoints        []FailoverEndpointStatus `json:"endpoints"`
+	LastSwitchAt     *time.Time               `json:"last_switch_at"`
+	LastSwitchReason string                   `json:"last_switch_reason,omitempty"`
+}
+
+// failoverEndpoint is one server that can act as primary
+type failoverEndpoint struct {
+	name   string
+	server *serverConnector
+
+	// probe is a single-connection pool used for health and writability checks
+	probe *sql.DB
+
+	mu        sync.Mutex
+	healthy   bool
+	writable  bool
+	lastError string
+	checkedAt *time.Time
+}
+
+// failoverConnector hands out connections to the active endpoint. Switching endpoints bumps
+// the generation, which makes pooled connections to the old server invalid.
+type failoverConnector struct {
+	driver    driver.Driver
+	endpoints []*failoverEndpoint
+
+	active     atomic.Int32
+	generation atomic.Uint64
+	fenced     atomic.Bool
+	inflight   atomic.Int64
+
+	// switchMu serializes switchovers
+	switchMu         sync.Mutex
+	lastSwitchAt     *time.Time
+	lastSwitchReason string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// failover is set when the connection was opened with a standby
+var failover *failoverConnector
+
+// Helper function to create a failover connector and start health monitoring
+func openWithFailover(endpoints ...*failoverEndpoint) (driver.Connector, error) {
+	connector := &failoverConnector{driver: endpoints[0].server.Driver(), endpoints: endpoints}
+	for _, endpoint := range endpoints {
+		if _, err := endpoint.server.connector(); err != nil {
+			return nil, fmt.Errorf("%s database: %v", endpoint.name, err)
+		}
+		endpoint.probe = sql.OpenDB(endpoint.server)
+		endpoint.probe.SetMaxOpenConns(1)
+		endpoint.probe.SetConnMaxIdleTime(time.Minute)
+	}
+
+	cfg := config.Current().Database
+
+	failover = connector
+	connector.startMonitor(cfg.FailoverCheckInterval, cfg.FailoverThreshold)
+	log.Printf("🔁 Database failover enabled: %s -> %s", endpoints[0].server.Address(), endpoints[1].server.Address())
+
+	return connector, nil
+}
+
+// Connect opens a connection to the active endpoint
+func (fc *failoverConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	generation := fc.generation.Load()
+	conn, err := fc.endpoints[fc.active.Load()].server.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &failoverConn{Conn: conn, connector: fc, generation: generation}, nil
+}
+
+// Driver returns the underlying driver
+func (fc *failoverConnector) Driver() driver.Driver {
+	return fc.driver
+}
+
+// Helper function to admit a write, failing fast while writes are fenced.
+// The returned function must be called when the write finishes.
+func (fc *failoverConnector) beginWrite() (func(), error) {
+	if fc.fenced.Load() {
+		return nil, ErrWritesFenced
+	}
+	fc.inflight.Add(1)
+	// Re-check so a write admitted concurrently with fencing is counted by the drain
+	if fc.fenced.Load() {
+		fc.inflight.Add(-1)
+		return nil, ErrWritesFenced
+	}
+	return func() { fc.inflight.Add(-1) }, nil
+}
+
+// Helper function to check one endpoint's health and whether it accepts writes
+func (fc *failoverConnector) check(endpoint *failoverEndpoint) {
+	ctx, cancel := context.WithTimeout(context.Background(), failoverProbeTimeout)
+	defer cancel()
+
+	query := `SELECT @@global.read_only = 0`
+	if dialect == DialectPostgres {
+		query = `SELECT NOT pg_is_in_recovery()`
+	}
+
+	var writable bool
+	err := endpoint.probe.QueryRowContext(ctx, query).Scan(&writable)
+	now := time.Now()
+
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+	endpoint.healthy = err == nil
+	endpoint.writable = err == nil && writable
+	endpoint.lastError = ""
+	if err != nil {
+		endpoint.lastError = err.Error()
+	}
+	endpoint.checkedAt = &now
+}
+
+// Helper function to read an endpoint's last check result
+func (endpoint *failoverEndpoint) state() (healthy, writable bool) {
+	endpoint.mu.Lock()
+	defer endpoint.mu.Unlock()
+	return endpoint.healthy, endpoint.writable
+}
+
+// Helper function to monitor the endpoints and fail over automatically after threshold
+// consecutive failed checks of the active one, once the standby has been promoted
+func (fc *failoverConnector) startMonitor(interval time.Duration, threshold int) {
+	fc.stop = make(chan struct{})
+	fc.done = make(chan struct{})
+
+	go func() {
+		defer close(fc.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		failures := 0
+		warned := false
+		for {
+			for _, endpoint := range fc.endpoints {
+				fc.check(endpoint)
+			}
+
+			active := fc.active.Load()
+			if healthy, writable := fc.endpoints[active].state(); healthy && writable {
+				failures, warned = 0, false
+			} else {
+				failures++
+			}
+
+			if failures >= threshold {
+				target := int((active + 1) % int32(len(fc.endpoints)))
+				reason := fmt.Sprintf("automatic: %s failed %d consecutive checks", fc.endpoints[active].name, failures)
+				if err := fc.switchTo(target, reason); err != nil {
+					// Keep retrying every check, but only log once per outage
+					if !warned {
+						log.Printf("⚠️ Warning: database failover to %s not possible: %v", fc.endpoints[target].name, err)
+						warned = true
+					}
+				} else {
+					failures, warned = 0, false
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-fc.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Helper function to make target the active endpoint. Writes are fenced while switching:
+// in-flight writes are drained, the target is verified to be a writable primary, and
+// pooled connections to the previous server are invalidated.
+func (fc *failoverConnector) switchTo(target int, reason string) error {
+	fc.switchMu.Lock()
+	defer fc.switchMu.Unlock()
+
+	if int(fc.active.Load()) == target {
+		return fmt.Errorf("%s is already the active database", fc.endpoints[target].name)
+	}
+
+	fc.fenced.Store(true)
+	defer fc.fenced.Store(false)
+
+	deadline := time.Now().Add(failoverDrainTimeout)
+	for fc.inflight.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	endpoint := fc.endpoints[target]
+	fc.check(endpoint)
+	if healthy, writable := endpoint.state(); !healthy {
+		return fmt.Errorf("%s (%s) is unreachable", endpoint.name, endpoint.server.Address())
+	} else if !writable {
+		return fmt.Errorf("%s (%s) is read-only; promote it before switching", endpoint.name, endpoint.server.Address())
+	}
+
+	previous := fc.endpoints[fc.active.Load()]
+	fc.active.Store(int32(target))
+	fc.generation.Add(1)
+
+	now := time.Now()
+	fc.lastSwitchAt = &now
+	fc.lastSwitchReason = reason
+	log.Printf("🔁 Database switched from %s (%s) to %s (%s): %s",
+		previous.name, previous.server.Address(), endpoint.name, endpoint.server.Address(), reason)
+	return nil
+}
+
+// Helper function to stop monitoring and close the probe connections
+func stopFailover() {
+	if failover == nil || failover.stop == nil {
+		return
+	}
+	close(failover.stop)
+	<-failover.done
+	for _, endpoint := range failover.endpoints {
+		endpoint.probe.Close()
+	}
+	failover = nil
+}
+
+// Switchover makes the other configured server the active database (controlled failover).
+// It fails if that server is unreachable or still read-only.
+func Switchover(reason string) (FailoverStatus, error) {
+	if failover == nil {
+		return FailoverStatus{}, ErrFailoverDisabled
+	}
+	if reason == "" {
+		reason = "manual switchover"
+	}
+
+	target := int((failover.active.Load() + 1) % int32(len(failover.endpoints)))
+	err := failover.switchTo(target, reason)
+	return GetFailoverStatus(), err
+}
+
+// GetFailoverStatus reports the active server, fencing state and last health checks
+func GetFailoverStatus() FailoverStatus {
+	if failover == nil {
+		return FailoverStatus{Enabled: false, Endpoints: []FailoverEndpointStatus{}}
+	}
+
+	failover.switchMu.Lock()
+	status := FailoverStatus{
+		Enabled:          true,
+		Fenced:           failover.fenced.Load(),
+		LastSwitchAt:     failover.lastSwitchAt,
+		LastSwitchReason: failover.lastSwitchReason,
+	}
+	failover.switchMu.Unlock()
+
+	active := int(failover.active.Load())
+	for i, endpoint := range failover.endpoints {
+		endpoint.mu.Lock()
+		status.Endpoints = append(status.Endpoints, FailoverEndpointStatus{
+			Name:      endpoint.name,
+			Address:   endpoint.server.Address(),
+			Active:    i == active,
+			Healthy:   endpoint.healthy,
+			Writable:  endpoint.writable,
+			LastError: endpoint.lastError,
+			CheckedAt: endpoint.checkedAt,
+		})
+		endpoint.mu.Unlock()
+	}
+	return status
+}
+
+// failoverConn wraps a driver connection with the generation it was opened in and
+// enforces write fencing
+type failoverConn struct {
+	driver.Conn
+	connector  *failoverConnector
+	generation uint64
+}
+
+// Helper function to tell whether a connection predates the last switch
+func (c *failoverConn) stale() bool {
+	return c.generation != c.connector.generation.Load()
+}
+
+// Helper function to classify statements that modify data or schema
+func isWriteStatement(query string) bool {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "CREATE", "ALTER", "DROP", "TRUNCATE":
+		return true
+	}
+	return false
+}
+
+// IsValid lets database/sql discard pooled connections to the previous server
+func (c *failoverConn) IsValid() bool {
+	if c.stale() {
+		return false
+	}
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// ResetSession rejects stale connections before they are reused
+func (c *failoverConn) ResetSession(ctx context.Context) error {
+	if c.stale() {
+		return driver.ErrBadConn
+	}
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+// Prepare fences write statements
+func (c *failoverConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+// PrepareContext fences write statements
+func (c *failoverConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if isWriteStatement(query) && c.connector.fenced.Load() {
+		return nil, ErrWritesFenced
+	}
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// ExecContext fences write statements and tracks them for draining
+func (c *failoverConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if isWriteStatement(query) {
+		done, err := c.connector.beginWrite()
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+// QueryContext fences write statements (e.g. INSERT ... RETURNING)
+func (c *failoverConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if isWriteStatement(query) {
+		done, err := c.connector.beginWrite()
+		if err != nil {
+			return nil, err
+		}
+		defer done()
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+// BeginTx fences read-write transactions
+func (c *failoverConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if !opts.ReadOnly && c.connector.fenced.Load() {
+		return nil, ErrWritesFenced
+	}
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+// Ping forwards to the driver connection
+func (c *failoverConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+// CheckNamedValue keeps the driver's own argument conversions
+func (c *failoverConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
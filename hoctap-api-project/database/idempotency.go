@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Idempotency key settings
+const (
+	// idempotencyLockTimeout is how long a key stays reserved by a request that never
+	// completes (e.g. the instance crashed), after which a retry may run again
+	idempotencyLockTimeout = time.Minute
+)
+
+var (
+	// ErrIdempotencyKeyInUse is returned while the first request with a key is still running
+	ErrIdempotencyKeyInUse = errors.New("idempotency key is in use by a request in progress")
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+)
+
+// IdempotentResponse is the response stored for an idempotency key and replayed for repeats
+type IdempotentResponse struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// IdempotencyRepository stores the responses of requests sent with an Idempotency-Key
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+// NewIdempotencyRepository creates a new idempotency key repository
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{db: DB}
+}
+
+// Reserve claims key for the request identified by requestHash. For a key seen before it
+// returns the stored response of that request, ErrIdempotencyKeyInUse while the request is
+// still running, or ErrIdempotencyKeyReused when the key came with a different request.
+// A nil response and error mean the caller owns the key and must Complete or Release it.
+func (ir *IdempotencyRepository) Reserve(ctx context.Context, key, requestHash string) (*IdempotentResponse, error) {
+	now := time.Now()
+
+	// An expired key may be reused as if it had never been sent
+	if _, err := ir.db.ExecContext(ctx, rebind(`DELETE FROM idempotency_keys WHERE idempotency_key = ? AND expires_at < ?`),
+		key, now); err != nil {
+		return nil, fmt.Errorf("failed to expire idempotency key: %v", err)
+	}
+
+	_, err := ir.db.ExecContext(ctx, rebind(`INSERT INTO idempotency_keys (idempotency_key, request_hash, expires_at) VALUES (?, ?, ?)`),
+		key, requestHash, now.Add(idempotencyLockTimeout))
+	if err == nil {
+		return nil, nil
+	}
+	if !isDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %v", err)
+	}
+
+	var storedHash string
+	var response IdempotentResponse
+	err = ir.db.QueryRowContext(ctx, rebind(`SELECT request_hash, status_code, content_type, body FROM idempotency_keys WHERE idempotency_key = ?`),
+		key).Scan(&storedHash, &response.StatusCode, &response.ContentType, &response.Body)
+	if err == sql.ErrNoRows {
+		// Released between our insert and select; the client may simply retry
+		return nil, ErrIdempotencyKeyInUse
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %v", err)
+	}
+
+	switch {
+	case storedHash != requestHash:
+		return nil, ErrIdempotencyKeyReused
+	case response.StatusCode == 0:
+		return nil, ErrIdempotencyKeyInUse
+	default:
+		return &response, nil
+	}
+}
+
+// Complete stores the response for a reserved key, to be replayed until ttl has passed
+func (ir *IdempotencyRepository) Complete(ctx context.Context, key string, response IdempotentResponse, ttl time.Duration) error {
+	query := `UPDATE idempotency_keys SET status_code = ?, content_type = ?, body = ?, expires_at = ? WHERE idempotency_key = ?`
+
+	if _, err := ir.db.ExecContext(ctx, rebind(query), response.StatusCode, response.ContentType, response.Body,
+		time.Now().Add(ttl), key); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %v", err)
+	}
+	return nil
+}
+
+// Release gives up a reserved key without storing a response, so the request can be retried
+func (ir *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := ir.db.ExecContext(ctx, rebind(`DELETE FROM idempotency_keys WHERE idempotency_key = ?`), key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %v", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes the keys whose response is no longer replayed
+func (ir *IdempotencyRepository) PurgeExpired(ctx context.Context) (int64, error) {
+	purged, err := execRowsAffected(ctx, ir.db, `DELETE FROM idempotency_keys WHERE expires_at < ?`, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %v", err)
+	}
+	return purged, nil
+}
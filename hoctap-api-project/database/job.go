@@ -0,0 +1,325 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Job statuses. Finished jobs are deleted, so a job is waiting, being run or dead: out of
+// attempts, kept for inspection until retried or deleted.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDead    = "dead"
+)
+
+// maxJobErrorLength is the size of the last_error column
+const maxJobErrorLength = 1024
+
+// Job is a unit of background work with a JSON payload
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error"`
+	RunAt       time.Time       `json:"run_at"`
+	LockedAt    *time.Time      `json:"locked_at"`
+	LockedBy    string          `json:"locked_by"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// JobFilter selects jobs for the admin listing; empty fields match every job
+type JobFilter struct {
+	Status string
+	Kind   string
+	Limit  int
+}
+
+// JobCount is the number of jobs of a kind in a status
+type JobCount struct {
+	Kind   string `json:"kind"`
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// ErrJobNotFound matches (with errors.Is) the error of a missing job, or of deleting a job
+// that is not on the dead-letter list
+var ErrJobNotFound = errors.New("job not found")
+
+// ErrJobLeaseLost is returned when a worker records the outcome of a job it no longer holds
+var ErrJobLeaseLost = errors.New("job lease lost")
+
+// JobRepository stores the background job queue
+type JobRepository struct {
+	db *sql.DB
+}
+
+// NewJobRepository creates a new job repository
+func NewJobRepository() *JobRepository {
+	return &JobRepository{db: DB}
+}
+
+// EnqueueJob adds a job that becomes due at runAt
+func (jr *JobRepository) EnqueueJob(ctx context.Context, kind string, payload []byte, maxAttempts int, runAt time.Time) (int64, error) {
+	query := `INSERT INTO jobs (kind, payload, status, max_attempts, run_at) VALUES (?, ?, ?, ?, ?)`
+
+	id, err := insertReturningID(ctx, jr.db, query, kind, string(payload), JobQueued, maxAttempts, runAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue %s job: %v", kind, err)
+	}
+
+	return id, nil
+}
+
+// ClaimJob locks the oldest due job for worker and counts the attempt, returning nil when
+// no job is due. Running jobs locked before staleBefore belonged to a worker that died and
+// are claimed again. SKIP LOCKED lets several instances claim concurrently without waiting.
+func (jr *JobRepository) ClaimJob(ctx context.Context, worker string, now, staleBefore time.Time) (*Job, error) {
+	var job *Job
+	err := inTx(ctx, jr.db, func(tx *sql.Tx) error {
+		query := `SELECT id FROM jobs
+			WHERE (status = ? AND run_at <= ?) OR (status = ? AND locked_at < ?)
+			ORDER BY run_at, id LIMIT 1 FOR UPDATE SKIP LOCKED`
+
+		var id int64
+		err := tx.QueryRowContext(ctx, rebind(query), JobQueued, now, JobRunning, staleBefore).Scan(&id)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		update := `UPDATE jobs SET status = ?, attempts = attempts + 1, locked_at = ?, locked_by = ? WHERE id = ?`
+		if _, err := tx.ExecContext(ctx, rebind(update), JobRunning, now, worker, id); err != nil {
+			return err
+		}
+
+		job, err = getJob(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %v", err)
+	}
+
+	return job, nil
+}
+
+// CompleteJob deletes a job that succeeded, if worker still holds it
+func (jr *JobRepository) CompleteJob(ctx context.Context, id int64, worker string) error {
+	query := `DELETE FROM jobs WHERE id = ? AND status = ? AND locked_by = ?`
+
+	completed, err := execRowsAffected(ctx, jr.db, query, id, JobRunning, worker)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %v", err)
+	}
+	if completed == 0 {
+		return jobLeaseLost(id, worker)
+	}
+
+	return nil
+}
+
+// RescheduleJob records a failed attempt and queues the job again at runAt, if worker still
+// holds it
+func (jr *JobRepository) RescheduleJob(ctx context.Context, id int64, worker, lastError string, runAt time.Time) error {
+	query := `UPDATE jobs SET status = ?, last_error = ?, run_at = ?, locked_at = NULL, locked_by = ''
+		WHERE id = ? AND status = ? AND locked_by = ?`
+
+	rescheduled, err := execRowsAffected(ctx, jr.db, query, JobQueued, truncateJobError(lastError), runAt, id, JobRunning, worker)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule job: %v", err)
+	}
+	if rescheduled == 0 {
+		return jobLeaseLost(id, worker)
+	}
+
+	return nil
+}
+
+// BuryJob moves a job that failed for the last time to the dead-letter list, if worker
+// still holds it
+func (jr *JobRepository) BuryJob(ctx context.Context, id int64, worker, lastError string) error {
+	query := `UPDATE jobs SET status = ?, last_error = ?, locked_at = NULL, locked_by = ''
+		WHERE id = ? AND status = ? AND locked_by = ?`
+
+	buried, err := execRowsAffected(ctx, jr.db, query, JobDead, truncateJobError(lastError), id, JobRunning, worker)
+	if err != nil {
+		return fmt.Errorf("failed to bury job: %v", err)
+	}
+	if buried == 0 {
+		return jobLeaseLost(id, worker)
+	}
+
+	return nil
+}
+
+// Helper function to report that worker no longer holds a job: its lease expired and the
+// job was claimed again, so the other worker's run decides the outcome
+func jobLeaseLost(id int64, worker string) error {
+	return sentinelErrorf(ErrJobLeaseLost, "job with ID %d is no longer held by worker %s, its outcome was dropped", id, worker)
+}
+
+// RetryJob queues a dead job again with a fresh set of attempts
+func (jr *JobRepository) RetryJob(ctx context.Context, id int64) (*Job, error) {
+	query := `UPDATE jobs SET status = ?, attempts = 0, run_at = ? WHERE id = ? AND status = ?`
+
+	retried, err := execRowsAffected(ctx, jr.db, query, JobQueued, time.Now(), id, JobDead)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry job: %v", err)
+	}
+
+	job, err := jr.GetJobByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if retried == 0 {
+		return nil, &JobNotDeadError{ID: id, Status: job.Status}
+	}
+
+	return job, nil
+}
+
+// JobNotDeadError is the error of retrying a job that is not on the dead-letter list
+type JobNotDeadError struct {
+	ID     int64
+	Status string
+}
+
+func (e *JobNotDeadError) Error() string {
+	return fmt.Sprintf("job with ID %d is %s, only dead jobs can be retried", e.ID, e.Status)
+}
+
+// DeleteDeadJob discards a job of the dead-letter list
+func (jr *JobRepository) DeleteDeadJob(ctx context.Context, id int64) error {
+	deleted, err := execRowsAffected(ctx, jr.db, `DELETE FROM jobs WHERE id = ? AND status = ?`, id, JobDead)
+	if err != nil {
+		return fmt.Errorf("failed to delete job: %v", err)
+	}
+
+	if deleted == 0 {
+		return sentinelErrorf(ErrJobNotFound, "dead job with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// GetJobByID retrieves a job by ID
+func (jr *JobRepository) GetJobByID(ctx context.Context, id int64) (*Job, error) {
+	job, err := getJob(ctx, jr.db, id)
+	if err == sql.ErrNoRows {
+		return nil, sentinelErrorf(ErrJobNotFound, "job with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %v", err)
+	}
+
+	return job, nil
+}
+
+// ListJobs retrieves jobs matching filter, most recently changed first
+func (jr *JobRepository) ListJobs(ctx context.Context, filter JobFilter) ([]Job, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+
+	query := `SELECT ` + jobColumns + ` FROM jobs`
+	var conditions []string
+	var args []interface{}
+	if filter.Status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.Kind != "" {
+		conditions = append(conditions, "kind = ?")
+		args = append(args, filter.Kind)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += ` ORDER BY updated_at DESC, id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := jr.db.QueryContext(ctx, rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %v", err)
+	}
+	defer rows.Close()
+
+	jobs := []Job{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %v", err)
+		}
+		jobs = append(jobs, *job)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return jobs, nil
+}
+
+// CountJobs counts the jobs of every kind and status
+func (jr *JobRepository) CountJobs(ctx context.Context) ([]JobCount, error) {
+	rows, err := jr.db.QueryContext(ctx, `SELECT kind, status, COUNT(*) FROM jobs GROUP BY kind, status ORDER BY kind, status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count jobs: %v", err)
+	}
+	defer rows.Close()
+
+	counts := []JobCount{}
+	for rows.Next() {
+		var c JobCount
+		if err := rows.Scan(&c.Kind, &c.Status, &c.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan job count: %v", err)
+		}
+		counts = append(counts, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return counts, nil
+}
+
+// jobColumns are the columns scanned by scanJob
+const jobColumns = `id, kind, payload, status, attempts, max_attempts, last_error, run_at, locked_at, locked_by, created_at, updated_at`
+
+// Helper function to read one job inside or outside a transaction
+func getJob(ctx context.Context, db dbtx, id int64) (*Job, error) {
+	return scanJob(db.QueryRowContext(ctx, rebind(`SELECT `+jobColumns+` FROM jobs WHERE id = ?`), id))
+}
+
+// Helper function to scan a row of jobColumns
+func scanJob(row interface{ Scan(...interface{}) error }) (*Job, error) {
+	var j Job
+	var payload string
+	err := row.Scan(&j.ID, &j.Kind, &payload, &j.Status, &j.Attempts, &j.MaxAttempts, &j.LastError,
+		&j.RunAt, &j.LockedAt, &j.LockedBy, &j.CreatedAt, &j.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = json.RawMessage(payload)
+	return &j, nil
+}
+
+// Helper function to fit an error message into the last_error column
+func truncateJobError(message string) string {
+	if len(message) <= maxJobErrorLength {
+		return message
+	}
+	return strings.ToValidUTF8(message[:maxJobErrorLength], "")
+}
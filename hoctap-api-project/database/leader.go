@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// LeaderLock elects one instance among those sharing the database, with an advisory lock
+// (GET_LOCK in MySQL, pg_try_advisory_lock in PostgreSQL) held on a connection of its own.
+// The server releases the lock when that connection closes, so a leader that crashes or
+// loses the database hands over to another instance.
+type LeaderLock struct {
+	name string
+	key  int64
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+// NewLeaderLock creates a lock; instances electing a leader for the same work use the same name
+func NewLeaderLock(name string) *LeaderLock {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return &LeaderLock{name: name, key: int64(hash.Sum64())}
+}
+
+// Acquire reports whether this instance holds the lock, taking it without waiting when it is
+// free. While held, it checks that the connection holding it is still alive: a lost
+// connection means the lock was lost too.
+func (ll *LeaderLock) Acquire(ctx context.Context) (bool, error) {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	if ll.conn != nil {
+		if err := ll.conn.PingContext(ctx); err == nil {
+			return true, nil
+		}
+		discardConn(ll.conn)
+		ll.conn = nil
+	}
+
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire leader lock '%s': %v", ll.name, err)
+	}
+
+	var acquired sql.NullBool
+	if dialect == DialectPostgres {
+		err = conn.QueryRowContext(ctx, rebind(`SELECT pg_try_advisory_lock(?)`), ll.key).Scan(&acquired)
+	} else {
+		err = conn.QueryRowContext(ctx, `SELECT GET_LOCK(?, 0)`, ll.name).Scan(&acquired)
+	}
+	if err != nil || !acquired.Bool {
+		// Not holding the lock, the connection can go back to the pool
+		conn.Close()
+		if err != nil {
+			return false, fmt.Errorf("failed to acquire leader lock '%s': %v", ll.name, err)
+		}
+		return false, nil
+	}
+
+	ll.conn = conn
+	return true, nil
+}
+
+// Release gives up the lock, if held, so another instance can take over right away
+func (ll *LeaderLock) Release(ctx context.Context) error {
+	ll.mu.Lock()
+	defer ll.mu.Unlock()
+
+	if ll.conn == nil {
+		return nil
+	}
+
+	var err error
+	if dialect == DialectPostgres {
+		_, err = ll.conn.ExecContext(ctx, rebind(`SELECT pg_advisory_unlock(?)`), ll.key)
+	} else {
+		_, err = ll.conn.ExecContext(ctx, `SELECT RELEASE_LOCK(?)`, ll.name)
+	}
+	if err != nil {
+		// Closing the session releases the lock anyway
+		discardConn(ll.conn)
+		ll.conn = nil
+		return fmt.Errorf("failed to release leader lock '%s': %v", ll.name, err)
+	}
+
+	ll.conn.Close()
+	ll.conn = nil
+	return nil
+}
+
+// Helper function to close the session of a connection instead of returning it to the
+// pool, which would keep a lock it may still hold
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+	conn.Close()
+}
@@ -0,0 +1,315 @@
+package database
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryUserStore is an in-memory UserStore for running the API without a database
+// (`hoctap-api serve -mock`). It follows the error messages and ordering of UserRepository;
+// changes are not audited and are lost on restart.
+type MemoryUserStore struct {
+	mu     sync.RWMutex
+	users  map[int]User
+	nextID int
+	// txMu runs transactions one at a time
+	txMu sync.Mutex
+}
+
+// MemoryUserStore must satisfy UserStore
+var _ UserStore = (*MemoryUserStore)(nil)
+
+// NewMemoryUserStore creates a store holding users. Users without an ID get the next free
+// one, and users without a UUID a random one.
+func NewMemoryUserStore(users ...User) *MemoryUserStore {
+	ms := &MemoryUserStore{users: map[int]User{}}
+	for _, user := range users {
+		if user.ID == 0 {
+			ms.nextID++
+			user.ID = ms.nextID
+		} else if user.ID > ms.nextID {
+			ms.nextID = user.ID
+		}
+		if user.UUID == "" {
+			user.UUID = newUUID()
+		}
+		ms.users[user.ID] = user
+	}
+	return ms
+}
+
+// WithActor returns the store itself; there is no audit log in memory
+func (ms *MemoryUserStore) WithActor(actor AuditActor) UserStore {
+	return ms
+}
+
+// WithTx runs fn and restores the users it changed when it fails. Transactions run one at a
+// time but are not isolated from writes made outside of them.
+func (ms *MemoryUserStore) WithTx(ctx context.Context, fn func(tx UserStore) error) (err error) {
+	ms.txMu.Lock()
+	defer ms.txMu.Unlock()
+
+	ms.mu.RLock()
+	snapshot, nextID := make(map[int]User, len(ms.users)), ms.nextID
+	for id, user := range ms.users {
+		snapshot[id] = user
+	}
+	ms.mu.RUnlock()
+
+	rollback := func() {
+		ms.mu.Lock()
+		ms.users, ms.nextID = snapshot, nextID
+		ms.mu.Unlock()
+	}
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			rollback()
+			panic(recovered)
+		}
+	}()
+
+	if err := fn(memoryTx{ms}); err != nil {
+		rollback()
+		return err
+	}
+	return nil
+}
+
+// memoryTx is the store inside MemoryUserStore.WithTx; nested transactions join it
+type memoryTx struct {
+	*MemoryUserStore
+}
+
+func (tx memoryTx) WithTx(ctx context.Context, fn func(tx UserStore) error) error {
+	return fn(tx)
+}
+
+func (tx memoryTx) WithActor(actor AuditActor) UserStore {
+	return tx
+}
+
+// GetAllUsers returns every user, newest first
+func (ms *MemoryUserStore) GetAllUsers(ctx context.Context) ([]User, error) {
+	return ms.ListUsers(ctx, UserFilter{})
+}
+
+// ListUsers returns the users matching filter, newest first
+func (ms *MemoryUserStore) ListUsers(ctx context.Context, filter UserFilter) ([]User, error) {
+	users := ms.matching(filter)
+
+	if filter.After != nil {
+		start := sort.Search(len(users), func(i int) bool { return filter.After.Before(users[i]) })
+		users = users[start:]
+	}
+	if filter.Offset > 0 {
+		users = users[min(filter.Offset, len(users)):]
+	}
+	if filter.Limit > 0 {
+		users = users[:min(filter.Limit, len(users))]
+	}
+	return users, nil
+}
+
+// SearchUsers returns up to limit users whose name or email starts with prefix, ignoring
+// case, ordered by name
+func (ms *MemoryUserStore) SearchUsers(ctx context.Context, prefix string, limit int) ([]User, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	prefix = strings.ToLower(prefix)
+	users := []User{}
+	for _, user := range ms.users {
+		if strings.HasPrefix(strings.ToLower(user.Name), prefix) || strings.HasPrefix(strings.ToLower(user.Email), prefix) {
+			users = append(users, User{ID: user.ID, UUID: user.UUID, Name: user.Name, Email: user.Email})
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Name != users[j].Name {
+			return users[i].Name < users[j].Name
+		}
+		return users[i].ID < users[j].ID
+	})
+	return users[:min(limit, len(users))], nil
+}
+
+// CountUsers counts the users matching filter
+func (ms *MemoryUserStore) CountUsers(ctx context.Context, filter UserFilter) (int, error) {
+	return len(ms.matching(filter)), nil
+}
+
+// CountSignups counts the users created in [from, to) per SignupSlot
+func (ms *MemoryUserStore) CountSignups(ctx context.Context, from, to time.Time) ([]SignupCount, error) {
+	var counts []SignupCount
+	users := ms.matching(UserFilter{CreatedSince: from})
+	// Users are newest first, so slots are counted from the last one
+	for i := len(users) - 1; i >= 0; i-- {
+		if !users[i].CreatedAt.Before(to) {
+			break
+		}
+		start := users[i].CreatedAt.Truncate(SignupSlot).UTC()
+		if n := len(counts); n > 0 && counts[n-1].Start.Equal(start) {
+			counts[n-1].Count++
+		} else {
+			counts = append(counts, SignupCount{Start: start, Count: 1})
+		}
+	}
+	return counts, nil
+}
+
+// GetUserByID retrieves a user by ID
+func (ms *MemoryUserStore) GetUserByID(ctx context.Context, id int) (*User, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	user, ok := ms.users[id]
+	if !ok {
+		return nil, sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+	}
+	return &user, nil
+}
+
+// GetUserIDByUUID returns the numeric ID of the user with a UUID, given in either case
+func (ms *MemoryUserStore) GetUserIDByUUID(ctx context.Context, uuid string) (int, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	uuid = normalizeUUID(uuid)
+	for _, user := range ms.users {
+		if user.UUID == uuid {
+			return user.ID, nil
+		}
+	}
+	return 0, sentinelErrorf(ErrUserNotFound, "user with UUID '%s' not found", uuid)
+}
+
+// GetUsersCount returns the number of users
+func (ms *MemoryUserStore) GetUsersCount(ctx context.Context) (int, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	return len(ms.users), nil
+}
+
+// CreateUser adds a user
+func (ms *MemoryUserStore) CreateUser(ctx context.Context, name, email string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	if ms.emailTaken(email, 0) {
+		return nil, &EmailExistsError{Email: email}
+	}
+
+	now := time.Now()
+	ms.nextID++
+	user := User{ID: ms.nextID, UUID: newUUID(), Name: name, Email: email, CreatedAt: now, UpdatedAt: now}
+	ms.users[user.ID] = user
+	return &user, nil
+}
+
+// UpdateUser updates an existing user
+func (ms *MemoryUserStore) UpdateUser(ctx context.Context, id int, name, email string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[id]
+	if !ok {
+		return nil, sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+	}
+	if ms.emailTaken(email, id) {
+		return nil, &EmailExistsError{Email: email}
+	}
+
+	user.Name, user.Email, user.UpdatedAt = name, email, time.Now()
+	ms.users[id] = user
+	return &user, nil
+}
+
+// DeleteUser deletes a user by ID
+func (ms *MemoryUserStore) DeleteUser(ctx context.Context, id int) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[id]
+	if !ok {
+		return sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+	}
+	if user.LegalHold {
+		return sentinelErrorf(ErrUserUnderLegalHold, "user with ID %d is under legal hold", id)
+	}
+
+	delete(ms.users, id)
+	return nil
+}
+
+// SetLegalHold places or lifts a legal hold on a user
+func (ms *MemoryUserStore) SetLegalHold(ctx context.Context, id int, hold bool, reason string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[id]
+	if !ok {
+		return nil, sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+	}
+	if !hold {
+		reason = ""
+	}
+
+	user.LegalHold, user.LegalHoldReason = hold, reason
+	ms.users[id] = user
+	return &user, nil
+}
+
+// SetAvatar records the URL of a user's avatar image
+func (ms *MemoryUserStore) SetAvatar(ctx context.Context, id int, avatarURL string) (*User, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	user, ok := ms.users[id]
+	if !ok {
+		return nil, sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+	}
+
+	user.AvatarURL = avatarURL
+	ms.users[id] = user
+	return &user, nil
+}
+
+// Helper function to list the users matching the search and creation time of filter, newest first
+func (ms *MemoryUserStore) matching(filter UserFilter) []User {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+
+	search := strings.ToLower(filter.Search)
+	users := []User{}
+	for _, user := range ms.users {
+		if user.CreatedAt.Before(filter.CreatedSince) {
+			continue
+		}
+		if search == "" || strings.Contains(strings.ToLower(user.Name), search) ||
+			strings.Contains(strings.ToLower(user.Email), search) {
+			users = append(users, user)
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	return users
+}
+
+// Helper function to tell whether another user than id has email, ignoring case like the
+// unique index of the database; the caller holds mu
+func (ms *MemoryUserStore) emailTaken(email string, id int) bool {
+	for _, user := range ms.users {
+		if user.ID != id && strings.EqualFold(user.Email, email) {
+			return true
+		}
+	}
+	return false
+}
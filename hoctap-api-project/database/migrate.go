@@ -0,0 +1,284 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	migratedb "github.com/golang-migrate/migrate/v4/database"
+	migratemysql "github.com/golang-migrate/migrate/v4/database/mysql"
+	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// migrationFiles holds the versioned schema, one directory per dialect. Files are named
+// <version>_<name>.up.sql / <version>_<name>.down.sql; applied versions are recorded in
+// the schema_migrations table.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// baselineVersion is the migration matching the users table that setup-database.sql created
+// before versioned migrations, the only schema a database can have without a version row
+const baselineVersion = 1
+
+// baselineUserColumns are the columns of the users table of migration 1
+var baselineUserColumns = []string{"id", "name", "email", "created_at", "updated_at"}
+
+// Helper function to build a migrator for the files in dir/<dialect>, tracked in table, on its
+// own connection to the active server. The MySQL driver needs multiStatements to run a
+// migration file in one go, which the pool must not have.
+func newMigrator(files fs.FS, dir, table string) (*migrate.Migrate, error) {
+	source, err := iofs.New(files, path.Join(dir, string(dialect)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %v", err)
+	}
+
+	dsn := primary.DSN()
+	if failover != nil {
+		dsn = failover.endpoints[failover.active.Load()].server.DSN()
+	}
+
+	var conn *sql.DB
+	var instance migratedb.Driver
+	if dialect == DialectPostgres {
+		if conn, err = sql.Open("pgx", dsn); err == nil {
+			instance, err = migratepgx.WithInstance(conn, &migratepgx.Config{MigrationsTable: table})
+		}
+	} else {
+		if conn, err = sql.Open("mysql", dsn+"&multiStatements=true"); err == nil {
+			instance, err = migratemysql.WithInstance(conn, &migratemysql.Config{MigrationsTable: table})
+		}
+	}
+	if err != nil {
+		if conn != nil {
+			conn.Close()
+		}
+		source.Close()
+		return nil, fmt.Errorf("failed to open migration connection: %v", err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, string(dialect), instance)
+	if err != nil {
+		instance.Close()
+		source.Close()
+		return nil, fmt.Errorf("failed to initialize migrations: %v", err)
+	}
+	m.Log = migrationLogger{}
+
+	return m, nil
+}
+
+// Helper function to run fn with the core migrator and close it afterwards
+func withMigrator(fn func(m *migrate.Migrate) error) error {
+	m, err := newMigrator(migrationFiles, "migrations", "schema_migrations")
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	return fn(m)
+}
+
+// MigrateUp applies all pending migrations
+func MigrateUp() error {
+	return withMigrator(func(m *migrate.Migrate) error {
+		if err := baselineLegacySchema(m); err != nil {
+			return err
+		}
+
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to apply migrations: %v", err)
+		}
+
+		version, _, err := m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return fmt.Errorf("failed to read schema version: %v", err)
+		}
+
+		log.Printf("✅ Database schema is at version %d", version)
+		return nil
+	})
+}
+
+// MigratePluginUp applies the pending migrations of a plugin. files holds one directory per
+// dialect; versions are tracked in schema_migrations_<plugin>.
+func MigratePluginUp(plugin string, files fs.FS) error {
+	m, err := newMigrator(files, ".", "schema_migrations_"+plugin)
+	if err != nil {
+		return fmt.Errorf("plugin %s: %v", plugin, err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("failed to apply migrations of plugin %s: %v", plugin, err)
+	}
+	return nil
+}
+
+// MigrateDown rolls back the given number of migrations
+func MigrateDown(steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("steps must be positive")
+	}
+
+	return withMigrator(func(m *migrate.Migrate) error {
+		if err := m.Steps(-steps); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to roll back migrations: %v", err)
+		}
+		return nil
+	})
+}
+
+// ForceMigrationVersion records the given version as applied and clears the dirty flag,
+// without running any migration. It is the way out after a migration failed halfway.
+func ForceMigrationVersion(version int) error {
+	return withMigrator(func(m *migrate.Migrate) error {
+		if err := m.Force(version); err != nil {
+			return fmt.Errorf("failed to force schema version %d: %v", version, err)
+		}
+		return nil
+	})
+}
+
+// MigrationVersion returns the current schema version and whether the last migration failed
+// halfway. The version is 0 when no migration has been applied.
+func MigrationVersion() (uint, bool, error) {
+	var version uint
+	var dirty bool
+
+	err := withMigrator(func(m *migrate.Migrate) error {
+		var err error
+		version, dirty, err = m.Version()
+		if errors.Is(err, migrate.ErrNilVersion) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	return version, dirty, nil
+}
+
+// Migration is an embedded schema migration
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+}
+
+// Migrations lists the migrations embedded for the active dialect in version order
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, path.Join("migrations", string(dialect)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %v", err)
+	}
+
+	// Every version has an up and a down file; entries are sorted by file name
+	var migrations []Migration
+	for _, entry := range entries {
+		prefix, rest, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name '%s'", entry.Name())
+		}
+		if n := len(migrations); n > 0 && migrations[n-1].Version == uint(version) {
+			continue
+		}
+		name, _, _ := strings.Cut(rest, ".")
+		migrations = append(migrations, Migration{Version: uint(version), Name: name})
+	}
+	return migrations, nil
+}
+
+// LatestMigrationVersion returns the version of the newest migration embedded for the active
+// dialect, the version a fully migrated database is at
+func LatestMigrationVersion() (uint, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, migration := range migrations {
+		latest = max(latest, migration.Version)
+	}
+	return latest, nil
+}
+
+// Helper function to mark a database whose users table was created by setup-database.sql,
+// before versioned migrations, as migrated up to the migration creating it
+func baselineLegacySchema(m *migrate.Migrate) error {
+	if _, _, err := m.Version(); !errors.Is(err, migrate.ErrNilVersion) {
+		return nil
+	}
+
+	exists, err := schemaObjectExists("users", "")
+	if err != nil {
+		return fmt.Errorf("failed to inspect existing schema: %v", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	// Only a users table of the baseline schema is taken over; anything else is left to the
+	// operator rather than guessed at
+	for _, column := range baselineUserColumns {
+		exists, err := schemaObjectExists("users", column)
+		if err != nil {
+			return fmt.Errorf("failed to inspect existing schema: %v", err)
+		}
+		if !exists {
+			return fmt.Errorf("existing users table has no %s column and does not match the baseline schema, set its version with 'migrate force'", column)
+		}
+	}
+
+	if err := m.Force(baselineVersion); err != nil {
+		return fmt.Errorf("failed to baseline existing schema: %v", err)
+	}
+
+	log.Printf("📦 Existing schema baselined at migration version %d", baselineVersion)
+	return nil
+}
+
+// Helper function to check whether a table of the current schema exists, or its column when
+// one is given
+func schemaObjectExists(table, column string) (bool, error) {
+	schema := "DATABASE()"
+	if dialect == DialectPostgres {
+		schema = "CURRENT_SCHEMA()"
+	}
+
+	query := `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ` + schema + ` AND table_name = ?`
+	args := []interface{}{table}
+	if column != "" {
+		query = `SELECT COUNT(*) FROM information_schema.columns
+		WHERE table_schema = ` + schema + ` AND table_name = ? AND column_name = ?`
+		args = append(args, column)
+	}
+
+	var count int
+	if err := DB.QueryRow(rebind(query), args...).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// migrationLogger routes golang-migrate progress messages to the standard logger
+type migrationLogger struct{}
+
+func (migrationLogger) Printf(format string, v ...interface{}) {
+	log.Printf("📦 "+strings.TrimSuffix(format, "\n"), v...)
+}
+
+func (migrationLogger) Verbose() bool {
+	return false
+}
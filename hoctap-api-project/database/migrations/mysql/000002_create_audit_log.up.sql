@@ -0,0 +1,13 @@
+CREATE TABLE audit_log (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	actor VARCHAR(255) NOT NULL,
+	action VARCHAR(16) NOT NULL,
+	entity VARCHAR(64) NOT NULL,
+	entity_id INT NOT NULL,
+	before_data JSON NULL,
+	after_data JSON NULL,
+	ip VARCHAR(45) NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_audit_log_entity (entity, entity_id),
+	INDEX idx_audit_log_created_at (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
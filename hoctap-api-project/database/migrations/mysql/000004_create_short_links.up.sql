@@ -0,0 +1,9 @@
+CREATE TABLE short_links (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	code VARCHAR(32) NOT NULL UNIQUE,
+	target_url VARCHAR(2048) NOT NULL,
+	clicks INT NOT NULL DEFAULT 0,
+	last_clicked_at TIMESTAMP NULL DEFAULT NULL,
+	expires_at TIMESTAMP NULL DEFAULT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
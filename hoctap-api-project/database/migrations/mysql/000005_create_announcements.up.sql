@@ -0,0 +1,11 @@
+CREATE TABLE announcements (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	title VARCHAR(255) NOT NULL,
+	body TEXT NOT NULL,
+	audience VARCHAR(64) NOT NULL DEFAULT 'all',
+	publish_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	unpublish_at TIMESTAMP NULL DEFAULT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	INDEX idx_announcements_window (publish_at, unpublish_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+CREATE TABLE experiment_exposures (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	experiment VARCHAR(128) NOT NULL,
+	variant VARCHAR(128) NOT NULL,
+	exposures INT NOT NULL DEFAULT 1,
+	first_exposed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	last_exposed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE KEY uniq_experiment_exposures_user (experiment, user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
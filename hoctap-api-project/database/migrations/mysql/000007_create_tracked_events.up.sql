@@ -0,0 +1,13 @@
+CREATE TABLE tracked_events (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	name VARCHAR(128) NOT NULL,
+	path VARCHAR(1024) NOT NULL DEFAULT '',
+	user_id INT NULL,
+	session_id VARCHAR(128) NOT NULL DEFAULT '',
+	properties JSON NULL,
+	client_ip VARCHAR(45) NOT NULL DEFAULT '',
+	occurred_at TIMESTAMP NOT NULL,
+	received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_tracked_events_name_time (name, occurred_at),
+	INDEX idx_tracked_events_occurred_at (occurred_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
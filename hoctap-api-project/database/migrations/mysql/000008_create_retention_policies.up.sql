@@ -0,0 +1,9 @@
+CREATE TABLE retention_policies (
+	entity VARCHAR(64) PRIMARY KEY,
+	action VARCHAR(16) NOT NULL,
+	days INT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	last_run_at TIMESTAMP NULL DEFAULT NULL,
+	last_affected BIGINT NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+	ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT FALSE AFTER email,
+	ADD COLUMN legal_hold_reason VARCHAR(512) NOT NULL DEFAULT '' AFTER legal_hold;
@@ -0,0 +1,9 @@
+CREATE TABLE validation_rules (
+	id INT AUTO_INCREMENT PRIMARY KEY,
+	entity VARCHAR(32) NOT NULL,
+	name VARCHAR(128) NOT NULL UNIQUE,
+	script TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	INDEX idx_validation_rules_entity (entity)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
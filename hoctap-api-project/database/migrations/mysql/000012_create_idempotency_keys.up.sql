@@ -0,0 +1,10 @@
+CREATE TABLE idempotency_keys (
+	idempotency_key VARCHAR(255) NOT NULL PRIMARY KEY,
+	request_hash CHAR(64) NOT NULL,
+	status_code INT NOT NULL DEFAULT 0,
+	content_type VARCHAR(255) NOT NULL DEFAULT '',
+	body MEDIUMBLOB NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	expires_at TIMESTAMP NULL DEFAULT NULL,
+	INDEX idx_idempotency_keys_expires_at (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_bin;
@@ -0,0 +1,16 @@
+CREATE TABLE jobs (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	kind VARCHAR(64) NOT NULL,
+	payload MEDIUMTEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'queued',
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL,
+	last_error VARCHAR(1024) NOT NULL DEFAULT '',
+	run_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	locked_at TIMESTAMP NULL DEFAULT NULL,
+	locked_by VARCHAR(128) NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+	-- Workers claim the oldest due job; the admin listing filters by status
+	INDEX idx_jobs_due (status, run_at, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,10 @@
+CREATE TABLE outbox_events (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	event_id CHAR(32) NOT NULL,
+	event VARCHAR(64) NOT NULL,
+	payload MEDIUMTEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	published_at TIMESTAMP NULL DEFAULT NULL,
+	-- The relay reads unpublished events in insert order; the purge deletes old published ones
+	INDEX idx_outbox_events_pending (published_at, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,12 @@
+ALTER TABLE users ADD COLUMN uuid CHAR(36) NULL AFTER id;
+
+-- Existing users get random (version 4) UUIDs; new ones are given theirs by the application
+UPDATE users SET uuid = LOWER(CONCAT_WS('-',
+	HEX(RANDOM_BYTES(4)),
+	HEX(RANDOM_BYTES(2)),
+	CONCAT('4', SUBSTR(HEX(RANDOM_BYTES(2)), 2)),
+	CONCAT(HEX(8 + FLOOR(RAND() * 4)), SUBSTR(HEX(RANDOM_BYTES(2)), 2)),
+	HEX(RANDOM_BYTES(6))));
+
+ALTER TABLE users MODIFY COLUMN uuid CHAR(36) NOT NULL;
+CREATE UNIQUE INDEX idx_users_uuid ON users (uuid);
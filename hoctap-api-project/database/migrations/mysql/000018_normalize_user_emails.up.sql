@@ -0,0 +1,10 @@
+-- Trim emails and lowercase their domain, as the API now does on every write. The unique index
+-- ignores case already; an email that would then equal another user's once trimmed is left
+-- as it is for an admin to resolve.
+UPDATE users u
+LEFT JOIN users other ON other.id <> u.id AND TRIM(other.email) = TRIM(u.email)
+SET u.email = CONCAT(
+	SUBSTRING(TRIM(u.email), 1, CHAR_LENGTH(TRIM(u.email)) - CHAR_LENGTH(SUBSTRING_INDEX(TRIM(u.email), '@', -1)) - 1),
+	'@',
+	LOWER(SUBSTRING_INDEX(TRIM(u.email), '@', -1)))
+WHERE other.id IS NULL AND u.email LIKE '%@%';
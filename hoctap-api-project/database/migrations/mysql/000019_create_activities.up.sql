@@ -0,0 +1,11 @@
+CREATE TABLE activities (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	user_id INT NOT NULL,
+	type VARCHAR(64) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	details JSON NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	-- The feed reads a user's activities newest first
+	INDEX idx_activities_user (user_id, id),
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
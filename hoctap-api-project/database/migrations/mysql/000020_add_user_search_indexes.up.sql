@@ -0,0 +1,3 @@
+-- Typeahead search matches name and email prefixes; the unique index on email already serves
+-- email prefixes, as the collation ignores case
+CREATE INDEX idx_users_name ON users (name);
@@ -0,0 +1,7 @@
+ALTER TABLE outbox_events
+	DROP COLUMN dead_at,
+	DROP COLUMN next_attempt_at,
+	DROP COLUMN last_error,
+	DROP COLUMN attempts,
+	DROP COLUMN webhooks_published_at,
+	DROP COLUMN broker_published_at;
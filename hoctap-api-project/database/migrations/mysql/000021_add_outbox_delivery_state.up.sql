@@ -0,0 +1,11 @@
+-- Each destination is published to on its own; an event is done once every one has it
+ALTER TABLE outbox_events
+	ADD COLUMN broker_published_at TIMESTAMP NULL DEFAULT NULL AFTER created_at,
+	ADD COLUMN webhooks_published_at TIMESTAMP NULL DEFAULT NULL AFTER broker_published_at,
+	ADD COLUMN attempts INT NOT NULL DEFAULT 0 AFTER published_at,
+	ADD COLUMN last_error VARCHAR(1024) NOT NULL DEFAULT '' AFTER attempts,
+	ADD COLUMN next_attempt_at TIMESTAMP NULL DEFAULT NULL AFTER last_error,
+	ADD COLUMN dead_at TIMESTAMP NULL DEFAULT NULL AFTER next_attempt_at;
+
+UPDATE outbox_events SET broker_published_at = published_at, webhooks_published_at = published_at
+WHERE published_at IS NOT NULL;
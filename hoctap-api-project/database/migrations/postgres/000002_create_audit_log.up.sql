@@ -0,0 +1,15 @@
+CREATE TABLE audit_log (
+	id SERIAL PRIMARY KEY,
+	actor VARCHAR(255) NOT NULL,
+	action VARCHAR(16) NOT NULL,
+	entity VARCHAR(64) NOT NULL,
+	entity_id INT NOT NULL,
+	before_data JSONB NULL,
+	after_data JSONB NULL,
+	ip VARCHAR(45) NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log (entity, entity_id);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
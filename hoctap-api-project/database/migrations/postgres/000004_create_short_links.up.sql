@@ -0,0 +1,9 @@
+CREATE TABLE short_links (
+	id SERIAL PRIMARY KEY,
+	code VARCHAR(32) NOT NULL UNIQUE,
+	target_url VARCHAR(2048) NOT NULL,
+	clicks INT NOT NULL DEFAULT 0,
+	last_clicked_at TIMESTAMPTZ NULL DEFAULT NULL,
+	expires_at TIMESTAMPTZ NULL DEFAULT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
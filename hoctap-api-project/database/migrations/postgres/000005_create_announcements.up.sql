@@ -0,0 +1,15 @@
+CREATE TABLE announcements (
+	id SERIAL PRIMARY KEY,
+	title VARCHAR(255) NOT NULL,
+	body TEXT NOT NULL,
+	audience VARCHAR(64) NOT NULL DEFAULT 'all',
+	publish_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	unpublish_at TIMESTAMPTZ NULL DEFAULT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_announcements_window ON announcements (publish_at, unpublish_at);
+
+CREATE TRIGGER trg_announcements_updated_at BEFORE UPDATE ON announcements
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,10 @@
+CREATE TABLE experiment_exposures (
+	id SERIAL PRIMARY KEY,
+	user_id INT NOT NULL,
+	experiment VARCHAR(128) NOT NULL,
+	variant VARCHAR(128) NOT NULL,
+	exposures INT NOT NULL DEFAULT 1,
+	first_exposed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	last_exposed_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT uniq_experiment_exposures_user UNIQUE (experiment, user_id)
+);
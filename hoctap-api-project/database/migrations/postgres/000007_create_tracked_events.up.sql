@@ -0,0 +1,15 @@
+CREATE TABLE tracked_events (
+	id BIGSERIAL PRIMARY KEY,
+	name VARCHAR(128) NOT NULL,
+	path VARCHAR(1024) NOT NULL DEFAULT '',
+	user_id INT NULL,
+	session_id VARCHAR(128) NOT NULL DEFAULT '',
+	properties JSONB NULL,
+	client_ip VARCHAR(45) NOT NULL DEFAULT '',
+	occurred_at TIMESTAMPTZ NOT NULL,
+	received_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_tracked_events_name_time ON tracked_events (name, occurred_at);
+
+CREATE INDEX idx_tracked_events_occurred_at ON tracked_events (occurred_at);
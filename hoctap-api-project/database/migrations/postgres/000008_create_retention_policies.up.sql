@@ -0,0 +1,12 @@
+CREATE TABLE retention_policies (
+	entity VARCHAR(64) PRIMARY KEY,
+	action VARCHAR(16) NOT NULL,
+	days INT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	last_run_at TIMESTAMPTZ NULL DEFAULT NULL,
+	last_affected BIGINT NOT NULL DEFAULT 0,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER trg_retention_policies_updated_at BEFORE UPDATE ON retention_policies
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN legal_hold_reason, DROP COLUMN legal_hold;
@@ -0,0 +1,3 @@
+ALTER TABLE users
+	ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN legal_hold_reason VARCHAR(512) NOT NULL DEFAULT '';
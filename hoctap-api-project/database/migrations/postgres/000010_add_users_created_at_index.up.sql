@@ -0,0 +1,2 @@
+-- User listings are ordered newest first; the index avoids a sort on every page
+CREATE INDEX idx_users_created_at ON users (created_at, id);
@@ -0,0 +1,10 @@
+CREATE TABLE validation_rules (
+	id SERIAL PRIMARY KEY,
+	entity VARCHAR(32) NOT NULL,
+	name VARCHAR(128) NOT NULL UNIQUE,
+	script TEXT NOT NULL,
+	enabled BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_validation_rules_entity ON validation_rules (entity);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN avatar_url VARCHAR(512) NOT NULL DEFAULT '';
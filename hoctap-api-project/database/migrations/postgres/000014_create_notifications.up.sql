@@ -0,0 +1,27 @@
+CREATE TABLE notifications (
+	id BIGSERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type VARCHAR(64) NOT NULL,
+	title VARCHAR(255) NOT NULL,
+	body TEXT NOT NULL,
+	link VARCHAR(512) NOT NULL DEFAULT '',
+	read_at TIMESTAMPTZ NULL DEFAULT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Listings and the unread count read a user's notifications newest first
+CREATE INDEX idx_notifications_user ON notifications (user_id, read_at, id);
+
+CREATE INDEX idx_notifications_created_at ON notifications (created_at);
+
+CREATE TABLE notification_preferences (
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type VARCHAR(64) NOT NULL,
+	email BOOLEAN NOT NULL,
+	in_app BOOLEAN NOT NULL,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (user_id, type)
+);
+
+CREATE TRIGGER trg_notification_preferences_updated_at BEFORE UPDATE ON notification_preferences
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,20 @@
+CREATE TABLE jobs (
+	id BIGSERIAL PRIMARY KEY,
+	kind VARCHAR(64) NOT NULL,
+	payload TEXT NOT NULL,
+	status VARCHAR(16) NOT NULL DEFAULT 'queued',
+	attempts INT NOT NULL DEFAULT 0,
+	max_attempts INT NOT NULL,
+	last_error VARCHAR(1024) NOT NULL DEFAULT '',
+	run_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	locked_at TIMESTAMPTZ NULL DEFAULT NULL,
+	locked_by VARCHAR(128) NOT NULL DEFAULT '',
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Workers claim the oldest due job; the admin listing filters by status
+CREATE INDEX idx_jobs_due ON jobs (status, run_at, id);
+
+CREATE TRIGGER trg_jobs_updated_at BEFORE UPDATE ON jobs
+FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,11 @@
+CREATE TABLE outbox_events (
+	id BIGSERIAL PRIMARY KEY,
+	event_id CHAR(32) NOT NULL,
+	event VARCHAR(64) NOT NULL,
+	payload TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	published_at TIMESTAMPTZ NULL DEFAULT NULL
+);
+
+-- The relay reads unpublished events in insert order; the purge deletes old published ones
+CREATE INDEX idx_outbox_events_pending ON outbox_events (published_at, id);
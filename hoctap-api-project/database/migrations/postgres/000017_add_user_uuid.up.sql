@@ -0,0 +1,4 @@
+-- Existing users get random UUIDs; new ones are given theirs by the application
+ALTER TABLE users ADD COLUMN uuid UUID NOT NULL DEFAULT gen_random_uuid();
+ALTER TABLE users ALTER COLUMN uuid DROP DEFAULT;
+CREATE UNIQUE INDEX idx_users_uuid ON users (uuid);
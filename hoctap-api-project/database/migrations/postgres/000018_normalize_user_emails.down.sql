@@ -0,0 +1,2 @@
+-- The original spelling of normalized emails is not kept
+SELECT 1;
@@ -0,0 +1,10 @@
+-- Trim emails and lowercase their domain, as the API now does on every write. The unique index
+-- ignores case already; an email that would then equal another user's once trimmed is left
+-- as it is for an admin to resolve.
+UPDATE users u
+SET email = regexp_replace(TRIM(u.email), '@[^@]*$', '') || '@' || LOWER(substring(TRIM(u.email) from '[^@]*$'))
+WHERE u.email LIKE '%@%'
+	AND NOT EXISTS (
+		SELECT 1 FROM users other
+		WHERE other.id <> u.id AND LOWER(TRIM(other.email)) = LOWER(TRIM(u.email))
+	);
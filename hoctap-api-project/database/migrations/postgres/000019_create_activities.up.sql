@@ -0,0 +1,11 @@
+CREATE TABLE activities (
+	id BIGSERIAL PRIMARY KEY,
+	user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	type VARCHAR(64) NOT NULL,
+	actor VARCHAR(255) NOT NULL,
+	details JSONB NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+-- The feed reads a user's activities newest first
+CREATE INDEX idx_activities_user ON activities (user_id, id);
@@ -0,0 +1,2 @@
+DROP INDEX idx_users_email_prefix;
+DROP INDEX idx_users_name_prefix;
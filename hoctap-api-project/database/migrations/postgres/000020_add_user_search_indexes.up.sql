@@ -0,0 +1,5 @@
+-- Typeahead search matches lowercased name and email prefixes, which need pattern operator
+-- classes to use an index whatever the collation
+CREATE INDEX idx_users_name_prefix ON users (LOWER(name) text_pattern_ops);
+
+CREATE INDEX idx_users_email_prefix ON users (LOWER(email) text_pattern_ops);
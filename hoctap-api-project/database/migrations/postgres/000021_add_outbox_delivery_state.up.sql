@@ -0,0 +1,11 @@
+-- Each destination is published to on its own; an event is done once every one has it
+ALTER TABLE outbox_events
+	ADD COLUMN broker_published_at TIMESTAMPTZ NULL DEFAULT NULL,
+	ADD COLUMN webhooks_published_at TIMESTAMPTZ NULL DEFAULT NULL,
+	ADD COLUMN attempts INT NOT NULL DEFAULT 0,
+	ADD COLUMN last_error VARCHAR(1024) NOT NULL DEFAULT '',
+	ADD COLUMN next_attempt_at TIMESTAMPTZ NULL DEFAULT NULL,
+	ADD COLUMN dead_at TIMESTAMPTZ NULL DEFAULT NULL;
+
+UPDATE outbox_events SET broker_published_at = published_at, webhooks_published_at = published_at
+WHERE published_at IS NOT NULL;
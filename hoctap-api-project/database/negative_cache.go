@@ -0,0 +1,78 @@
+package database
+
+import (
+	"sync"
+	"time"
+)
+
+// negativeCacheMaxEntries bounds the memory of a negative cache
+const negativeCacheMaxEntries = 10000
+
+// negativeCache remembers keys that were recently looked up and not found, so repeated
+// lookups of nonexistent records (enumeration scans, misbehaving clients) skip the database
+type negativeCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]time.Time
+}
+
+// newNegativeCache creates a negative cache; a zero TTL disables it
+func newNegativeCache(ttl time.Duration) *negativeCache {
+	return &negativeCache{ttl: ttl, entries: make(map[string]time.Time)}
+}
+
+// Has reports whether key is known to be missing
+func (c *negativeCache) Has(key string) bool {
+	if c.ttl <= 0 {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(expiresAt) {
+		delete(c.entries, key)
+		return false
+	}
+	return true
+}
+
+// Add records key as missing for the cache TTL
+func (c *negativeCache) Add(key string) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.entries) >= negativeCacheMaxEntries {
+		c.evictExpired()
+		if len(c.entries) >= negativeCacheMaxEntries {
+			// Still full: start over rather than grow without bound during a scan
+			c.entries = make(map[string]time.Time)
+		}
+	}
+	c.entries[key] = time.Now().Add(c.ttl)
+}
+
+// Forget drops key, e.g. when the record is created
+func (c *negativeCache) Forget(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Helper function to drop expired entries; the caller holds the lock
+func (c *negativeCache) evictExpired() {
+	now := time.Now()
+	for key, expiresAt := range c.entries {
+		if now.After(expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
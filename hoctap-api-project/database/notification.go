@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Notification is an in-app notification shown to one user
+type Notification struct {
+	ID        int64      `json:"id"`
+	UserID    int        `json:"user_id"`
+	Type      string     `json:"type"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Link      string     `json:"link"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// NotificationPreference is the channels a user chose for one notification type
+type NotificationPreference struct {
+	Type  string `json:"type"`
+	Email bool   `json:"email"`
+	InApp bool   `json:"in_app"`
+}
+
+// NotificationFilter selects a page of a user's notifications, newest first
+type NotificationFilter struct {
+	UserID     int
+	UnreadOnly bool
+	// Before returns notifications older than this ID, for paging; 0 starts at the newest
+	Before int64
+	Limit  int
+}
+
+// ErrNotificationNotFound matches (with errors.Is) the error of a missing notification
+var ErrNotificationNotFound = errors.New("notification not found")
+
+// NotificationRepository handles notification and notification preference database operations
+type NotificationRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationRepository creates a new notification repository
+func NewNotificationRepository() *NotificationRepository {
+	return &NotificationRepository{db: DB}
+}
+
+// CreateNotification stores a new unread notification
+func (nr *NotificationRepository) CreateNotification(ctx context.Context, userID int, notificationType, title, body, link string) (*Notification, error) {
+	query := `INSERT INTO notifications (user_id, type, title, body, link) VALUES (?, ?, ?, ?, ?)`
+
+	id, err := insertReturningID(ctx, nr.db, query, userID, notificationType, title, body, link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create notification: %v", err)
+	}
+
+	return nr.GetNotificationByID(ctx, id)
+}
+
+// GetNotificationByID retrieves a notification by ID
+func (nr *NotificationRepository) GetNotificationByID(ctx context.Context, id int64) (*Notification, error) {
+	query := `SELECT id, user_id, type, title, body, link, read_at, created_at FROM notifications WHERE id = ?`
+
+	var n Notification
+	err := nr.db.QueryRowContext(ctx, rebind(query), id).Scan(
+		&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &n.Link, &n.ReadAt, &n.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sentinelErrorf(ErrNotificationNotFound, "notification with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get notification: %v", err)
+	}
+
+	return &n, nil
+}
+
+// GetNotifications retrieves a page of a user's notifications, newest first
+func (nr *NotificationRepository) GetNotifications(ctx context.Context, filter NotificationFilter) ([]Notification, error) {
+	query := `SELECT id, user_id, type, title, body, link, read_at, created_at FROM notifications WHERE user_id = ?`
+	args := []interface{}{filter.UserID}
+
+	if filter.UnreadOnly {
+		query += ` AND read_at IS NULL`
+	}
+	if filter.Before > 0 {
+		query += ` AND id < ?`
+		args = append(args, filter.Before)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := nr.db.QueryContext(ctx, rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %v", err)
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var n Notification
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Type, &n.Title, &n.Body, &n.Link, &n.ReadAt, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %v", err)
+		}
+		notifications = append(notifications, n)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return notifications, nil
+}
+
+// GetUnreadCount counts a user's unread notifications
+func (nr *NotificationRepository) GetUnreadCount(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := nr.db.QueryRowContext(ctx, rebind(`SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`), userID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count unread notifications: %v", err)
+	}
+
+	return count, nil
+}
+
+// MarkRead marks one notification as read; marking it again keeps the first read time
+func (nr *NotificationRepository) MarkRead(ctx context.Context, id int64) (*Notification, error) {
+	query := `UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE id = ? AND read_at IS NULL`
+	if _, err := nr.db.ExecContext(ctx, rebind(query), id); err != nil {
+		return nil, fmt.Errorf("failed to mark notification as read: %v", err)
+	}
+
+	return nr.GetNotificationByID(ctx, id)
+}
+
+// MarkAllRead marks every unread notification of a user as read, returning how many were
+func (nr *NotificationRepository) MarkAllRead(ctx context.Context, userID int) (int64, error) {
+	query := `UPDATE notifications SET read_at = CURRENT_TIMESTAMP WHERE user_id = ? AND read_at IS NULL`
+
+	marked, err := execRowsAffected(ctx, nr.db, query, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %v", err)
+	}
+
+	return marked, nil
+}
+
+// GetPreferences retrieves the preferences a user saved, by notification type. Types the
+// user never changed are absent.
+func (nr *NotificationRepository) GetPreferences(ctx context.Context, userID int) (map[string]NotificationPreference, error) {
+	query := `SELECT type, email, in_app FROM notification_preferences WHERE user_id = ?`
+
+	rows, err := nr.db.QueryContext(ctx, rebind(query), userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification preferences: %v", err)
+	}
+	defer rows.Close()
+
+	preferences := map[string]NotificationPreference{}
+	for rows.Next() {
+		var p NotificationPreference
+		if err := rows.Scan(&p.Type, &p.Email, &p.InApp); err != nil {
+			return nil, fmt.Errorf("failed to scan notification preference: %v", err)
+		}
+		preferences[p.Type] = p
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return preferences, nil
+}
+
+// SavePreferences creates or replaces a user's preferences for the given types in one transaction
+func (nr *NotificationRepository) SavePreferences(ctx context.Context, userID int, preferences []NotificationPreference) error {
+	query := `INSERT INTO notification_preferences (user_id, type, email, in_app) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE email = VALUES(email), in_app = VALUES(in_app)`
+	if dialect == DialectPostgres {
+		query = `INSERT INTO notification_preferences (user_id, type, email, in_app) VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id, type) DO UPDATE SET email = EXCLUDED.email, in_app = EXCLUDED.in_app`
+	}
+
+	return inTx(ctx, nr.db, func(tx *sql.Tx) error {
+		for _, p := range preferences {
+			if _, err := tx.ExecContext(ctx, rebind(query), userID, p.Type, p.Email, p.InApp); err != nil {
+				return fmt.Errorf("failed to save notification preference: %v", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,228 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// User lifecycle events, recorded in the outbox with every change to a user
+const (
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// Outbox destinations. Each is published to on its own, so one being down neither holds back
+// nor duplicates the events of the other.
+const (
+	OutboxBroker   = "broker"
+	OutboxWebhooks = "webhooks"
+)
+
+// outboxDestinationColumns are the columns recording when an event reached each destination
+var outboxDestinationColumns = map[string]string{
+	OutboxBroker:   "broker_published_at",
+	OutboxWebhooks: "webhooks_published_at",
+}
+
+// OutboxEvent is an event recorded in the same transaction as the change it announces,
+// published by the relay once that transaction committed
+type OutboxEvent struct {
+	ID int64 `json:"id"`
+	// EventID identifies the event to its consumers, the same on every publish attempt
+	EventID             string          `json:"event_id"`
+	Event               string          `json:"event"`
+	Payload             json.RawMessage `json:"payload"`
+	CreatedAt           time.Time       `json:"created_at"`
+	BrokerPublishedAt   *time.Time      `json:"broker_published_at"`
+	WebhooksPublishedAt *time.Time      `json:"webhooks_published_at"`
+	PublishedAt         *time.Time      `json:"published_at"`
+	// Attempts counts the publish attempts that failed for at least one destination
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"last_error"`
+	// DeadAt is when the event ran out of attempts; dead events are no longer published
+	DeadAt *time.Time `json:"dead_at"`
+}
+
+// Pending reports whether the event still has to be published to destination
+func (e OutboxEvent) Pending(destination string) bool {
+	switch destination {
+	case OutboxBroker:
+		return e.BrokerPublishedAt == nil
+	case OutboxWebhooks:
+		return e.WebhooksPublishedAt == nil
+	}
+	return false
+}
+
+// OutboxAttempt is the outcome of publishing a claimed event
+type OutboxAttempt struct {
+	// Published lists the destinations this attempt published the event to
+	Published []string
+	// Done is set once every destination has the event
+	Done bool
+	// Error is why a destination failed, empty when none did. A failed attempt is counted.
+	Error string
+	// RetryAt is when a failed event may be claimed again; zero moves it to the dead letters
+	RetryAt time.Time
+}
+
+// OutboxRepository stores the events waiting to be published
+type OutboxRepository struct {
+	db dbtx
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository() *OutboxRepository {
+	return &OutboxRepository{db: DB}
+}
+
+// Add records an event with data encoded as JSON. Called with a repository scoped to a
+// transaction, the event is published only if that transaction commits.
+func (or *OutboxRepository) Add(ctx context.Context, event string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %v", event, err)
+	}
+
+	buf := make([]byte, 16)
+	rand.Read(buf)
+
+	query := `INSERT INTO outbox_events (event_id, event, payload) VALUES (?, ?, ?)`
+	if _, err := or.db.ExecContext(ctx, rebind(query), hex.EncodeToString(buf), event, string(payload)); err != nil {
+		return fmt.Errorf("failed to record %s event: %v", event, err)
+	}
+
+	return nil
+}
+
+// Claim takes up to limit events that are due for publishing, oldest first, and hides them
+// from other relays until leaseUntil. The claim is its own short transaction: events are
+// published after it committed, and each is then settled with Settle. SKIP LOCKED lets
+// several instances claim concurrently without waiting or taking the same events.
+func (or *OutboxRepository) Claim(ctx context.Context, limit int, leaseUntil time.Time) ([]OutboxEvent, error) {
+	db, ok := or.db.(*sql.DB)
+	if !ok {
+		return nil, fmt.Errorf("failed to claim events: the outbox is relayed outside transactions")
+	}
+
+	events := []OutboxEvent{}
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		query := `SELECT ` + outboxColumns + ` FROM outbox_events
+			WHERE published_at IS NULL AND dead_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= ?)
+			ORDER BY id LIMIT ? FOR UPDATE SKIP LOCKED`
+
+		rows, err := tx.QueryContext(ctx, rebind(query), time.Now(), limit)
+		if err != nil {
+			return err
+		}
+		for rows.Next() {
+			event, err := scanOutboxEvent(rows)
+			if err != nil {
+				rows.Close()
+				return err
+			}
+			events = append(events, *event)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		update := `UPDATE outbox_events SET next_attempt_at = ? WHERE id = ?`
+		for _, event := range events {
+			if _, err := tx.ExecContext(ctx, rebind(update), leaseUntil, event.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim events: %v", err)
+	}
+
+	return events, nil
+}
+
+// Settle records the outcome of publishing a claimed event: the destinations it reached, and
+// either that it is done, a failed attempt retried at attempt.RetryAt or moved to the dead
+// letters, or, when it was not attempted, that it is released for the next claim
+func (or *OutboxRepository) Settle(ctx context.Context, id int64, attempt OutboxAttempt) error {
+	now := time.Now()
+	var assignments []string
+	var args []interface{}
+
+	for _, destination := range attempt.Published {
+		column, ok := outboxDestinationColumns[destination]
+		if !ok {
+			return fmt.Errorf("failed to settle event %d: unknown destination '%s'", id, destination)
+		}
+		assignments = append(assignments, column+" = ?")
+		args = append(args, now)
+	}
+
+	switch {
+	case attempt.Done:
+		assignments = append(assignments, "published_at = ?", "next_attempt_at = NULL")
+		args = append(args, now)
+	case attempt.Error != "" && attempt.RetryAt.IsZero():
+		assignments = append(assignments, "attempts = attempts + 1", "last_error = ?", "next_attempt_at = NULL", "dead_at = ?")
+		args = append(args, truncateJobError(attempt.Error), now)
+	case attempt.Error != "":
+		assignments = append(assignments, "attempts = attempts + 1", "last_error = ?", "next_attempt_at = ?")
+		args = append(args, truncateJobError(attempt.Error), attempt.RetryAt)
+	default:
+		assignments = append(assignments, "next_attempt_at = NULL")
+	}
+
+	query := `UPDATE outbox_events SET ` + strings.Join(assignments, ", ") + ` WHERE id = ?`
+	if _, err := or.db.ExecContext(ctx, rebind(query), append(args, id)...); err != nil {
+		return fmt.Errorf("failed to settle event %d: %v", id, err)
+	}
+
+	return nil
+}
+
+// CountPending counts the events waiting to be published, dead ones excluded
+func (or *OutboxRepository) CountPending(ctx context.Context) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM outbox_events WHERE published_at IS NULL AND dead_at IS NULL`
+	if err := or.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count pending events: %v", err)
+	}
+
+	return count, nil
+}
+
+// PurgePublished deletes the events published before cutoff
+func (or *OutboxRepository) PurgePublished(ctx context.Context, cutoff time.Time) (int64, error) {
+	purged, err := execRowsAffected(ctx, or.db, `DELETE FROM outbox_events WHERE published_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge published events: %v", err)
+	}
+
+	return purged, nil
+}
+
+// outboxColumns are the columns scanned by scanOutboxEvent
+const outboxColumns = `id, event_id, event, payload, created_at, broker_published_at, webhooks_published_at,
+	published_at, attempts, last_error, dead_at`
+
+// Helper function to scan a row of outboxColumns
+func scanOutboxEvent(row interface{ Scan(...interface{}) error }) (*OutboxEvent, error) {
+	var e OutboxEvent
+	var payload string
+	err := row.Scan(&e.ID, &e.EventID, &e.Event, &payload, &e.CreatedAt, &e.BrokerPublishedAt, &e.WebhooksPublishedAt,
+		&e.PublishedAt, &e.Attempts, &e.LastError, &e.DeadAt)
+	if err != nil {
+		return nil, err
+	}
+	e.Payload = json.RawMessage(payload)
+	return &e, nil
+}
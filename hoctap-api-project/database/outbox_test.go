@@ -0,0 +1,107 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recordingDB is a dbtx remembering the statement it was asked to execute
+type recordingDB struct {
+	query string
+	args  []interface{}
+}
+
+func (db *recordingDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	db.query, db.args = query, args
+	return driver.RowsAffected(1), nil
+}
+
+func (db *recordingDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("queries are not supported")
+}
+
+func (db *recordingDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+// settledAt stands in for the timestamps Settle takes from the clock
+const settledAt = "now"
+
+func TestOutboxSettle(t *testing.T) {
+	retryAt := time.Now().Add(time.Minute)
+	tests := []struct {
+		name      string
+		attempt   OutboxAttempt
+		wantQuery string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "done",
+			attempt:   OutboxAttempt{Published: []string{OutboxBroker, OutboxWebhooks}, Done: true},
+			wantQuery: `UPDATE outbox_events SET broker_published_at = ?, webhooks_published_at = ?, published_at = ?, next_attempt_at = NULL WHERE id = ?`,
+			wantArgs:  []interface{}{settledAt, settledAt, settledAt, int64(7)},
+		},
+		{
+			name:      "failed with a retry",
+			attempt:   OutboxAttempt{Published: []string{OutboxWebhooks}, Error: "broker: timeout", RetryAt: retryAt},
+			wantQuery: `UPDATE outbox_events SET webhooks_published_at = ?, attempts = attempts + 1, last_error = ?, next_attempt_at = ? WHERE id = ?`,
+			wantArgs:  []interface{}{settledAt, "broker: timeout", retryAt, int64(7)},
+		},
+		{
+			name:      "out of attempts",
+			attempt:   OutboxAttempt{Error: "broker: timeout"},
+			wantQuery: `UPDATE outbox_events SET attempts = attempts + 1, last_error = ?, next_attempt_at = NULL, dead_at = ? WHERE id = ?`,
+			wantArgs:  []interface{}{"broker: timeout", settledAt, int64(7)},
+		},
+		{
+			name:      "released for a destination failing earlier",
+			attempt:   OutboxAttempt{Published: []string{OutboxWebhooks}},
+			wantQuery: `UPDATE outbox_events SET webhooks_published_at = ?, next_attempt_at = NULL WHERE id = ?`,
+			wantArgs:  []interface{}{settledAt, int64(7)},
+		},
+		{
+			name:    "unknown destination",
+			attempt: OutboxAttempt{Published: []string{"mailer"}, Done: true},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &recordingDB{}
+			err := (&OutboxRepository{db: db}).Settle(context.Background(), 7, tt.attempt)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Settle() succeeded, want an error")
+				}
+				if db.query != "" {
+					t.Errorf("Settle() executed %q, want nothing", db.query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Settle() failed: %v", err)
+			}
+
+			if db.query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", db.query, tt.wantQuery)
+			}
+			args := make([]interface{}, len(db.args))
+			for i, arg := range db.args {
+				if at, ok := arg.(time.Time); ok && !at.Equal(retryAt) {
+					arg = settledAt
+				}
+				args[i] = arg
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
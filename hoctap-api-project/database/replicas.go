@@ -0,0 +1,210 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// replicaProbeTimeout bounds a single replica health check
+const replicaProbeTimeout = 2 * time.Second
+
+// replica is one read-only server
+type replica struct {
+	name    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet spreads reads over the replicas round-robin, skipping the unhealthy ones
+type replicaSet struct {
+	replicas []*replica
+	next     atomic.Uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// replicas is set by InitDB when DB_REPLICA_DSN lists at least one server
+var replicas *replicaSet
+
+// Helper function to open a pool per replica DSN and start checking their health
+func openReplicas(dsns []string, interval time.Duration) (*replicaSet, error) {
+	driverName := "mysql"
+	if dialect == DialectPostgres {
+		driverName = "pgx"
+	}
+
+	set := &replicaSet{stop: make(chan struct{}), done: make(chan struct{})}
+	for i, dsn := range dsns {
+		if dialect == DialectPostgres {
+			// Run sessions in UTC like the primary, whatever the DSN says
+			dsn = withPostgresUTC(dsn)
+		} else {
+			parsed, err := mysql.ParseDSN(dsn)
+			if err != nil {
+				set.closeAll()
+				return nil, fmt.Errorf("invalid DSN for replica %d: %v", i+1, err)
+			}
+			// Read timestamps in UTC like the primary, whatever the DSN says
+			parsed.ParseTime = true
+			parsed.Loc = time.UTC
+			if parsed.Params == nil {
+				parsed.Params = map[string]string{}
+			}
+			parsed.Params["time_zone"] = "'+00:00'"
+			dsn = parsed.FormatDSN()
+		}
+
+		db, err := sql.Open(driverName, dsn)
+		if err != nil {
+			set.closeAll()
+			return nil, fmt.Errorf("invalid DSN for replica %d: %v", i+1, err)
+		}
+		db.SetMaxOpenConns(25)
+		db.SetMaxIdleConns(10)
+
+		r := &replica{name: fmt.Sprintf("replica %d", i+1), db: db}
+		set.replicas = append(set.replicas, r)
+		set.check(r)
+	}
+
+	go set.monitor(interval)
+	log.Printf("📚 Reading users from %d replica(s)", len(set.replicas))
+	return set, nil
+}
+
+// Helper function to ping every replica each interval until stopped
+func (rs *replicaSet) monitor(interval time.Duration) {
+	defer close(rs.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rs.stop:
+			return
+		case <-ticker.C:
+			for _, r := range rs.replicas {
+				rs.check(r)
+			}
+		}
+	}
+}
+
+// Helper function to ping a replica and log health changes
+func (rs *replicaSet) check(r *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), replicaProbeTimeout)
+	defer cancel()
+
+	err := r.db.PingContext(ctx)
+	if err == nil {
+		if !r.healthy.Swap(true) {
+			log.Printf("✅ Database %s is available for reads", r.name)
+		}
+		return
+	}
+	r.markDown(err)
+}
+
+// Helper function to take a replica out of rotation until its next successful check
+func (r *replica) markDown(err error) {
+	if r.healthy.Swap(false) {
+		log.Printf("⚠️ Warning: database %s is down, reading from the primary: %v", r.name, err)
+	}
+}
+
+// Helper function to pick the next healthy replica, or nil when there is none
+func (rs *replicaSet) pick() *replica {
+	if rs == nil {
+		return nil
+	}
+	for range rs.replicas {
+		r := rs.replicas[rs.next.Add(1)%uint64(len(rs.replicas))]
+		if r.healthy.Load() {
+			return r
+		}
+	}
+	return nil
+}
+
+// Helper function to stop monitoring and close the replica pools
+func (rs *replicaSet) close() {
+	close(rs.stop)
+	<-rs.done
+	rs.closeAll()
+}
+
+// Helper function to close the replica pools
+func (rs *replicaSet) closeAll() {
+	for _, r := range rs.replicas {
+		r.db.Close()
+	}
+}
+
+// Helper function to run read on a healthy replica, or on primary when none is configured or
+// healthy. A replica failing is taken out of rotation and the read is retried on primary.
+// Replicas may lag, so a row a replica does not have yet is looked up on primary too: an
+// sql.ErrNoRows returned is always confirmed by primary. Reads that must see a write just made
+// go to primary directly, as do reads inside a transaction.
+func readFrom(ctx context.Context, primary dbtx, read func(db dbtx) error) error {
+	if _, inTx := primary.(*sql.Tx); inTx {
+		return read(primary)
+	}
+
+	r := replicas.pick()
+	if r == nil {
+		return read(primary)
+	}
+
+	err := read(r.db)
+	if err == nil || ctx.Err() != nil {
+		return err
+	}
+
+	// Only a lost server takes the replica out of rotation; a query error may be lag, and
+	// would fail the same on every replica otherwise
+	if isConnectionError(err) {
+		r.markDown(err)
+	}
+	return read(primary)
+}
+
+// Helper function to tell whether err means the server could not be reached or the
+// connection broke, rather than that the query failed
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, mysql.ErrInvalidConn) ||
+		errors.Is(err, ErrDatabaseUnavailable) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
+
+// Helper function to add timezone=UTC to a PostgreSQL DSN, in URL or keyword/value form
+func withPostgresUTC(dsn string) string {
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		if parsed, err := url.Parse(dsn); err == nil {
+			query := parsed.Query()
+			query.Set("timezone", "UTC")
+			parsed.RawQuery = query.Encode()
+			return parsed.String()
+		}
+		// An invalid URL is reported when the replica is opened
+		return dsn
+	}
+	// A later keyword overrides an earlier one
+	return dsn + " timezone=UTC"
+}
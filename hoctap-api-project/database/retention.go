@@ -0,0 +1,223 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"hoctap-api/i18n"
+)
+
+// Retention actions
+const (
+	RetentionActionDelete    = "delete"
+	RetentionActionAnonymize = "anonymize"
+)
+
+// RetentionPolicy says how long records of an entity are kept and what happens afterwards
+type RetentionPolicy struct {
+	Entity       string     `json:"entity"`
+	Action       string     `json:"action"`
+	Days         int        `json:"days"`
+	Enabled      bool       `json:"enabled"`
+	LastRunAt    *time.Time `json:"last_run_at"`
+	LastAffected int64      `json:"last_affected"`
+}
+
+// retentionTarget describes how a policy is applied to one entity
+type retentionTarget struct {
+	actions []string
+	apply   func(ctx context.Context, db *sql.DB, action string, cutoff time.Time) (int64, error)
+}
+
+// retentionTargets lists the entities retention policies can be defined for
+var retentionTargets = map[string]retentionTarget{
+	"tracked_events": {
+		actions: []string{RetentionActionDelete},
+		apply: func(ctx context.Context, db *sql.DB, action string, cutoff time.Time) (int64, error) {
+			return execRowsAffected(ctx, db, `DELETE FROM tracked_events WHERE occurred_at < ?`, cutoff)
+		},
+	},
+	"webhook_deliveries": {
+		actions: []string{RetentionActionDelete},
+		apply: func(ctx context.Context, db *sql.DB, action string, cutoff time.Time) (int64, error) {
+			return execRowsAffected(ctx, db, `DELETE FROM webhook_deliveries WHERE created_at < ?`, cutoff)
+		},
+	},
+	"notifications": {
+		actions: []string{RetentionActionDelete},
+		apply: func(ctx context.Context, db *sql.DB, action string, cutoff time.Time) (int64, error) {
+			return execRowsAffected(ctx, db, `DELETE FROM notifications WHERE created_at < ?`, cutoff)
+		},
+	},
+	"dead_jobs": {
+		actions: []string{RetentionActionDelete},
+		apply: func(ctx context.Context, db *sql.DB, action string, cutoff time.Time) (int64, error) {
+			return execRowsAffected(ctx, db, `DELETE FROM jobs WHERE status = ? AND updated_at < ?`, JobDead, cutoff)
+		},
+	},
+	"experiment_exposures": {
+		actions: []string{RetentionActionDelete},
+		apply: func(ctx context.Context, db *sql.DB, action string, cutoff time.Time) (int64, error) {
+			return execRowsAffected(ctx, db, `DELETE FROM experiment_exposures WHERE last_exposed_at < ?`, cutoff)
+		},
+	},
+	"users": {
+		actions: []string{RetentionActionDelete, RetentionActionAnonymize},
+		apply: func(ctx context.Context, db *sql.DB, action string, cutoff time.Time) (int64, error) {
+			// Users without changes since the cutoff are considered inactive; held users are never touched
+			if action == RetentionActionDelete {
+				return execRowsAffected(ctx, db, `DELETE FROM users WHERE updated_at < ? AND legal_hold = FALSE`, cutoff)
+			}
+			return execRowsAffected(ctx, db, `UPDATE users SET name = 'Anonymized User',
+				email = CONCAT('anonymized-', id, '@invalid.local'), avatar_url = ''
+				WHERE updated_at < ? AND legal_hold = FALSE AND email NOT LIKE 'anonymized-%@invalid.local'`, cutoff)
+		},
+	},
+}
+
+// RetentionEntities returns the entities that support retention policies
+func RetentionEntities() []string {
+	entities := make([]string, 0, len(retentionTargets))
+	for entity := range retentionTargets {
+		entities = append(entities, entity)
+	}
+	sort.Strings(entities)
+	return entities
+}
+
+// ValidateRetentionPolicy checks that the entity and action are supported and the period is
+// positive. Its errors are i18n errors, sent to clients as they are.
+func ValidateRetentionPolicy(policy RetentionPolicy) error {
+	target, ok := retentionTargets[policy.Entity]
+	if !ok {
+		return i18n.NewError("retention_unsupported_entity", "entity", policy.Entity)
+	}
+
+	supported := false
+	for _, action := range target.actions {
+		if action == policy.Action {
+			supported = true
+		}
+	}
+	if !supported {
+		return i18n.NewError("retention_unsupported_action", "action", policy.Action, "entity", policy.Entity)
+	}
+
+	if policy.Days < 1 {
+		return i18n.NewError("retention_period_too_short")
+	}
+
+	return nil
+}
+
+// ErrRetentionPolicyNotFound matches (with errors.Is) the error of a missing retention policy
+var ErrRetentionPolicyNotFound = errors.New("retention policy not found")
+
+// RetentionRepository handles retention policy database operations
+type RetentionRepository struct {
+	db *sql.DB
+}
+
+// NewRetentionRepository creates a new retention repository
+func NewRetentionRepository() *RetentionRepository {
+	return &RetentionRepository{db: DB}
+}
+
+// GetPolicies retrieves all configured retention policies
+func (rr *RetentionRepository) GetPolicies(ctx context.Context) ([]RetentionPolicy, error) {
+	query := `SELECT entity, action, days, enabled, last_run_at, last_affected FROM retention_policies ORDER BY entity`
+
+	rows, err := rr.db.QueryContext(ctx, rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention policies: %v", err)
+	}
+	defer rows.Close()
+
+	policies := []RetentionPolicy{}
+	for rows.Next() {
+		var p RetentionPolicy
+		if err := rows.Scan(&p.Entity, &p.Action, &p.Days, &p.Enabled, &p.LastRunAt, &p.LastAffected); err != nil {
+			return nil, fmt.Errorf("failed to scan retention policy: %v", err)
+		}
+		policies = append(policies, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return policies, nil
+}
+
+// SavePolicy creates or replaces the policy for an entity
+func (rr *RetentionRepository) SavePolicy(ctx context.Context, policy RetentionPolicy) error {
+	if err := ValidateRetentionPolicy(policy); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO retention_policies (entity, action, days, enabled) VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE action = VALUES(action), days = VALUES(days), enabled = VALUES(enabled)`
+	if dialect == DialectPostgres {
+		query = `INSERT INTO retention_policies (entity, action, days, enabled) VALUES (?, ?, ?, ?)
+		ON CONFLICT (entity) DO UPDATE SET action = EXCLUDED.action, days = EXCLUDED.days, enabled = EXCLUDED.enabled`
+	}
+
+	if _, err := rr.db.ExecContext(ctx, rebind(query), policy.Entity, policy.Action, policy.Days, policy.Enabled); err != nil {
+		return fmt.Errorf("failed to save retention policy: %v", err)
+	}
+
+	return nil
+}
+
+// DeletePolicy removes the policy for an entity
+func (rr *RetentionRepository) DeletePolicy(ctx context.Context, entity string) error {
+	result, err := rr.db.ExecContext(ctx, rebind(`DELETE FROM retention_policies WHERE entity = ?`), entity)
+	if err != nil {
+		return fmt.Errorf("failed to delete retention policy: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return sentinelErrorf(ErrRetentionPolicyNotFound, "retention policy for '%s' not found", entity)
+	}
+
+	return nil
+}
+
+// ApplyPolicy purges or anonymizes the records older than the policy's period
+// and records the outcome on the policy
+func (rr *RetentionRepository) ApplyPolicy(ctx context.Context, policy RetentionPolicy, now time.Time) (int64, error) {
+	if err := ValidateRetentionPolicy(policy); err != nil {
+		return 0, err
+	}
+
+	cutoff := now.AddDate(0, 0, -policy.Days)
+	affected, err := retentionTargets[policy.Entity].apply(ctx, rr.db, policy.Action, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply retention policy for '%s': %v", policy.Entity, err)
+	}
+
+	query := `UPDATE retention_policies SET last_run_at = ?, last_affected = ? WHERE entity = ?`
+	if _, err := rr.db.ExecContext(ctx, rebind(query), now, affected, policy.Entity); err != nil {
+		return affected, fmt.Errorf("failed to record retention run: %v", err)
+	}
+
+	return affected, nil
+}
+
+// Helper function to execute a statement and return the number of affected rows
+func execRowsAffected(ctx context.Context, db dbtx, query string, args ...interface{}) (int64, error) {
+	result, err := db.ExecContext(ctx, rebind(query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,203 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"hoctap-api/config"
+)
+
+// Short link code settings
+const (
+	shortLinkCodeLength   = 7
+	shortLinkCodeAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	shortLinkMaxRetries   = 5
+)
+
+// ShortLink represents a short code redirecting to a longer URL
+type ShortLink struct {
+	ID            int        `json:"id"`
+	Code          string     `json:"code"`
+	TargetURL     string     `json:"target_url"`
+	Clicks        int        `json:"clicks"`
+	LastClickedAt *time.Time `json:"last_clicked_at"`
+	ExpiresAt     *time.Time `json:"expires_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Expired reports whether the link can no longer be followed
+func (sl *ShortLink) Expired() bool {
+	return sl.ExpiresAt != nil && time.Now().After(*sl.ExpiresAt)
+}
+
+// Errors of short link writes and lookups, matched with errors.Is
+var (
+	ErrShortLinkNotFound = errors.New("short link not found")
+	ErrShortLinkExists   = errors.New("short link already exists")
+)
+
+// ShortLinkRepository handles short link database operations
+type ShortLinkRepository struct {
+	db *sql.DB
+	// missing remembers codes recently found not to exist
+	missing *negativeCache
+}
+
+// NewShortLinkRepository creates a new short link repository
+func NewShortLinkRepository() *ShortLinkRepository {
+	return &ShortLinkRepository{db: DB, missing: newNegativeCache(config.Current().Database.NegativeCacheTTL)}
+}
+
+// CreateShortLink stores a new short link. When code is empty a random one is generated.
+func (sr *ShortLinkRepository) CreateShortLink(ctx context.Context, code, targetURL string, expiresAt *time.Time) (*ShortLink, error) {
+	generated := code == ""
+
+	for attempt := 0; attempt < shortLinkMaxRetries; attempt++ {
+		if generated {
+			var err error
+			if code, err = generateShortLinkCode(); err != nil {
+				return nil, fmt.Errorf("failed to generate short link code: %v", err)
+			}
+		}
+
+		if exists, err := sr.codeExists(ctx, code); err != nil {
+			return nil, fmt.Errorf("failed to check code existence: %v", err)
+		} else if exists {
+			if generated {
+				continue
+			}
+			return nil, sentinelErrorf(ErrShortLinkExists, "short link with code '%s' already exists", code)
+		}
+
+		query := `INSERT INTO short_links (code, target_url, expires_at) VALUES (?, ?, ?)`
+
+		if _, err := sr.db.ExecContext(ctx, rebind(query), code, targetURL, expiresAt); err != nil {
+			// Lost a race with a concurrent create of the same code
+			if isDuplicateKeyError(err) {
+				if generated {
+					continue
+				}
+				return nil, sentinelErrorf(ErrShortLinkExists, "short link with code '%s' already exists", code)
+			}
+			return nil, fmt.Errorf("failed to create short link: %v", err)
+		}
+		sr.missing.Forget(code)
+
+		return sr.GetShortLinkByCode(ctx, code)
+	}
+
+	return nil, fmt.Errorf("failed to generate a unique short link code")
+}
+
+// GetShortLinkByCode retrieves a short link by its code. Codes found missing are
+// answered from the negative cache for a short while.
+func (sr *ShortLinkRepository) GetShortLinkByCode(ctx context.Context, code string) (*ShortLink, error) {
+	if sr.missing.Has(code) {
+		return nil, sentinelErrorf(ErrShortLinkNotFound, "short link '%s' not found", code)
+	}
+
+	query := `SELECT id, code, target_url, clicks, last_clicked_at, expires_at, created_at FROM short_links WHERE code = ?`
+
+	var link ShortLink
+	err := sr.db.QueryRowContext(ctx, rebind(query), code).Scan(
+		&link.ID, &link.Code, &link.TargetURL, &link.Clicks, &link.LastClickedAt, &link.ExpiresAt, &link.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			sr.missing.Add(code)
+			return nil, sentinelErrorf(ErrShortLinkNotFound, "short link '%s' not found", code)
+		}
+		return nil, fmt.Errorf("failed to get short link: %v", err)
+	}
+
+	return &link, nil
+}
+
+// GetAllShortLinks retrieves all short links, newest first
+func (sr *ShortLinkRepository) GetAllShortLinks(ctx context.Context) ([]ShortLink, error) {
+	query := `SELECT id, code, target_url, clicks, last_clicked_at, expires_at, created_at FROM short_links ORDER BY created_at DESC`
+
+	rows, err := sr.db.QueryContext(ctx, rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query short links: %v", err)
+	}
+	defer rows.Close()
+
+	links := []ShortLink{}
+	for rows.Next() {
+		var link ShortLink
+		err := rows.Scan(&link.ID, &link.Code, &link.TargetURL, &link.Clicks, &link.LastClickedAt, &link.ExpiresAt, &link.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan short link: %v", err)
+		}
+		links = append(links, link)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return links, nil
+}
+
+// RecordClick increments the click counter of a short link
+func (sr *ShortLinkRepository) RecordClick(ctx context.Context, id int) error {
+	query := `UPDATE short_links SET clicks = clicks + 1, last_clicked_at = CURRENT_TIMESTAMP WHERE id = ?`
+
+	if _, err := sr.db.ExecContext(ctx, rebind(query), id); err != nil {
+		return fmt.Errorf("failed to record short link click: %v", err)
+	}
+
+	return nil
+}
+
+// DeleteShortLink removes a short link by code
+func (sr *ShortLinkRepository) DeleteShortLink(ctx context.Context, code string) error {
+	result, err := sr.db.ExecContext(ctx, rebind(`DELETE FROM short_links WHERE code = ?`), code)
+	if err != nil {
+		return fmt.Errorf("failed to delete short link: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return sentinelErrorf(ErrShortLinkNotFound, "short link '%s' not found", code)
+	}
+
+	return nil
+}
+
+// Helper function to check if a code is already taken
+func (sr *ShortLinkRepository) codeExists(ctx context.Context, code string) (bool, error) {
+	var count int
+	err := sr.db.QueryRowContext(ctx, rebind(`SELECT COUNT(*) FROM short_links WHERE code = ?`), code).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// Helper function to generate a random code from an unambiguous alphabet
+func generateShortLinkCode() (string, error) {
+	var b strings.Builder
+	max := big.NewInt(int64(len(shortLinkCodeAlphabet)))
+	for i := 0; i < shortLinkCodeLength; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(shortLinkCodeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TrackedEvent is a lightweight client analytics event (page view, button click, ...)
+type TrackedEvent struct {
+	Name       string
+	Path       string
+	UserID     *int
+	SessionID  string
+	Properties string
+	ClientIP   string
+	OccurredAt time.Time
+}
+
+// TrackingRepository handles analytics event database operations
+type TrackingRepository struct {
+	db *sql.DB
+}
+
+// NewTrackingRepository creates a new tracking repository
+func NewTrackingRepository() *TrackingRepository {
+	return &TrackingRepository{db: DB}
+}
+
+// InsertEvents stores a batch of events with a single multi-row INSERT
+func (tr *TrackingRepository) InsertEvents(ctx context.Context, events []TrackedEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	placeholders := make([]string, 0, len(events))
+	args := make([]interface{}, 0, len(events)*7)
+	for _, e := range events {
+		placeholders = append(placeholders, "(?, ?, ?, ?, ?, ?, ?)")
+		var properties interface{}
+		if e.Properties != "" {
+			properties = e.Properties
+		}
+		args = append(args, e.Name, e.Path, e.UserID, e.SessionID, properties, e.ClientIP, e.OccurredAt)
+	}
+
+	query := `INSERT INTO tracked_events (name, path, user_id, session_id, properties, client_ip, occurred_at) VALUES ` +
+		strings.Join(placeholders, ", ")
+
+	if _, err := tr.db.ExecContext(ctx, rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to insert tracked events: %v", err)
+	}
+
+	return nil
+}
@@ -1,136 +1,671 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
+
+	"hoctap-api/config"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // User represents a user in the database
 type User struct {
-	ID        int       `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID int `json:"id"`
+	// UUID is the public identifier of the user; the numeric ID is deprecated in URLs
+	UUID            string    `json:"uuid"`
+	Name            string    `json:"name"`
+	Email           string    `json:"email"`
+	LegalHold       bool      `json:"legal_hold"`
+	LegalHoldReason string    `json:"legal_hold_reason,omitempty"`
+	AvatarURL       string    `json:"avatar_url,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// userColumns is the column list matching scanUser
+const userColumns = `id, uuid, name, email, legal_hold, legal_hold_reason, avatar_url, created_at, updated_at`
+
+// Helper function to scan a user row selected with userColumns
+func scanUser(row rowScanner) (*User, error) {
+	var user User
+	if err := scanUserInto(row, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Helper function to scan a user row into an existing value, letting list queries
+// fill slice elements in place instead of allocating and copying each user
+func scanUserInto(row rowScanner, user *User) error {
+	return row.Scan(&user.ID, &user.UUID, &user.Name, &user.Email, &user.LegalHold, &user.LegalHoldReason,
+		&user.AvatarURL, &user.CreatedAt, &user.UpdatedAt)
+}
+
+// userFields lists the fields of User in userColumns order by JSON name, with their column
+// and scan destination, so listings can select a sparse fieldset
+var userFields = []struct {
+	name   string
+	column string
+	dest   func(*User) interface{}
+}{
+	{"id", "id", func(u *User) interface{} { return &u.ID }},
+	{"uuid", "uuid", func(u *User) interface{} { return &u.UUID }},
+	{"name", "name", func(u *User) interface{} { return &u.Name }},
+	{"email", "email", func(u *User) interface{} { return &u.Email }},
+	{"legal_hold", "legal_hold", func(u *User) interface{} { return &u.LegalHold }},
+	{"legal_hold_reason", "legal_hold_reason", func(u *User) interface{} { return &u.LegalHoldReason }},
+	{"avatar_url", "avatar_url", func(u *User) interface{} { return &u.AvatarURL }},
+	{"created_at", "created_at", func(u *User) interface{} { return &u.CreatedAt }},
+	{"updated_at", "updated_at", func(u *User) interface{} { return &u.UpdatedAt }},
+}
+
+// IsUserField tells whether name is the JSON name of a User field
+func IsUserField(name string) bool {
+	for _, field := range userFields {
+		if field.name == name {
+			return true
+		}
+	}
+	return false
 }
 
+// Helper function to build the column list and scanner selecting only fields (JSON names).
+// The ID and UUID are always selected; no fields selects every column.
+func userProjection(fields []string) (string, func(rowScanner, *User) error) {
+	if len(fields) == 0 {
+		return userColumns, scanUserInto
+	}
+
+	selected := map[string]bool{"id": true, "uuid": true}
+	for _, name := range fields {
+		selected[name] = true
+	}
+
+	var columns []string
+	var dests []func(*User) interface{}
+	for _, field := range userFields {
+		if selected[field.name] {
+			columns = append(columns, field.column)
+			dests = append(dests, field.dest)
+		}
+	}
+
+	return strings.Join(columns, ", "), func(row rowScanner, user *User) error {
+		targets := make([]interface{}, len(dests))
+		for i, dest := range dests {
+			targets[i] = dest(user)
+		}
+		return row.Scan(targets...)
+	}
+}
+
+// ErrEmailExists matches (with errors.Is) the error of a write giving a user the email of
+// another user
+var ErrEmailExists = errors.New("email already exists")
+
+// ErrUserNotFound matches (with errors.Is) the error of a lookup or write of a missing user,
+// by ID or by UUID
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrUserUnderLegalHold matches (with errors.Is) the error of deleting a user under legal hold
+var ErrUserUnderLegalHold = errors.New("user is under legal hold")
+
+// EmailExistsError is the error of a write giving a user the email of another user,
+// detected by the case-insensitive unique index on email
+type EmailExistsError struct {
+	Email string
+}
+
+func (e *EmailExistsError) Error() string {
+	return fmt.Sprintf("user with email '%s' already exists", e.Email)
+}
+
+// Is makes EmailExistsError match ErrEmailExists
+func (e *EmailExistsError) Is(target error) bool {
+	return target == ErrEmailExists
+}
+
+// auditEntityUser is the entity name used for user changes in the audit log
+const auditEntityUser = "user"
+
 // UserRepository handles user database operations
 type UserRepository struct {
-	db *sql.DB
+	db    dbtx
+	audit *AuditRepository
+	actor AuditActor
+	// outbox records the user events, published once the change commits
+	outbox *OutboxRepository
+	// activity records the changes shown in the user's activity feed
+	activity *ActivityRepository
+	// reads coalesces concurrent identical hot reads into one query; shared by WithActor copies
+	reads *singleflight.Group
+	// missing remembers IDs recently found not to exist
+	missing *negativeCache
+	// committed collects what to run once the transaction of a scoped copy commits
+	committed *[]func()
 }
 
 // NewUserRepository creates a new user repository
 func NewUserRepository() *UserRepository {
-	return &UserRepository{db: DB}
+	return &UserRepository{
+		db:       DB,
+		audit:    NewAuditRepository(),
+		outbox:   NewOutboxRepository(),
+		activity: NewActivityRepository(),
+		reads:    &singleflight.Group{},
+		missing:  newNegativeCache(config.Current().Database.NegativeCacheTTL),
+	}
+}
+
+// WithActor returns a copy of the repository that attributes audited changes to actor
+func (ur *UserRepository) WithActor(actor AuditActor) UserStore {
+	scoped := *ur
+	scoped.actor = actor
+	return &scoped
+}
+
+// WithTx runs fn with a copy of the repository whose queries, audit records and outbox events
+// included, run in one transaction. It commits when fn returns nil and rolls back when fn
+// returns an error or panics. Inside fn, reads see the transaction's own writes: they skip replicas, shared reads
+// and the negative cache. Calls nested in fn join the outer transaction.
+func (ur *UserRepository) WithTx(ctx context.Context, fn func(tx UserStore) error) error {
+	return ur.withTx(ctx, func(tx *UserRepository) error {
+		return fn(tx)
+	})
+}
+
+// Helper function to run fn in a transaction, or in the current one when ur is already in a
+// transaction
+func (ur *UserRepository) withTx(ctx context.Context, fn func(tx *UserRepository) error) error {
+	db, ok := ur.db.(*sql.DB)
+	if !ok {
+		return fn(ur)
+	}
+
+	var committed []func()
+	err := inTx(ctx, db, func(tx *sql.Tx) error {
+		scoped := *ur
+		scoped.db = tx
+		scoped.audit = &AuditRepository{db: tx}
+		scoped.outbox = &OutboxRepository{db: tx}
+		scoped.activity = &ActivityRepository{db: tx}
+		scoped.committed = &committed
+		return fn(&scoped)
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, hook := range committed {
+		hook()
+	}
+	return nil
+}
+
+// Helper function to run hook once the transaction of ur commits, or now outside one. Hooks
+// of a rolled back transaction never run.
+func (ur *UserRepository) afterCommit(hook func()) {
+	if ur.committed == nil {
+		hook()
+		return
+	}
+	*ur.committed = append(*ur.committed, hook)
+}
+
+// Helper function to tell whether the repository runs in a transaction
+func (ur *UserRepository) inTx() bool {
+	_, ok := ur.db.(*sql.Tx)
+	return ok
 }
 
 // GetAllUsers retrieves all users from the database
-func (ur *UserRepository) GetAllUsers() ([]User, error) {
-	query := `SELECT id, name, email, created_at, updated_at FROM users ORDER BY created_at DESC`
+func (ur *UserRepository) GetAllUsers(ctx context.Context) ([]User, error) {
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY created_at DESC`
 
-	rows, err := ur.db.Query(query)
+	var users []User
+	err := readFrom(ctx, ur.db, func(db dbtx) error {
+		users = nil
+		return queryUsers(ctx, db, &users, scanUserInto, query)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to query users: %v", err)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Helper function to run a user query and append the rows, read with scan, to users
+func queryUsers(ctx context.Context, db dbtx, users *[]User, scan func(rowScanner, *User) error, query string, args ...interface{}) error {
+	rows, err := db.QueryContext(ctx, rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to query users: %v", err)
 	}
 	defer rows.Close()
 
-	var users []User
 	for rows.Next() {
-		var user User
-		err := rows.Scan(&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan user: %v", err)
+		*users = append(*users, User{})
+		if err := scan(rows, &(*users)[len(*users)-1]); err != nil {
+			return fmt.Errorf("failed to scan user: %v", err)
 		}
-		users = append(users, user)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %v", err)
+		return fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return nil
+}
+
+// UserFilter narrows down and paginates user listings; zero values are ignored.
+// After pages by keyset instead of Offset: the listing starts right after that user.
+// Fields (JSON names) lets a store select only those columns; other fields may be left zero.
+// CreatedSince keeps the users created at or after that time.
+type UserFilter struct {
+	Search       string
+	CreatedSince time.Time
+	Limit        int
+	Offset       int
+	After        *UserCursor
+	Fields       []string
+}
+
+// UserCursor is a position in the newest-first user listing, the (created_at, id) of the
+// last user of the previous page
+type UserCursor struct {
+	CreatedAt time.Time
+	ID        int
+}
+
+// SignupSlot is the length of the slots CountSignups groups signups into. Time zone offsets
+// are multiples of it, so slots add up to whole local days.
+const SignupSlot = 15 * time.Minute
+
+// SignupCount is the number of users created in the SignupSlot starting at Start
+type SignupCount struct {
+	Start time.Time
+	Count int
+}
+
+// Before tells whether user comes after the cursor in the newest-first listing
+func (c UserCursor) Before(user User) bool {
+	if !user.CreatedAt.Equal(c.CreatedAt) {
+		return user.CreatedAt.Before(c.CreatedAt)
+	}
+	return user.ID < c.ID
+}
+
+// Helper function to build the WHERE clause shared by ListUsers and CountUsers
+func (f UserFilter) where() (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if f.Search != "" {
+		pattern := "%" + f.Search + "%"
+		like := likeIgnoreCase()
+		conditions = append(conditions, "(name "+like+" ? OR email "+like+" ?)")
+		args = append(args, pattern, pattern)
+	}
+	if !f.CreatedSince.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, f.CreatedSince)
+	}
+	if f.After != nil {
+		conditions = append(conditions, "(created_at < ? OR (created_at = ? AND id < ?))")
+		args = append(args, f.After.CreatedAt, f.After.CreatedAt, f.After.ID)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// ListUsers retrieves a page of users matching the filter, newest first
+func (ur *UserRepository) ListUsers(ctx context.Context, filter UserFilter) ([]User, error) {
+	where, args := filter.where()
+	columns, scan := userProjection(filter.Fields)
+	query := `SELECT ` + columns + ` FROM users` + where + ` ORDER BY created_at DESC, id DESC`
+
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	// A page never holds more than the limit, so size the slice once
+	users := make([]User, 0, filter.Limit)
+	err := readFrom(ctx, ur.db, func(db dbtx) error {
+		users = users[:0]
+		return queryUsers(ctx, db, &users, scan, query, args...)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return users, nil
 }
 
-// GetUserByID retrieves a user by ID
-func (ur *UserRepository) GetUserByID(id int) (*User, error) {
-	query := `SELECT id, name, email, created_at, updated_at FROM users WHERE id = ?`
+// CountUsers returns the number of users matching the filter, ignoring pagination
+func (ur *UserRepository) CountUsers(ctx context.Context, filter UserFilter) (int, error) {
+	filter.After = nil
+	where, args := filter.where()
 
-	var user User
-	err := ur.db.QueryRow(query, id).Scan(
-		&user.ID, &user.Name, &user.Email, &user.CreatedAt, &user.UpdatedAt,
-	)
+	var count int
+	err := readFrom(ctx, ur.db, func(db dbtx) error {
+		return db.QueryRowContext(ctx, rebind(`SELECT COUNT(*) FROM users`+where), args...).Scan(&count)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count users: %v", err)
+	}
 
+	return count, nil
+}
+
+// SearchUsers returns up to limit users whose name or email starts with prefix, ignoring
+// case, ordered by name, for autocomplete. Only the ID, UUID, name and email are selected.
+func (ur *UserRepository) SearchUsers(ctx context.Context, prefix string, limit int) ([]User, error) {
+	columns, scan := userProjection([]string{"name", "email"})
+	pattern := likeEscaper.Replace(prefix) + "%"
+	name, email := "name", "email"
+	if dialect == DialectPostgres {
+		// The prefix indexes are on the lowercased columns; MySQL's collation ignores case
+		name, email, pattern = "LOWER(name)", "LOWER(email)", strings.ToLower(pattern)
+	}
+
+	// One branch per column, so each can use its prefix index, which an OR of both would not
+	query := `SELECT ` + columns + ` FROM (
+		(SELECT ` + columns + ` FROM users WHERE ` + name + ` LIKE ? ORDER BY name, id LIMIT ?)
+		UNION
+		(SELECT ` + columns + ` FROM users WHERE ` + email + ` LIKE ? ORDER BY name, id LIMIT ?)
+	) matches ORDER BY name, id LIMIT ?`
+
+	users := make([]User, 0, limit)
+	err := readFrom(ctx, ur.db, func(db dbtx) error {
+		users = users[:0]
+		return queryUsers(ctx, db, &users, scan, query, pattern, limit, pattern, limit, limit)
+	})
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user with ID %d not found", id)
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// CountSignups returns the number of users created in [from, to) per SignupSlot, for the
+// slots with any, in order. Slots are counted in UTC by the database, to be summed into
+// days, weeks or months of any time zone by the caller.
+func (ur *UserRepository) CountSignups(ctx context.Context, from, to time.Time) ([]SignupCount, error) {
+	seconds := int(SignupSlot / time.Second)
+	query := `SELECT ` + epochSlot("created_at", seconds) + ` AS slot, COUNT(*) FROM users
+		WHERE created_at >= ? AND created_at < ? GROUP BY slot ORDER BY slot`
+
+	var counts []SignupCount
+	err := readFrom(ctx, ur.db, func(db dbtx) error {
+		rows, err := db.QueryContext(ctx, rebind(query), from.UTC(), to.UTC())
+		if err != nil {
+			return err
 		}
-		return nil, fmt.Errorf("failed to get user: %v", err)
+		defer rows.Close()
+
+		counts = counts[:0]
+		for rows.Next() {
+			var slot int64
+			var count SignupCount
+			if err := rows.Scan(&slot, &count.Count); err != nil {
+				return err
+			}
+			count.Start = time.Unix(slot*int64(seconds), 0).UTC()
+			counts = append(counts, count)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count signups: %v", err)
 	}
 
+	return counts, nil
+}
+
+// GetUserByID retrieves a user by ID.
+// Concurrent lookups of the same ID share a single query, and IDs primary found missing
+// are answered from the negative cache for a short while.
+func (ur *UserRepository) GetUserByID(ctx context.Context, id int) (*User, error) {
+	if ur.inTx() {
+		return ur.getUserFromPrimary(ctx, id)
+	}
+
+	key := userReadKey(id)
+	if ur.missing.Has(key) {
+		return nil, sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+	}
+
+	result, err := ur.sharedRead(ctx, key, func(ctx context.Context) (interface{}, error) {
+		query := `SELECT ` + userColumns + ` FROM users WHERE id = ?`
+
+		var user *User
+		err := readFrom(ctx, ur.db, func(db dbtx) (err error) {
+			user, err = scanUser(db.QueryRowContext(ctx, rebind(query), id))
+			return err
+		})
+		if err != nil {
+			// readFrom confirms a miss on primary, so a lagging replica is never cached
+			if err == sql.ErrNoRows {
+				ur.missing.Add(key)
+				return nil, sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+			}
+			return nil, fmt.Errorf("failed to get user: %v", err)
+		}
+		return *user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Every caller gets its own copy so shared results are never mutated
+	user := result.(User)
 	return &user, nil
 }
 
-// CreateUser creates a new user in the database
-func (ur *UserRepository) CreateUser(name, email string) (*User, error) {
-	// Check if email already exists
-	if exists, err := ur.emailExists(email); err != nil {
-		return nil, fmt.Errorf("failed to check email existence: %v", err)
-	} else if exists {
-		return nil, fmt.Errorf("user with email '%s' already exists", email)
+// GetUserIDByUUID returns the numeric ID of the user with a UUID, given in either case
+func (ur *UserRepository) GetUserIDByUUID(ctx context.Context, uuid string) (int, error) {
+	uuid = normalizeUUID(uuid)
+	query := `SELECT id FROM users WHERE uuid = ?`
+
+	var id int
+	err := readFrom(ctx, ur.db, func(db dbtx) error {
+		return db.QueryRowContext(ctx, rebind(query), uuid).Scan(&id)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, sentinelErrorf(ErrUserNotFound, "user with UUID '%s' not found", uuid)
+		}
+		return 0, fmt.Errorf("failed to get user: %v", err)
+	}
+
+	return id, nil
+}
+
+// Helper function to run a read through singleflight. The shared query is detached from
+// the cancellation of whichever caller started it; each caller still stops waiting as
+// soon as its own context is done.
+func (ur *UserRepository) sharedRead(ctx context.Context, key string, read func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	shared := context.WithoutCancel(ctx)
+	results := ur.reads.DoChan(key, func() (interface{}, error) {
+		return read(shared)
+	})
+
+	select {
+	case result := <-results:
+		return result.Val, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
+}
 
-	query := `INSERT INTO users (name, email) VALUES (?, ?)`
+// Helper function to build the read key of a user ID
+func userReadKey(id int) string {
+	return "user:" + strconv.Itoa(id)
+}
 
-	result, err := ur.db.Exec(query, name, email)
+// Helper function to read a user from the primary, bypassing replicas and caches, so writes
+// check and return the current row
+func (ur *UserRepository) getUserFromPrimary(ctx context.Context, id int) (*User, error) {
+	return ur.selectUser(ctx, `SELECT `+userColumns+` FROM users WHERE id = ?`, id)
+}
+
+// Helper function to read a user and lock the row until the transaction of ur ends, so the
+// checks of a write still hold when it is made
+func (ur *UserRepository) lockUser(ctx context.Context, id int) (*User, error) {
+	return ur.selectUser(ctx, `SELECT `+userColumns+` FROM users WHERE id = ? FOR UPDATE`, id)
+}
+
+// Helper function to run a single-user query on the primary
+func (ur *UserRepository) selectUser(ctx context.Context, query string, id int) (*User, error) {
+	user, err := scanUser(ur.db.QueryRowContext(ctx, rebind(query), id))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %v", err)
+		if err == sql.ErrNoRows {
+			return nil, sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get user: %v", err)
 	}
+	return user, nil
+}
+
+// CreateUser creates a new user in the database. The email check, insert, audit record and
+// outbox event run in one transaction.
+func (ur *UserRepository) CreateUser(ctx context.Context, name, email string) (user *User, err error) {
+	err = ur.withTx(ctx, func(tx *UserRepository) error {
+		user, err = tx.createUser(ctx, name, email)
+		return err
+	})
+	return user, err
+}
+
+// Helper function to create a user
+func (ur *UserRepository) createUser(ctx context.Context, name, email string) (*User, error) {
+	query := `INSERT INTO users (uuid, name, email) VALUES (?, ?, ?)`
 
-	id, err := result.LastInsertId()
+	// The unique index on email rejects duplicates, also between concurrent creates, so there
+	// is no existence check to race with
+	id, err := insertReturningID(ctx, ur.db, query, newUUID(), name, email)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert ID: %v", err)
+		if isDuplicateKeyError(err) {
+			return nil, &EmailExistsError{Email: email}
+		}
+		return nil, fmt.Errorf("failed to create user: %v", err)
 	}
 
+	// The ID may have been probed before it existed: once the user is visible to other
+	// connections, drop the cached miss and any in-flight lookup
+	ur.afterCommit(func() {
+		ur.missing.Forget(userReadKey(int(id)))
+		ur.reads.Forget(userReadKey(int(id)))
+	})
+
 	// Retrieve the created user
-	return ur.GetUserByID(int(id))
-}
+	user, err := ur.getUserFromPrimary(ctx, int(id))
+	if err != nil {
+		return nil, err
+	}
 
-// UpdateUser updates an existing user
-func (ur *UserRepository) UpdateUser(id int, name, email string) (*User, error) {
-	// Check if user exists
-	if _, err := ur.GetUserByID(id); err != nil {
+	if err := recordAudit(ctx, ur.audit, ur.actor, AuditActionCreate, auditEntityUser, user.ID, nil, user); err != nil {
 		return nil, err
 	}
+	recordActivity(ctx, ur.activity, user.ID, ActivityAccountCreated, ur.actor, nil)
+	if err := ur.outbox.Add(ctx, EventUserCreated, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
 
-	// Check if email already exists for another user
-	if exists, err := ur.emailExistsForOtherUser(email, id); err != nil {
-		return nil, fmt.Errorf("failed to check email existence: %v", err)
-	} else if exists {
-		return nil, fmt.Errorf("user with email '%s' already exists", email)
+// UpdateUser updates an existing user. The user is locked from the checks to the audit
+// record, which run in one transaction.
+func (ur *UserRepository) UpdateUser(ctx context.Context, id int, name, email string) (user *User, err error) {
+	err = ur.withTx(ctx, func(tx *UserRepository) error {
+		user, err = tx.updateUser(ctx, id, name, email)
+		return err
+	})
+	return user, err
+}
+
+// Helper function to update a user
+func (ur *UserRepository) updateUser(ctx context.Context, id int, name, email string) (*User, error) {
+	// Check if user exists
+	before, err := ur.lockUser(ctx, id)
+	if err != nil {
+		return nil, err
 	}
 
 	query := `UPDATE users SET name = ?, email = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`
 
-	_, err := ur.db.Exec(query, name, email, id)
+	_, err = ur.db.ExecContext(ctx, rebind(query), name, email, id)
 	if err != nil {
+		// Another user has the email
+		if isDuplicateKeyError(err) {
+			return nil, &EmailExistsError{Email: email}
+		}
 		return nil, fmt.Errorf("failed to update user: %v", err)
 	}
 
 	// Retrieve the updated user
-	return ur.GetUserByID(id)
+	user, err := ur.getUserFromPrimary(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, ur.audit, ur.actor, AuditActionUpdate, auditEntityUser, id, before, user); err != nil {
+		return nil, err
+	}
+	if changed := changedProfileFields(before, user); len(changed) > 0 {
+		recordActivity(ctx, ur.activity, id, ActivityProfileUpdated, ur.actor, map[string]interface{}{"fields": changed})
+	}
+	if err := ur.outbox.Add(ctx, EventUserUpdated, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Helper function to list the JSON names of the profile fields an update changed
+func changedProfileFields(before, after *User) []string {
+	var changed []string
+	if before.Name != after.Name {
+		changed = append(changed, "name")
+	}
+	if before.Email != after.Email {
+		changed = append(changed, "email")
+	}
+	return changed
 }
 
-// DeleteUser deletes a user by ID
-func (ur *UserRepository) DeleteUser(id int) error {
+// DeleteUser deletes a user by ID. The user is locked from the legal hold check to the audit
+// record, which run in one transaction.
+func (ur *UserRepository) DeleteUser(ctx context.Context, id int) error {
+	return ur.withTx(ctx, func(tx *UserRepository) error {
+		return tx.deleteUser(ctx, id)
+	})
+}
+
+// Helper function to delete a user
+func (ur *UserRepository) deleteUser(ctx context.Context, id int) error {
 	// Check if user exists
-	if _, err := ur.GetUserByID(id); err != nil {
+	before, err := ur.lockUser(ctx, id)
+	if err != nil {
 		return err
 	}
 
-	query := `DELETE FROM users WHERE id = ?`
+	// Records under legal hold must be kept until the hold is lifted
+	if before.LegalHold {
+		return sentinelErrorf(ErrUserUnderLegalHold, "user with ID %d is under legal hold", id)
+	}
 
-	result, err := ur.db.Exec(query, id)
+	query := `DELETE FROM users WHERE id = ? AND legal_hold = FALSE`
+
+	result, err := ur.db.ExecContext(ctx, rebind(query), id)
 	if err != nil {
 		return fmt.Errorf("failed to delete user: %v", err)
 	}
@@ -141,55 +676,136 @@ func (ur *UserRepository) DeleteUser(id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user with ID %d not found", id)
+		return sentinelErrorf(ErrUserNotFound, "user with ID %d not found", id)
 	}
 
-	return nil
+	if err := recordAudit(ctx, ur.audit, ur.actor, AuditActionDelete, auditEntityUser, id, before, nil); err != nil {
+		return err
+	}
+	return ur.outbox.Add(ctx, EventUserDeleted, map[string]interface{}{"id": id, "uuid": before.UUID})
 }
 
-// GetUsersCount returns the total number of users
-func (ur *UserRepository) GetUsersCount() (int, error) {
-	query := `SELECT COUNT(*) FROM users`
+// SetLegalHold places or lifts a legal hold on a user. While held, the user cannot be
+// deleted and is skipped by retention policies.
+func (ur *UserRepository) SetLegalHold(ctx context.Context, id int, hold bool, reason string) (user *User, err error) {
+	err = ur.withTx(ctx, func(tx *UserRepository) error {
+		user, err = tx.setLegalHold(ctx, id, hold, reason)
+		return err
+	})
+	return user, err
+}
 
-	var count int
-	err := ur.db.QueryRow(query).Scan(&count)
+// Helper function to place or lift a legal hold
+func (ur *UserRepository) setLegalHold(ctx context.Context, id int, hold bool, reason string) (*User, error) {
+	before, err := ur.lockUser(ctx, id)
 	if err != nil {
-		return 0, fmt.Errorf("failed to count users: %v", err)
+		return nil, err
 	}
 
-	return count, nil
+	if !hold {
+		reason = ""
+	}
+
+	query := `UPDATE users SET legal_hold = ?, legal_hold_reason = ? WHERE id = ?`
+
+	if _, err := ur.db.ExecContext(ctx, rebind(query), hold, reason, id); err != nil {
+		return nil, fmt.Errorf("failed to update legal hold: %v", err)
+	}
+
+	user, err := ur.getUserFromPrimary(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, ur.audit, ur.actor, AuditActionUpdate, auditEntityUser, id, before, user); err != nil {
+		return nil, err
+	}
+	if hold {
+		recordActivity(ctx, ur.activity, id, ActivityLegalHoldPlaced, ur.actor, map[string]interface{}{"reason": reason})
+	} else if before.LegalHold {
+		recordActivity(ctx, ur.activity, id, ActivityLegalHoldLifted, ur.actor, nil)
+	}
+	if err := ur.outbox.Add(ctx, EventUserUpdated, user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-// Helper function to check if email exists
-func (ur *UserRepository) emailExists(email string) (bool, error) {
-	query := `SELECT COUNT(*) FROM users WHERE email = ?`
+// SetAvatar records the URL of a user's stored avatar image; an empty URL removes it
+func (ur *UserRepository) SetAvatar(ctx context.Context, id int, avatarURL string) (user *User, err error) {
+	err = ur.withTx(ctx, func(tx *UserRepository) error {
+		user, err = tx.setAvatar(ctx, id, avatarURL)
+		return err
+	})
+	return user, err
+}
 
-	var count int
-	err := ur.db.QueryRow(query, email).Scan(&count)
+// Helper function to record a user's avatar URL
+func (ur *UserRepository) setAvatar(ctx context.Context, id int, avatarURL string) (*User, error) {
+	before, err := ur.lockUser(ctx, id)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
-	return count > 0, nil
+	query := `UPDATE users SET avatar_url = ? WHERE id = ?`
+
+	if _, err := ur.db.ExecContext(ctx, rebind(query), avatarURL, id); err != nil {
+		return nil, fmt.Errorf("failed to update avatar: %v", err)
+	}
+
+	user, err := ur.getUserFromPrimary(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordAudit(ctx, ur.audit, ur.actor, AuditActionUpdate, auditEntityUser, id, before, user); err != nil {
+		return nil, err
+	}
+	if avatarURL == "" {
+		recordActivity(ctx, ur.activity, id, ActivityAvatarRemoved, ur.actor, nil)
+	} else {
+		recordActivity(ctx, ur.activity, id, ActivityAvatarChanged, ur.actor, nil)
+	}
+	if err := ur.outbox.Add(ctx, EventUserUpdated, user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
 
-// Helper function to check if email exists for another user
-func (ur *UserRepository) emailExistsForOtherUser(email string, userID int) (bool, error) {
-	query := `SELECT COUNT(*) FROM users WHERE email = ? AND id != ?`
+// GetUsersCount returns the total number of users. Concurrent calls (stats endpoint,
+// GraphQL, live stats pushes) share a single query.
+func (ur *UserRepository) GetUsersCount(ctx context.Context) (int, error) {
+	read := ur.sharedRead
+	if ur.inTx() {
+		// Shared results must not leak a transaction's uncommitted rows
+		read = func(ctx context.Context, _ string, fn func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+			return fn(ctx)
+		}
+	}
 
-	var count int
-	err := ur.db.QueryRow(query, email, userID).Scan(&count)
+	result, err := read(ctx, "users:count", func(ctx context.Context) (interface{}, error) {
+		query := `SELECT COUNT(*) FROM users`
+
+		var count int
+		err := readFrom(ctx, ur.db, func(db dbtx) error {
+			return db.QueryRowContext(ctx, rebind(query)).Scan(&count)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to count users: %v", err)
+		}
+		return count, nil
+	})
 	if err != nil {
-		return false, err
+		return 0, err
 	}
 
-	return count > 0, nil
+	return result.(int), nil
 }
 
 // SeedUsers creates some initial users for testing
-func (ur *UserRepository) SeedUsers() error {
+func (ur *UserRepository) SeedUsers(ctx context.Context) error {
 	// Check if users already exist
-	count, err := ur.GetUsersCount()
+	count, err := ur.GetUsersCount(ctx)
 	if err != nil {
 		return err
 	}
@@ -209,7 +825,7 @@ func (ur *UserRepository) SeedUsers() error {
 	}
 
 	for _, user := range initialUsers {
-		_, err := ur.CreateUser(user.Name, user.Email)
+		_, err := ur.CreateUser(ctx, user.Name, user.Email)
 		if err != nil {
 			return fmt.Errorf("failed to seed user %s: %v", user.Name, err)
 		}
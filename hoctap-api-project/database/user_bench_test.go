@@ -0,0 +1,198 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// benchDriver is a database/sql driver answering every query with a fixed number of generated
+// user rows (the DSN), holding the columns the query selects, so the list and scan path can be
+// measured without a database server
+type benchDriver struct{}
+
+func (benchDriver) Open(dsn string) (driver.Conn, error) {
+	n, err := strconv.Atoi(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &benchConn{rows: n}, nil
+}
+
+type benchConn struct {
+	rows int
+}
+
+func (c *benchConn) Prepare(query string) (driver.Stmt, error) {
+	return &benchStmt{conn: c, query: query}, nil
+}
+
+func (c *benchConn) Close() error { return nil }
+
+func (c *benchConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions are not supported")
+}
+
+type benchStmt struct {
+	conn  *benchConn
+	query string
+}
+
+func (s *benchStmt) Close() error  { return nil }
+func (s *benchStmt) NumInput() int { return -1 }
+
+func (s *benchStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s *benchStmt) Query(args []driver.Value) (driver.Rows, error) {
+	start := strings.Index(s.query, "SELECT ") + len("SELECT ")
+	end := strings.Index(s.query, " FROM ")
+	columns := strings.Split(s.query[start:end], ", ")
+	for i := range columns {
+		columns[i] = strings.TrimSpace(columns[i])
+	}
+
+	limit := s.conn.rows
+	if len(args) >= 2 {
+		if n, ok := args[len(args)-2].(int64); ok && int(n) < limit {
+			limit = int(n)
+		}
+	}
+	return &benchRows{columns: columns, limit: limit}, nil
+}
+
+type benchRows struct {
+	columns []string
+	limit   int
+	next    int
+}
+
+// benchCreatedAt is the creation time of the first generated user; later ones are older
+var benchCreatedAt = time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+
+func (r *benchRows) Columns() []string { return r.columns }
+func (r *benchRows) Close() error      { return nil }
+
+func (r *benchRows) Next(dest []driver.Value) error {
+	if r.next == r.limit {
+		return io.EOF
+	}
+	id := int64(r.limit - r.next)
+	r.next++
+
+	for i, column := range r.columns {
+		switch column {
+		case "id":
+			dest[i] = id
+		case "uuid":
+			dest[i] = fmt.Sprintf("0b6c3b1e-5f4a-4c57-9a3e-%012d", id)
+		case "name":
+			dest[i] = "Nguyen Van " + strconv.FormatInt(id, 10)
+		case "email":
+			dest[i] = "user" + strconv.FormatInt(id, 10) + "@example.com"
+		case "legal_hold":
+			dest[i] = false
+		case "legal_hold_reason", "avatar_url":
+			dest[i] = ""
+		case "created_at", "updated_at":
+			dest[i] = benchCreatedAt.Add(-time.Duration(id) * time.Minute)
+		default:
+			return fmt.Errorf("unknown column %s", column)
+		}
+	}
+	return nil
+}
+
+func init() {
+	sql.Register("benchusers", benchDriver{})
+}
+
+// Helper function to open a bench database answering queries with rows users
+func openBenchDB(b *testing.B, rows int) *sql.DB {
+	b.Helper()
+
+	db, err := sql.Open("benchusers", strconv.Itoa(rows))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return db
+}
+
+// BenchmarkQueryUsers compares filling the result slice in place, as queryUsers does, with
+// scanning each user into its own allocation and copying it into the slice
+func BenchmarkQueryUsers(b *testing.B) {
+	ctx := context.Background()
+	query := `SELECT ` + userColumns + ` FROM users ORDER BY created_at DESC`
+
+	for _, rows := range []int{100, 1000} {
+		db := openBenchDB(b, rows)
+
+		b.Run(fmt.Sprintf("in_place/%d", rows), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				var users []User
+				if err := queryUsers(ctx, db, &users, scanUserInto, query); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("copied/%d", rows), func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				result, err := db.QueryContext(ctx, query)
+				if err != nil {
+					b.Fatal(err)
+				}
+				var users []User
+				for result.Next() {
+					user, err := scanUser(result)
+					if err != nil {
+						b.Fatal(err)
+					}
+					users = append(users, *user)
+				}
+				result.Close()
+			}
+		})
+	}
+}
+
+// BenchmarkListUsers measures a page of the user listing, with every column and with a
+// sparse fieldset
+func BenchmarkListUsers(b *testing.B) {
+	ctx := context.Background()
+	repo := &UserRepository{db: openBenchDB(b, 1000)}
+
+	tests := []struct {
+		name   string
+		filter UserFilter
+	}{
+		{"page", UserFilter{Limit: 50}},
+		{"page_fields", UserFilter{Limit: 50, Fields: []string{"name", "email"}}},
+	}
+
+	for _, tt := range tests {
+		b.Run(tt.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				users, err := repo.ListUsers(ctx, tt.filter)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if len(users) != tt.filter.Limit {
+					b.Fatalf("got %d users, want %d", len(users), tt.filter.Limit)
+				}
+			}
+		})
+	}
+}
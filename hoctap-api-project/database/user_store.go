@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// UserStore is the set of user operations the API layers (REST, GraphQL, gRPC) depend on.
+// UserRepository is the MySQL/PostgreSQL implementation; tests can substitute a mock.
+//
+//go:generate go run go.uber.org/mock/mockgen@v0.6.0 -source=user_store.go -destination=mocks/user_store.go -package=mocks
+type UserStore interface {
+	// WithActor returns a store that attributes audited changes to actor
+	WithActor(actor AuditActor) UserStore
+	// WithTx runs fn with a store whose operations run in one transaction, committed when fn
+	// returns nil and rolled back otherwise
+	WithTx(ctx context.Context, fn func(tx UserStore) error) error
+
+	GetAllUsers(ctx context.Context) ([]User, error)
+	ListUsers(ctx context.Context, filter UserFilter) ([]User, error)
+	SearchUsers(ctx context.Context, prefix string, limit int) ([]User, error)
+	CountUsers(ctx context.Context, filter UserFilter) (int, error)
+	GetUserByID(ctx context.Context, id int) (*User, error)
+	GetUserIDByUUID(ctx context.Context, uuid string) (int, error)
+	GetUsersCount(ctx context.Context) (int, error)
+	CountSignups(ctx context.Context, from, to time.Time) ([]SignupCount, error)
+
+	CreateUser(ctx context.Context, name, email string) (*User, error)
+	UpdateUser(ctx context.Context, id int, name, email string) (*User, error)
+	DeleteUser(ctx context.Context, id int) error
+	SetLegalHold(ctx context.Context, id int, hold bool, reason string) (*User, error)
+	SetAvatar(ctx context.Context, id int, avatarURL string) (*User, error)
+}
+
+// UserRepository must satisfy UserStore
+var _ UserStore = (*UserRepository)(nil)
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"crypto/rand"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// uuidPattern matches a UUID in its canonical text form
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsUUID tells whether s is a UUID in its canonical text form, in either case
+func IsUUID(s string) bool {
+	return uuidPattern.MatchString(s)
+}
+
+// Helper function to generate a random (version 4) UUID in lowercase canonical form
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// Helper function to bring a UUID to the lowercase form it is stored in
+func normalizeUUID(s string) string {
+	return strings.ToLower(s)
+}
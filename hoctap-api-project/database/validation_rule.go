@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ValidationRuleEntityUser is the entity of rules checked when users are created or updated
+const ValidationRuleEntityUser = "user"
+
+// ValidationRule is an admin-defined Lua script that validates records of an entity
+type ValidationRule struct {
+	ID        int       `json:"id"`
+	Entity    string    `json:"entity"`
+	Name      string    `json:"name"`
+	Script    string    `json:"script"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Errors of validation rule writes and lookups, matched with errors.Is
+var (
+	ErrValidationRuleNotFound = errors.New("validation rule not found")
+	ErrValidationRuleExists   = errors.New("validation rule already exists")
+)
+
+// ValidationRuleRepository handles validation rule database operations
+type ValidationRuleRepository struct {
+	db *sql.DB
+}
+
+// NewValidationRuleRepository creates a new validation rule repository
+func NewValidationRuleRepository() *ValidationRuleRepository {
+	return &ValidationRuleRepository{db: DB}
+}
+
+// CreateRule stores a new validation rule
+func (vr *ValidationRuleRepository) CreateRule(ctx context.Context, entity, name, script string, enabled bool) (*ValidationRule, error) {
+	query := `INSERT INTO validation_rules (entity, name, script, enabled) VALUES (?, ?, ?, ?)`
+
+	id, err := insertReturningID(ctx, vr.db, query, entity, name, script, enabled)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, sentinelErrorf(ErrValidationRuleExists, "validation rule '%s' already exists", name)
+		}
+		return nil, fmt.Errorf("failed to create validation rule: %v", err)
+	}
+
+	return vr.GetRuleByID(ctx, int(id))
+}
+
+// GetRuleByID retrieves a validation rule by ID
+func (vr *ValidationRuleRepository) GetRuleByID(ctx context.Context, id int) (*ValidationRule, error) {
+	query := `SELECT id, entity, name, script, enabled, created_at FROM validation_rules WHERE id = ?`
+
+	var rule ValidationRule
+	err := vr.db.QueryRowContext(ctx, rebind(query), id).Scan(
+		&rule.ID, &rule.Entity, &rule.Name, &rule.Script, &rule.Enabled, &rule.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sentinelErrorf(ErrValidationRuleNotFound, "validation rule with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get validation rule: %v", err)
+	}
+
+	return &rule, nil
+}
+
+// GetAllRules retrieves every validation rule, enabled or not
+func (vr *ValidationRuleRepository) GetAllRules(ctx context.Context) ([]ValidationRule, error) {
+	query := `SELECT id, entity, name, script, enabled, created_at FROM validation_rules ORDER BY entity, name`
+
+	rows, err := vr.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query validation rules: %v", err)
+	}
+	defer rows.Close()
+
+	rules := []ValidationRule{}
+	for rows.Next() {
+		var rule ValidationRule
+		if err := rows.Scan(&rule.ID, &rule.Entity, &rule.Name, &rule.Script, &rule.Enabled, &rule.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan validation rule: %v", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return rules, nil
+}
+
+// DeleteRule removes a validation rule by ID
+func (vr *ValidationRuleRepository) DeleteRule(ctx context.Context, id int) error {
+	rowsAffected, err := execRowsAffected(ctx, vr.db, `DELETE FROM validation_rules WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete validation rule: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return sentinelErrorf(ErrValidationRuleNotFound, "validation rule with ID %d not found", id)
+	}
+
+	return nil
+}
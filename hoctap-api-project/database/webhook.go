@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook represents a registered outgoing webhook endpoint
+type Webhook struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Subscribes reports whether the webhook wants to receive the given event.
+// A webhook without explicit events receives all of them.
+func (wh *Webhook) Subscribes(event string) bool {
+	if len(wh.Events) == 0 {
+		return true
+	}
+	for _, e := range wh.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery represents a single delivery attempt of an event to a webhook
+type WebhookDelivery struct {
+	ID         int       `json:"id"`
+	WebhookID  int       `json:"webhook_id"`
+	EventID    string    `json:"event_id"`
+	Event      string    `json:"event"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ErrWebhookNotFound matches (with errors.Is) the error of a missing webhook
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// WebhookRepository handles webhook database operations
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+// NewWebhookRepository creates a new webhook repository
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{db: DB}
+}
+
+// CreateWebhook registers a new webhook endpoint
+func (wr *WebhookRepository) CreateWebhook(ctx context.Context, url, secret string, events []string) (*Webhook, error) {
+	query := `INSERT INTO webhooks (url, secret, events) VALUES (?, ?, ?)`
+
+	id, err := insertReturningID(ctx, wr.db, query, url, secret, strings.Join(events, ","))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %v", err)
+	}
+
+	return wr.GetWebhookByID(ctx, int(id))
+}
+
+// GetWebhookByID retrieves a webhook by ID, including its signing secret
+func (wr *WebhookRepository) GetWebhookByID(ctx context.Context, id int) (*Webhook, error) {
+	query := `SELECT id, url, secret, events, active, created_at FROM webhooks WHERE id = ?`
+
+	webhook, err := scanWebhook(wr.db.QueryRowContext(ctx, rebind(query), id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, sentinelErrorf(ErrWebhookNotFound, "webhook with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get webhook: %v", err)
+	}
+
+	return webhook, nil
+}
+
+// GetAllWebhooks retrieves all registered webhooks, including their signing secrets
+func (wr *WebhookRepository) GetAllWebhooks(ctx context.Context) ([]Webhook, error) {
+	query := `SELECT id, url, secret, events, active, created_at FROM webhooks ORDER BY id`
+
+	return wr.queryWebhooks(ctx, query)
+}
+
+// GetActiveWebhooks retrieves the webhooks that should receive events
+func (wr *WebhookRepository) GetActiveWebhooks(ctx context.Context) ([]Webhook, error) {
+	query := `SELECT id, url, secret, events, active, created_at FROM webhooks WHERE active = TRUE ORDER BY id`
+
+	return wr.queryWebhooks(ctx, query)
+}
+
+// DeleteWebhook removes a webhook and its delivery log
+func (wr *WebhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	result, err := wr.db.ExecContext(ctx, rebind(`DELETE FROM webhooks WHERE id = ?`), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %v", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %v", err)
+	}
+
+	if rowsAffected == 0 {
+		return sentinelErrorf(ErrWebhookNotFound, "webhook with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// RecordDelivery stores the outcome of a delivery attempt
+func (wr *WebhookRepository) RecordDelivery(ctx context.Context, delivery WebhookDelivery) error {
+	query := `INSERT INTO webhook_deliveries (webhook_id, event_id, event, attempt, status_code, success, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := wr.db.ExecContext(ctx, rebind(query), delivery.WebhookID, delivery.EventID, delivery.Event, delivery.Attempt,
+		delivery.StatusCode, delivery.Success, delivery.Error, delivery.DurationMs)
+	if err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %v", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries retrieves the most recent delivery attempts for a webhook
+func (wr *WebhookRepository) GetDeliveries(ctx context.Context, webhookID, limit int) ([]WebhookDelivery, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	query := `SELECT id, webhook_id, event_id, event, attempt, status_code, success, error, duration_ms, created_at
+		FROM webhook_deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?`
+
+	rows, err := wr.db.QueryContext(ctx, rebind(query), webhookID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhook deliveries: %v", err)
+	}
+	defer rows.Close()
+
+	deliveries := []WebhookDelivery{}
+	for rows.Next() {
+		var d WebhookDelivery
+		err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.Event, &d.Attempt, &d.StatusCode,
+			&d.Success, &d.Error, &d.DurationMs, &d.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %v", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return deliveries, nil
+}
+
+// Helper function to run a webhook list query
+func (wr *WebhookRepository) queryWebhooks(ctx context.Context, query string) ([]Webhook, error) {
+	rows, err := wr.db.QueryContext(ctx, rebind(query))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webhooks: %v", err)
+	}
+	defer rows.Close()
+
+	webhooks := []Webhook{}
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %v", err)
+		}
+		webhooks = append(webhooks, *webhook)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %v", err)
+	}
+
+	return webhooks, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Helper function to scan a webhook row, splitting the stored event list
+func scanWebhook(row rowScanner) (*Webhook, error) {
+	var webhook Webhook
+	var events string
+	if err := row.Scan(&webhook.ID, &webhook.URL, &webhook.Secret, &events, &webhook.Active, &webhook.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	webhook.Events = []string{}
+	if events != "" {
+		webhook.Events = strings.Split(events, ",")
+	}
+
+	return &webhook, nil
+}
@@ -0,0 +1,85 @@
+package emailaddr
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/mail"
+	"strings"
+
+	"hoctap-api/i18n"
+)
+
+// Length limits of an address and its local part (RFC 5321)
+const (
+	maxLength      = 254
+	maxLocalLength = 64
+)
+
+// Resolver looks up the mail servers and addresses of a domain; net.DefaultResolver is one
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Normalize trims an address, checks that it is a plain address such as name@example.com
+// (no display name, comment or quoted local part) and lowercases its domain. The local
+// part is kept as given, as only the receiving server may treat its case as insignificant.
+func Normalize(address string) (string, error) {
+	trimmed := strings.TrimSpace(address)
+	invalid := i18n.NewError("invalid_email", "email", trimmed)
+
+	parsed, err := mail.ParseAddress(trimmed)
+	if err != nil || parsed.Name != "" || parsed.Address != trimmed || len(trimmed) > maxLength {
+		return "", invalid
+	}
+
+	at := strings.LastIndex(trimmed, "@")
+	local, domain := trimmed[:at], strings.ToLower(trimmed[at+1:])
+	// A domain without a dot, such as localhost, cannot be reached from elsewhere
+	if len(local) > maxLocalLength || !strings.Contains(strings.Trim(domain, "."), ".") {
+		return "", invalid
+	}
+
+	return local + "@" + domain, nil
+}
+
+// Verify normalizes an address and checks that its domain receives mail: it has MX records
+// or, lacking them, an address to deliver to directly (RFC 5321). A null MX (RFC 7505)
+// refuses mail. Lookups that fail for another reason than a missing domain let the address
+// through, so a DNS outage does not block signups.
+func Verify(ctx context.Context, resolver Resolver, address string) (string, error) {
+	normalized, err := Normalize(address)
+	if err != nil {
+		return "", err
+	}
+	domain := normalized[strings.LastIndex(normalized, "@")+1:]
+	undeliverable := i18n.NewError("email_domain_undeliverable", "domain", domain)
+
+	records, err := resolver.LookupMX(ctx, domain)
+	if err == nil && len(records) > 0 {
+		if len(records) == 1 && records[0].Host == "." {
+			return "", undeliverable
+		}
+		return normalized, nil
+	}
+	if err != nil && !isNotFound(err) {
+		log.Printf("⚠️ Warning: failed to look up the mail servers of %s: %v", domain, err)
+		return normalized, nil
+	}
+
+	if _, err := resolver.LookupHost(ctx, domain); err != nil {
+		if isNotFound(err) {
+			return "", undeliverable
+		}
+		log.Printf("⚠️ Warning: failed to look up %s: %v", domain, err)
+	}
+	return normalized, nil
+}
+
+// Helper function to tell whether a lookup failed because the name has no such records
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
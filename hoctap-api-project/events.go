@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"hoctap-api/broker"
+	"hoctap-api/config"
+	"hoctap-api/database"
+	"hoctap-api/outbox"
+	"hoctap-api/plugins"
+	"hoctap-api/realtime"
+	"hoctap-api/webhooks"
+)
+
+// Global hub for live dashboard updates
+var liveHub *realtime.Hub
+
+// Global outbox repository and the relay publishing the user events recorded in it; nil in
+// mock mode
+var (
+	outboxRepo  *database.OutboxRepository
+	outboxRelay *outbox.Relay
+)
+
+// eventBroker publishes the user events to the message broker; nil when BROKER_BACKEND is
+// empty and in mock mode
+var eventBroker *broker.Events
+
+// Helper function to create the event publisher of the broker selected by BROKER_BACKEND,
+// or nil when there is none
+func newEventBroker(cfg config.BrokerConfig) (*broker.Events, error) {
+	var publisher broker.Publisher
+	var err error
+	switch cfg.Backend {
+	case "nats":
+		publisher, err = broker.NewNATS(cfg.URL)
+	case "kafka":
+		publisher, err = broker.NewKafka(cfg.URL)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("📣 Publishing user events to %s (topics %s)", cfg.Backend, cfg.Topic)
+	return broker.NewEvents(publisher, cfg.Topic), nil
+}
+
+// publishUserEvent fans a user lifecycle event out to live dashboard clients, followed by a
+// refreshed stats snapshot, and wakes the outbox relay publishing it to webhooks and plugins.
+// New users are also welcomed by notification.
+func publishUserEvent(event string, data interface{}) {
+	if outboxRelay != nil {
+		outboxRelay.Wake()
+	} else {
+		// Mock mode has no outbox and no webhooks
+		for _, err := range plugins.Publish(event, data) {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+	}
+	liveHub.Publish(realtime.TopicUsers, event, data)
+
+	if user, ok := data.(*database.User); ok && event == webhooks.EventUserCreated {
+		notifyWelcome(user)
+	}
+
+	if liveHub.ClientCount() == 0 {
+		return
+	}
+
+	count, err := userRepo.GetUsersCount(context.Background())
+	if err != nil {
+		log.Printf("⚠️ Warning: failed to refresh live stats: %v", err)
+		return
+	}
+	liveHub.Publish(realtime.TopicStats, "stats.updated", map[string]interface{}{"total_users": count})
+}
+
+// publishProfileEvent publishes a user event like publishUserEvent and also notifies the user
+// when the user API (REST, GraphQL or gRPC) updated their name or email address. Avatar and
+// legal hold changes are published without a notification.
+func publishProfileEvent(event string, data interface{}) {
+	publishUserEvent(event, data)
+
+	if user, ok := data.(*database.User); ok && event == webhooks.EventUserUpdated {
+		notifyProfileUpdated(user)
+	}
+}
+
+// publishToBroker publishes a user event taken from the outbox to the message broker
+func publishToBroker(ctx context.Context, event database.OutboxEvent) error {
+	return eventBroker.Publish(ctx, event)
+}
+
+// publishToWebhooks publishes a user event taken from the outbox to webhooks, then plugins.
+// A failure to queue the webhook deliveries leaves the event in the outbox to retry.
+func publishToWebhooks(ctx context.Context, event database.OutboxEvent) error {
+	if err := webhookDispatcher.Publish(ctx, event); err != nil {
+		return err
+	}
+
+	// Plugins get the data the event was recorded with
+	var data interface{}
+	switch event.Event {
+	case webhooks.EventUserCreated, webhooks.EventUserUpdated:
+		var user database.User
+		if err := json.Unmarshal(event.Payload, &user); err != nil {
+			return fmt.Errorf("invalid %s event %s: %v", event.Event, event.EventID, err)
+		}
+		data = &user
+	default:
+		var deleted struct {
+			ID int `json:"id"`
+		}
+		if err := json.Unmarshal(event.Payload, &deleted); err != nil {
+			return fmt.Errorf("invalid %s event %s: %v", event.Event, event.EventID, err)
+		}
+		data = map[string]interface{}{"id": deleted.ID}
+	}
+
+	for _, err := range plugins.Publish(event.Event, data) {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+	return nil
+}
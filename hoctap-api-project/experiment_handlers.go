@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"hoctap-api/database"
+	"hoctap-api/experiments"
+	"hoctap-api/i18n"
+)
+
+// Global experiment service and exposure repository
+var (
+	experimentService *experiments.Service
+	experimentRepo    *database.ExperimentRepository
+)
+
+// Get a user's experiment assignments, logging an exposure for each
+func getUserExperimentsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := userRepo.GetUserByID(r.Context(), userID); err != nil {
+		sendUserLookupError(w, userID, err)
+		return
+	}
+
+	assignments := experimentService.Assignments(userID)
+	for _, a := range assignments {
+		if err := experimentRepo.RecordExposure(r.Context(), userID, a.Experiment, a.Variant); err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("experiment_assignments_retrieved"), assignments)
+}
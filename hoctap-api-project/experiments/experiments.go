@@ -0,0 +1,111 @@
+package experiments
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// Variant is one arm of an experiment with a relative traffic weight
+type Variant struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// Experiment describes how users are split across variants
+type Experiment struct {
+	Name     string    `json:"name"`
+	Active   bool      `json:"active"`
+	Variants []Variant `json:"variants"`
+}
+
+// Assignment is the variant a user is bucketed into for an experiment
+type Assignment struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+}
+
+// Service assigns users to experiment variants deterministically
+type Service struct {
+	experiments []Experiment
+}
+
+// NewService creates a service from experiment definitions, validating their weights
+func NewService(definitions []Experiment) (*Service, error) {
+	for _, e := range definitions {
+		if e.Name == "" {
+			return nil, fmt.Errorf("experiment name is required")
+		}
+		total := 0
+		for _, v := range e.Variants {
+			if v.Name == "" || v.Weight < 0 {
+				return nil, fmt.Errorf("experiment '%s' has an invalid variant", e.Name)
+			}
+			total += v.Weight
+		}
+		if total == 0 {
+			return nil, fmt.Errorf("experiment '%s' needs at least one variant with positive weight", e.Name)
+		}
+	}
+
+	sorted := append([]Experiment(nil), definitions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	return &Service{experiments: sorted}, nil
+}
+
+// LoadFile reads experiment definitions from a JSON array file.
+// A missing file yields a service with no experiments.
+func LoadFile(path string) (*Service, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return NewService(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read experiments file: %v", err)
+	}
+
+	var definitions []Experiment
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		return nil, fmt.Errorf("failed to parse experiments file: %v", err)
+	}
+
+	return NewService(definitions)
+}
+
+// Assignments returns the user's variant in every active experiment
+func (s *Service) Assignments(userID int) []Assignment {
+	assignments := []Assignment{}
+	for _, e := range s.experiments {
+		if !e.Active {
+			continue
+		}
+		assignments = append(assignments, Assignment{Experiment: e.Name, Variant: Bucket(e, userID)})
+	}
+	return assignments
+}
+
+// Bucket picks the variant for a user by hashing the user ID with the experiment name,
+// so the same user always lands in the same variant while experiments stay independent
+func Bucket(e Experiment, userID int) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s:%d", e.Name, userID)))
+	point := binary.BigEndian.Uint64(sum[:8])
+
+	total := 0
+	for _, v := range e.Variants {
+		total += v.Weight
+	}
+
+	slot := int(point % uint64(total))
+	for _, v := range e.Variants {
+		if slot < v.Weight {
+			return v.Name
+		}
+		slot -= v.Weight
+	}
+
+	return e.Variants[len(e.Variants)-1].Name
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// switchoverInput is the optional request body of a controlled switchover
+type switchoverInput struct {
+	Reason string `json:"reason"`
+}
+
+// Get the database failover state: active server, fencing and last health checks
+func getFailoverStatusHandler(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, http.StatusOK, i18n.M("failover_status_retrieved"), database.GetFailoverStatus())
+}
+
+// Switch the active database to the standby, which must already be promoted
+func switchoverHandler(w http.ResponseWriter, r *http.Request) {
+	var input switchoverInput
+	if r.ContentLength > 0 {
+		if err := decodeRequestBody(r, &input); err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+			return
+		}
+	}
+
+	reason := "manual switchover"
+	if input.Reason != "" {
+		reason = "manual switchover: " + input.Reason
+	}
+	reason += " (by " + requestActor(r).Name + ")"
+
+	status, err := database.Switchover(reason)
+	if err == database.ErrFailoverDisabled {
+		sendJSONResponse(w, http.StatusNotImplemented, i18n.M("failover_disabled"), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error switching database: %v", err)
+		sendJSONResponse(w, http.StatusConflict, i18n.M("switchover_refused", "error", err), status)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("switchover_completed"), status)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// sparseUser is a user reduced to the fields requested with ?fields=
+type sparseUser map[string]interface{}
+
+// Helper function to read the sparse fieldset of a user request from ?fields=id,name (or the
+// JSON:API form ?fields[users]=). It returns nil when every field is wanted.
+func requestedUserFields(r *http.Request) ([]string, error) {
+	query := r.URL.Query()
+	value := query.Get("fields")
+	if value == "" {
+		value = query.Get("fields[users]")
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !database.IsUserField(name) {
+			return nil, i18n.NewError("invalid_fields", "field", name)
+		}
+		fields = append(fields, name)
+	}
+	return fields, nil
+}
+
+// Helper function to reduce users to fields for the response. The ID and UUID are always
+// kept so clients (and JSON:API resource objects) can still identify each user; without
+// fields the users are returned unchanged.
+func sparseUsers(users []database.User, fields []string) interface{} {
+	if len(fields) == 0 {
+		return users
+	}
+
+	sparse := make([]sparseUser, 0, len(users))
+	for _, user := range users {
+		sparse = append(sparse, sparseUserOf(user, fields))
+	}
+	return sparse
+}
+
+// Helper function to reduce a single user to fields
+func sparseUserOf(user database.User, fields []string) sparseUser {
+	raw, _ := json.Marshal(user)
+	var all map[string]interface{}
+	json.Unmarshal(raw, &all)
+
+	sparse := sparseUser{"id": all["id"], "uuid": all["uuid"]}
+	for _, name := range fields {
+		if value, ok := all[name]; ok {
+			sparse[name] = value
+		}
+	}
+	return sparse
+}
+
+// Helper function to add a field to a fieldset unless every field is already selected
+func withField(fields []string, name string) []string {
+	if len(fields) == 0 || slices.Contains(fields, name) {
+		return fields
+	}
+	return append(slices.Clip(fields), name)
+}
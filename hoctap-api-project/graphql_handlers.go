@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/webhooks"
+
+	"github.com/graphql-go/graphql"
+)
+
+// GraphQL pagination limits
+const (
+	graphQLDefaultLimit = 20
+	graphQLMaxLimit     = 100
+)
+
+// actorContextKey carries the audit actor of a GraphQL request into resolvers
+type actorContextKey struct{}
+
+// Global GraphQL schema, built once at startup
+var graphQLSchema graphql.Schema
+
+// graphQLUserType exposes the User model with camelCase field names
+var graphQLUserType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":    &graphql.Field{Type: graphql.NewNonNull(graphql.Int), DeprecationReason: "Use uuid"},
+		"uuid":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"name":  &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"email": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"avatarUrl": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if url := p.Source.(database.User).AvatarURL; url != "" {
+					return url, nil
+				}
+				return nil, nil
+			},
+		},
+		"createdAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(database.User).CreatedAt, nil
+			},
+		},
+		"updatedAt": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.DateTime),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(database.User).UpdatedAt, nil
+			},
+		},
+	},
+})
+
+// graphQLUserConnectionType is a page of users with pagination metadata
+var graphQLUserConnectionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "UserConnection",
+	Fields: graphql.Fields{
+		"items":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphQLUserType)))},
+		"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"limit":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"offset":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"hasMore":    &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+	},
+})
+
+// Build the GraphQL schema for users
+func newGraphQLSchema() (graphql.Schema, error) {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: graphQLUserType,
+				Args: graphQLUserIDArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphQLUserID(p)
+					// A missing user resolves to null rather than an error
+					if errors.Is(err, database.ErrUserNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					user, err := userRepo.GetUserByID(p.Context, id)
+					if errors.Is(err, database.ErrUserNotFound) {
+						return nil, nil
+					}
+					if err != nil {
+						return nil, err
+					}
+					return *user, nil
+				},
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(graphQLUserConnectionType),
+				Args: graphql.FieldConfigArgument{
+					"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: graphQLDefaultLimit},
+					"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+					"search": &graphql.ArgumentConfig{Type: graphql.String, Description: "Matches name or email"},
+				},
+				Resolve: resolveGraphQLUsers,
+			},
+			"usersCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return userRepo.GetUsersCount(p.Context)
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createUser": &graphql.Field{
+				Type: graphQLUserType,
+				Args: graphql.FieldConfigArgument{
+					"name":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"email": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name, email := p.Args["name"].(string), p.Args["email"].(string)
+					if name == "" || email == "" {
+						return nil, errors.New("name and email are required")
+					}
+					email, err := checkUserEmail(p.Context, email)
+					if err != nil {
+						return nil, err
+					}
+					user, err := graphQLUserRepo(p.Context).CreateUser(p.Context, name, email)
+					if err != nil {
+						return nil, err
+					}
+					publishUserEvent(webhooks.EventUserCreated, user)
+					return *user, nil
+				},
+			},
+			"updateUser": &graphql.Field{
+				Type: graphQLUserType,
+				Args: withGraphQLUserIDArgs(graphql.FieldConfigArgument{
+					"name":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"email": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				}),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name, email := p.Args["name"].(string), p.Args["email"].(string)
+					if name == "" || email == "" {
+						return nil, errors.New("name and email are required")
+					}
+					email, err := checkUserEmail(p.Context, email)
+					if err != nil {
+						return nil, err
+					}
+					id, err := graphQLUserID(p)
+					if err != nil {
+						return nil, err
+					}
+					user, err := graphQLUserRepo(p.Context).UpdateUser(p.Context, id, name, email)
+					if err != nil {
+						return nil, err
+					}
+					publishProfileEvent(webhooks.EventUserUpdated, user)
+					return *user, nil
+				},
+			},
+			"deleteUser": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphQLUserIDArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := graphQLUserID(p)
+					if err != nil {
+						return false, err
+					}
+					if err := graphQLUserRepo(p.Context).DeleteUser(p.Context, id); err != nil {
+						return false, err
+					}
+					publishUserEvent(webhooks.EventUserDeleted, map[string]interface{}{"id": id})
+					return true, nil
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// Helper function to build the arguments identifying a user: uuid, or the deprecated id
+func graphQLUserIDArgs() graphql.FieldConfigArgument {
+	return withGraphQLUserIDArgs(graphql.FieldConfigArgument{})
+}
+
+// Helper function to add the arguments identifying a user to args
+func withGraphQLUserIDArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args["uuid"] = &graphql.ArgumentConfig{Type: graphql.String}
+	args["id"] = &graphql.ArgumentConfig{Type: graphql.Int, Description: "Deprecated: use uuid"}
+	return args
+}
+
+// Helper function to resolve the uuid or id argument to the user's numeric ID
+func graphQLUserID(p graphql.ResolveParams) (int, error) {
+	if uuid, ok := p.Args["uuid"].(string); ok {
+		if !database.IsUUID(uuid) {
+			return 0, errors.New("uuid must be a UUID")
+		}
+		return userRepo.GetUserIDByUUID(p.Context, uuid)
+	}
+	if id, ok := p.Args["id"].(int); ok {
+		return id, nil
+	}
+	return 0, errors.New("uuid or id is required")
+}
+
+// Resolve a paginated, optionally filtered page of users
+func resolveGraphQLUsers(p graphql.ResolveParams) (interface{}, error) {
+	limit, _ := p.Args["limit"].(int)
+	offset, _ := p.Args["offset"].(int)
+	search, _ := p.Args["search"].(string)
+
+	if limit <= 0 || limit > graphQLMaxLimit {
+		return nil, errors.New("limit must be between 1 and 100")
+	}
+	if offset < 0 {
+		return nil, errors.New("offset must not be negative")
+	}
+
+	filter := database.UserFilter{Search: search, Limit: limit, Offset: offset}
+
+	users, err := userRepo.ListUsers(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := userRepo.CountUsers(p.Context, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"items":      users,
+		"totalCount": total,
+		"limit":      limit,
+		"offset":     offset,
+		"hasMore":    offset+len(users) < total,
+	}, nil
+}
+
+// Helper function to get a user repository attributed to the GraphQL request's actor
+func graphQLUserRepo(ctx context.Context) database.UserStore {
+	actor, _ := ctx.Value(actorContextKey{}).(database.AuditActor)
+	return userRepo.WithActor(actor)
+}
+
+// Execute GraphQL queries and mutations sent as POST JSON or GET ?query=
+func graphQLHandler(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Query         string                 `json:"query"`
+		OperationName string                 `json:"operationName"`
+		Variables     map[string]interface{} `json:"variables"`
+	}
+
+	if r.Method == http.MethodGet {
+		params.Query = r.URL.Query().Get("query")
+		params.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &params.Variables); err != nil {
+				sendJSONResponse(w, http.StatusBadRequest, i18n.M("graphql_invalid_variables"), nil)
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_json"), nil)
+		return
+	}
+
+	if params.Query == "" {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("graphql_query_required"), nil)
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         graphQLSchema,
+		RequestString:  params.Query,
+		OperationName:  params.OperationName,
+		VariableValues: params.Variables,
+		Context:        context.WithValue(r.Context(), actorContextKey{}, requestActor(r)),
+	})
+
+	// GraphQL responses use the spec's {data, errors} shape rather than the REST envelope
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
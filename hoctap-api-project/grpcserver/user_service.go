@@ -0,0 +1,189 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"hoctap-api/database"
+	userv1 "hoctap-api/proto/user/v1"
+	"hoctap-api/rules"
+	"hoctap-api/webhooks"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// List pagination limits
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+// PublishFunc fans out a user lifecycle event, as done for the REST handlers
+type PublishFunc func(event string, data interface{})
+
+// EmailCheckFunc normalizes and validates the email of a user being written, as done for
+// the REST handlers
+type EmailCheckFunc func(ctx context.Context, email string) (string, error)
+
+// UserService implements the gRPC UserService on top of the user repository
+type UserService struct {
+	userv1.UnimplementedUserServiceServer
+
+	repo       database.UserStore
+	publish    PublishFunc
+	checkEmail EmailCheckFunc
+}
+
+// NewServer creates a gRPC server with the UserService registered
+func NewServer(repo database.UserStore, publish PublishFunc, checkEmail EmailCheckFunc) *grpc.Server {
+	server := grpc.NewServer()
+	userv1.RegisterUserServiceServer(server, &UserService{repo: repo, publish: publish, checkEmail: checkEmail})
+	return server
+}
+
+// GetUser returns a single user by ID
+func (s *UserService) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.GetUserResponse, error) {
+	user, err := s.repo.GetUserByID(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &userv1.GetUserResponse{User: toProto(user)}, nil
+}
+
+// ListUsers returns a page of users, optionally filtered by name or email
+func (s *UserService) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	if limit < 0 || limit > maxListLimit {
+		return nil, status.Errorf(codes.InvalidArgument, "limit must be between 1 and %d", maxListLimit)
+	}
+	if req.GetOffset() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "offset must not be negative")
+	}
+
+	filter := database.UserFilter{Search: req.GetSearch(), Limit: limit, Offset: int(req.GetOffset())}
+
+	users, err := s.repo.ListUsers(ctx, filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to retrieve users")
+	}
+
+	total, err := s.repo.CountUsers(ctx, filter)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to count users")
+	}
+
+	resp := &userv1.ListUsersResponse{Users: make([]*userv1.User, 0, len(users)), TotalCount: int32(total)}
+	for i := range users {
+		resp.Users = append(resp.Users, toProto(&users[i]))
+	}
+
+	return resp, nil
+}
+
+// CreateUser creates a new user
+func (s *UserService) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.CreateUserResponse, error) {
+	if req.GetName() == "" || req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and email are required")
+	}
+	email, err := s.checkEmail(ctx, req.GetEmail())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user, err := s.repo.WithActor(actorFromContext(ctx)).CreateUser(ctx, req.GetName(), email)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	s.publish(webhooks.EventUserCreated, user)
+	return &userv1.CreateUserResponse{User: toProto(user)}, nil
+}
+
+// UpdateUser replaces a user's name and email
+func (s *UserService) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.UpdateUserResponse, error) {
+	if req.GetName() == "" || req.GetEmail() == "" {
+		return nil, status.Error(codes.InvalidArgument, "name and email are required")
+	}
+	email, err := s.checkEmail(ctx, req.GetEmail())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user, err := s.repo.WithActor(actorFromContext(ctx)).UpdateUser(ctx, int(req.GetId()), req.GetName(), email)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	s.publish(webhooks.EventUserUpdated, user)
+	return &userv1.UpdateUserResponse{User: toProto(user)}, nil
+}
+
+// DeleteUser deletes a user by ID
+func (s *UserService) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*userv1.DeleteUserResponse, error) {
+	id := int(req.GetId())
+	if err := s.repo.WithActor(actorFromContext(ctx)).DeleteUser(ctx, id); err != nil {
+		return nil, toStatus(err)
+	}
+
+	s.publish(webhooks.EventUserDeleted, map[string]interface{}{"id": id})
+	return &userv1.DeleteUserResponse{}, nil
+}
+
+// Helper function to convert a database user to its protobuf message
+func toProto(user *database.User) *userv1.User {
+	return &userv1.User{
+		Id:        int32(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
+
+// Helper function to map repository errors to gRPC status codes
+func toStatus(err error) error {
+	if errors.As(err, new(*rules.Violation)) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if errors.Is(err, database.ErrEmailExists) {
+		return status.Error(codes.AlreadyExists, err.Error())
+	}
+
+	if !database.Available() {
+		return status.Error(codes.Unavailable, database.ErrDatabaseUnavailable.Error())
+	}
+
+	switch {
+	case errors.Is(err, database.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, database.ErrUserUnderLegalHold):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Error(codes.Internal, "internal error")
+	}
+}
+
+// Helper function to build the audit actor from the peer address. The gRPC service has no
+// caller authentication, so every change is attributed to grpc rather than to a name the
+// caller asserts.
+func actorFromContext(ctx context.Context) database.AuditActor {
+	actor := database.AuditActor{Name: "grpc"}
+
+	if p, ok := peer.FromContext(ctx); ok {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+			actor.IP = host
+		}
+	}
+
+	return actor
+}
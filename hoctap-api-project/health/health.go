@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// checkTimeout bounds a single dependency check
+const checkTimeout = 2 * time.Second
+
+// Overall states of a Report
+const (
+	StatusHealthy   = "healthy"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+)
+
+// Check tells whether a dependency answers
+type Check func(ctx context.Context) error
+
+// Dependency is the last known state of one dependency
+type Dependency struct {
+	Name                string     `json:"name"`
+	Critical            bool       `json:"critical"`
+	Status              string     `json:"status"`
+	LatencyMs           float64    `json:"latency_ms"`
+	Error               string     `json:"error,omitempty"`
+	LastSuccess         *time.Time `json:"last_success"`
+	LastFailure         *time.Time `json:"last_failure,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+}
+
+// Report is the state of every dependency at CheckedAt. The status is unhealthy when a
+// critical dependency is down and degraded when only optional ones are.
+type Report struct {
+	Status       string       `json:"status"`
+	CheckedAt    time.Time    `json:"checked_at"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// dependency is a registered check with its history
+type dependency struct {
+	check Check
+	state Dependency
+}
+
+// Monitor checks the registered dependencies on demand. Reports are cached for a few
+// seconds, so frequent health requests do not themselves load the dependencies.
+type Monitor struct {
+	cacheTTL time.Duration
+
+	mu           sync.Mutex
+	dependencies []*dependency
+	last         *Report
+}
+
+// NewMonitor creates a monitor reusing a report for cacheTTL
+func NewMonitor(cacheTTL time.Duration) *Monitor {
+	return &Monitor{cacheTTL: cacheTTL}
+}
+
+// Register adds a dependency. A critical dependency being down makes the service unhealthy;
+// other ones only degrade it.
+func (m *Monitor) Register(name string, critical bool, check Check) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dependencies = append(m.dependencies, &dependency{
+		check: check,
+		state: Dependency{Name: name, Critical: critical, Status: "unknown"},
+	})
+	m.last = nil
+}
+
+// Report returns the state of every dependency, checking them concurrently unless the
+// last report is recent enough
+func (m *Monitor) Report(ctx context.Context) Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.last != nil && time.Since(m.last.CheckedAt) < m.cacheTTL {
+		return *m.last
+	}
+
+	var wg sync.WaitGroup
+	for _, dep := range m.dependencies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dep.run(context.WithoutCancel(ctx))
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusHealthy, CheckedAt: time.Now(), Dependencies: make([]Dependency, 0, len(m.dependencies))}
+	for _, dep := range m.dependencies {
+		if dep.state.Status != "up" {
+			if dep.state.Critical {
+				report.Status = StatusUnhealthy
+			} else if report.Status == StatusHealthy {
+				report.Status = StatusDegraded
+			}
+		}
+		report.Dependencies = append(report.Dependencies, dep.state)
+	}
+
+	m.last = &report
+	return report
+}
+
+// Helper function to check a dependency and record the outcome
+func (d *dependency) run(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := d.check(ctx)
+	now := time.Now()
+
+	d.state.LatencyMs = float64(now.Sub(start).Microseconds()) / 1000
+	if err != nil {
+		d.state.Status = "down"
+		d.state.Error = err.Error()
+		d.state.LastFailure = &now
+		d.state.ConsecutiveFailures++
+		return
+	}
+
+	d.state.Status = "up"
+	d.state.Error = ""
+	d.state.LastSuccess = &now
+	d.state.ConsecutiveFailures = 0
+}
@@ -0,0 +1,204 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLanguage is the language of the messages in the code, and of the responses to
+// clients accepting no supported language
+const DefaultLanguage = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// placeholder matches the {name} parts of a message, filled in from the message's arguments
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+// Message is a message sent to clients: the code of a catalog message, a stable
+// machine-readable name that is the same in every language, and the values of its
+// placeholders by name. A message without a code is sent as its Text, untranslated, such as
+// the message a validation rule script chose.
+type Message struct {
+	Code string
+	Args map[string]string
+	Text string
+}
+
+// M returns the catalog message with code, filling its placeholders from pairs of names and
+// values, as in M("user_id_not_found", "id", 7)
+func M(code string, args ...interface{}) Message {
+	m := Message{Code: code}
+	if len(args) > 0 {
+		m.Args = make(map[string]string, len(args)/2)
+		for i := 0; i+1 < len(args); i += 2 {
+			m.Args[fmt.Sprint(args[i])] = fmt.Sprint(args[i+1])
+		}
+	}
+	return m
+}
+
+// Text returns a message sent as it is, in every language
+func Text(text string) Message {
+	return Message{Text: text}
+}
+
+// Error is an error reported to clients with a catalog message, for the errors of packages
+// below the handlers that are sent as they are, such as invalid input. Error returns the
+// English message.
+type Error struct {
+	Message Message
+}
+
+// NewError returns an Error with the catalog message M(code, args...)
+func NewError(code string, args ...interface{}) *Error {
+	return &Error{Message: M(code, args...)}
+}
+
+func (e *Error) Error() string {
+	return englishCatalog().Localize(e.Message, DefaultLanguage)
+}
+
+// MessageOf returns the message of err when it is an Error, and its text untranslated otherwise
+func MessageOf(err error) Message {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return Text(err.Error())
+}
+
+// englishCatalog is the catalog the English text of an Error is read from
+var englishCatalog = sync.OnceValue(MustLoad)
+
+// Catalog holds the translations of the API messages, by code. Placeholders such as {id}
+// stand for the parts of a message that vary.
+type Catalog struct {
+	languages []string
+	messages  map[string]map[string]string
+}
+
+// Load reads the catalogs embedded from locales/<language>.json. Every language must
+// translate every message of the English catalog with the same placeholders.
+func Load() (*Catalog, error) {
+	files, err := localeFiles.ReadDir("locales")
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Catalog{messages: make(map[string]map[string]string)}
+	for _, file := range files {
+		data, err := localeFiles.ReadFile("locales/" + file.Name())
+		if err != nil {
+			return nil, err
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return nil, fmt.Errorf("invalid message catalog %s: %v", file.Name(), err)
+		}
+		language := strings.TrimSuffix(file.Name(), path.Ext(file.Name()))
+		c.messages[language] = messages
+		c.languages = append(c.languages, language)
+	}
+
+	english, ok := c.messages[DefaultLanguage]
+	if !ok {
+		return nil, fmt.Errorf("missing message catalog %s.json", DefaultLanguage)
+	}
+	for language, messages := range c.messages {
+		for code, message := range english {
+			translation, ok := messages[code]
+			if !ok {
+				return nil, fmt.Errorf("message catalog %s.json has no translation of %s", language, code)
+			}
+			if !samePlaceholders(message, translation) {
+				return nil, fmt.Errorf("message catalog %s.json: %s must use the placeholders of the English message", language, code)
+			}
+		}
+	}
+
+	sort.Strings(c.languages)
+	return c, nil
+}
+
+// MustLoad is like Load but panics when a catalog is invalid
+func MustLoad() *Catalog {
+	c, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Languages returns the supported languages
+func (c *Catalog) Languages() []string {
+	return c.languages
+}
+
+// Negotiate picks the supported language a client prefers from an Accept-Language header
+// such as "vi-VN,vi;q=0.9,en;q=0.8", matching on the primary subtag
+func (c *Catalog) Negotiate(acceptLanguage string) string {
+	best, bestQuality := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			value, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = value
+		}
+
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if primary == "*" {
+			primary = DefaultLanguage
+		}
+		if _, ok := c.messages[primary]; ok && quality > bestQuality {
+			best, bestQuality = primary, quality
+		}
+	}
+	return best
+}
+
+// Localize returns the text of a message in language, falling back to English for a language
+// without the catalog, and to the code for a code missing from the catalog
+func (c *Catalog) Localize(m Message, language string) string {
+	if m.Code == "" {
+		return m.Text
+	}
+
+	translation, ok := c.messages[language][m.Code]
+	if !ok {
+		translation, ok = c.messages[DefaultLanguage][m.Code]
+	}
+	if !ok {
+		return m.Code
+	}
+	if len(m.Args) == 0 {
+		return translation
+	}
+	return placeholder.ReplaceAllStringFunc(translation, func(name string) string {
+		if value, ok := m.Args[strings.Trim(name, "{}")]; ok {
+			return value
+		}
+		return name
+	})
+}
+
+// Helper function to tell whether two messages use the same placeholders
+func samePlaceholders(a, b string) bool {
+	namesA := placeholder.FindAllString(a, -1)
+	namesB := placeholder.FindAllString(b, -1)
+	sort.Strings(namesA)
+	sort.Strings(namesB)
+	return strings.Join(namesA, ",") == strings.Join(namesB, ",")
+}
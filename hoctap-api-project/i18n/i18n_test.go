@@ -0,0 +1,121 @@
+package i18n
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	c := MustLoad()
+
+	tests := []struct {
+		name     string
+		message  Message
+		language string
+		want     string
+	}{
+		{"english", M("user_not_found"), "en", "User not found"},
+		{"translated", M("user_not_found"), "vi", "Không tìm thấy người dùng"},
+		{"placeholders", M("user_id_not_found", "id", 7), "en", "user with ID 7 not found"},
+		{"unsupported language", M("user_id_not_found", "id", 7), "fr", "user with ID 7 not found"},
+		{"text", Text("script says no"), "vi", "script says no"},
+		{"unknown code", M("no_such_message"), "en", "no_such_message"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Localize(tt.message, tt.language); got != tt.want {
+				t.Errorf("Localize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	err := NewError("invalid_date", "date", "2024-13-01")
+	if got, want := err.Error(), "invalid date '2024-13-01', expected YYYY-MM-DD"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+	if got := MessageOf(err); got.Code != "invalid_date" {
+		t.Errorf("MessageOf() code = %q, want invalid_date", got.Code)
+	}
+}
+
+// TestMessageCodes checks that every code the code base passes to M and NewError is in the
+// catalog, with arguments for exactly the placeholders of its message
+func TestMessageCodes(t *testing.T) {
+	english := MustLoad().messages[DefaultLanguage]
+	fset := token.NewFileSet()
+	calls := 0
+
+	err := filepath.WalkDir("..", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (d.Name() == "proto" || d.Name() == "mocks") {
+			return filepath.SkipDir
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			selector, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || (selector.Sel.Name != "M" && selector.Sel.Name != "NewError") {
+				return true
+			}
+			if pkg, ok := selector.X.(*ast.Ident); !ok || pkg.Name != "i18n" {
+				return true
+			}
+			literal, ok := call.Args[0].(*ast.BasicLit)
+			if !ok {
+				return true
+			}
+			calls++
+
+			code, _ := strconv.Unquote(literal.Value)
+			message, ok := english[code]
+			if !ok {
+				t.Errorf("%s: unknown message code %s", fset.Position(call.Pos()), code)
+				return true
+			}
+
+			var names []string
+			for i := 1; i < len(call.Args); i += 2 {
+				if name, ok := call.Args[i].(*ast.BasicLit); ok {
+					value, _ := strconv.Unquote(name.Value)
+					names = append(names, "{"+value+"}")
+				}
+			}
+			placeholders := placeholder.FindAllString(message, -1)
+			sort.Strings(names)
+			sort.Strings(placeholders)
+			if strings.Join(names, ",") != strings.Join(placeholders, ",") {
+				t.Errorf("%s: %s takes %v, got %v", fset.Position(call.Pos()), code, placeholders, names)
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls == 0 {
+		t.Fatal("found no message codes")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"hoctap-api/config"
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// maxIdempotencyKeyLength matches the idempotency_keys column
+const maxIdempotencyKeyLength = 255
+
+// Global idempotency key repository (nil in mock mode, where keys are ignored)
+var idempotencyRepo *database.IdempotencyRepository
+
+// idempotencyRecorder passes a response through while keeping a copy to store for replays
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// Unwrap exposes the underlying writer to content negotiation and http.ResponseController
+func (w *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader records the status code
+func (w *idempotencyRecorder) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write records the body
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+// Middleware making POST requests safe to retry: the response to a request sent with an
+// Idempotency-Key header is stored for API_IDEMPOTENCY_TTL and replayed for repeats of the
+// key by the same client, so a retried create does not create a second user. Server errors
+// are not stored.
+func idempotentPOST(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		if r.Method != http.MethodPost || key == "" || idempotencyRepo == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("idempotency_key_too_long"), nil)
+			return
+		}
+
+		// The body is read before any handler's own limit applies
+		maxBody := config.Current().API.IdempotencyMaxBodyBytes
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, int64(maxBody)))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				sendJSONResponse(w, http.StatusRequestEntityTooLarge, i18n.M("idempotency_body_too_large", "max", maxBody), nil)
+			} else {
+				sendJSONResponse(w, http.StatusBadRequest, i18n.M("request_body_unreadable"), nil)
+			}
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the client, so clients choosing the same key do not collide
+		key = scopedIdempotencyKey(r, key)
+
+		stored, err := idempotencyRepo.Reserve(r.Context(), key, requestHash(r, body))
+		switch {
+		case errors.Is(err, database.ErrIdempotencyKeyReused):
+			sendJSONResponse(w, http.StatusUnprocessableEntity, i18n.M("idempotency_key_reused"), nil)
+			return
+		case errors.Is(err, database.ErrIdempotencyKeyInUse):
+			w.Header().Set("Retry-After", "1")
+			sendJSONResponse(w, http.StatusConflict, i18n.M("idempotency_key_in_progress"), nil)
+			return
+		case err != nil:
+			log.Printf("Error reserving idempotency key: %v", err)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("idempotency_key_failed"), nil)
+			return
+		case stored != nil:
+			w.Header().Set("Content-Type", stored.ContentType)
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.StatusCode)
+			w.Write(stored.Body)
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+
+		// The request may be cancelled once the response is written; finish the bookkeeping anyway
+		ctx := context.WithoutCancel(r.Context())
+		if recorder.status == 0 || recorder.status >= 500 {
+			if err := idempotencyRepo.Release(ctx, key); err != nil {
+				log.Printf("⚠️ Warning: %v", err)
+			}
+			return
+		}
+
+		response := database.IdempotentResponse{
+			StatusCode:  recorder.status,
+			ContentType: w.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		}
+		if err := idempotencyRepo.Complete(ctx, key, response, config.Current().API.IdempotencyTTL); err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+	})
+}
+
+// Helper function to fingerprint a request, so a key sent again with another request is
+// caught. Accept is included as the stored response is in the negotiated format.
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	io.WriteString(hash, r.Header.Get("Accept")+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Helper function to build the stored form of a client's key: a hash of the client and the
+// key, which fits the idempotency_keys column and keeps API keys out of the table
+func scopedIdempotencyKey(r *http.Request, key string) string {
+	hash := sha256.Sum256([]byte(concurrencyClient(r) + "\n" + key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/jobs"
+
+	"github.com/gorilla/mux"
+)
+
+// Global job repository and queue; nil in mock mode, where webhooks are not delivered and
+// emails are sent by the mailer alone
+var (
+	jobRepo  *database.JobRepository
+	jobQueue *jobs.Queue
+)
+
+// List background jobs, most recently changed first (?status=queued|running|dead, ?kind=
+// and ?limit=, at most 500). Finished jobs are deleted, so the list is the backlog and the
+// dead-letter list.
+func getJobsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := database.JobFilter{Status: query.Get("status"), Kind: query.Get("kind")}
+
+	switch filter.Status {
+	case "", database.JobQueued, database.JobRunning, database.JobDead:
+	default:
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_job_status"), nil)
+		return
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > 500 {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_limit_500"), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	list, err := jobRepo.ListJobs(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error getting jobs: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("jobs_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("jobs_retrieved"), list)
+}
+
+// Count the background jobs of every kind and status
+func getJobStatsHandler(w http.ResponseWriter, r *http.Request) {
+	counts, err := jobRepo.CountJobs(r.Context())
+	if err != nil {
+		log.Printf("Error counting jobs: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("jobs_count_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("job_stats_retrieved"), counts)
+}
+
+// Get a background job with its payload and last error
+func getJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID, ok := jobIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := jobRepo.GetJobByID(r.Context(), jobID)
+	if err != nil {
+		log.Printf("Error getting job: %v", err)
+		if errors.Is(err, database.ErrJobNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("job_not_found", "id", jobID), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("job_retrieve_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("job_retrieved"), job)
+}
+
+// Queue a dead job again with a fresh set of attempts
+func retryJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID, ok := jobIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := jobRepo.RetryJob(r.Context(), jobID)
+	if err != nil {
+		var notDead *database.JobNotDeadError
+		log.Printf("Error retrying job: %v", err)
+		switch {
+		case errors.Is(err, database.ErrJobNotFound):
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("job_not_found", "id", jobID), nil)
+		case errors.As(err, &notDead):
+			sendJSONResponse(w, http.StatusConflict, i18n.M("job_not_dead", "id", jobID, "status", notDead.Status), nil)
+		default:
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("job_retry_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("job_retry_queued"), job)
+}
+
+// Discard a dead job
+func deleteJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID, ok := jobIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := jobRepo.DeleteDeadJob(r.Context(), jobID); err != nil {
+		log.Printf("Error deleting job: %v", err)
+		if errors.Is(err, database.ErrJobNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("dead_job_not_found", "id", jobID), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("job_delete_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("job_deleted"), nil)
+}
+
+// Helper function to read the job ID of the path, answering 400 when it is invalid
+func jobIDParam(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	jobID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_job_id"), nil)
+		return 0, false
+	}
+	return jobID, true
+}
@@ -0,0 +1,228 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"hoctap-api/database"
+)
+
+// maxBackoff caps the delay between attempts of a job
+const maxBackoff = time.Hour
+
+// Handler runs one job. An error retries the job after a backoff until it is out of
+// attempts; an error wrapped with Permanent moves it to the dead-letter list right away.
+// The context expires when the job's lease does.
+type Handler func(ctx context.Context, job *database.Job) error
+
+// Options sets how a kind of job is retried: MaxAttempts in total, waiting Backoff after
+// the first failure and twice as long after every next one
+type Options struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// kind is a registered job kind
+type kind struct {
+	options Options
+	handler Handler
+}
+
+// permanentError marks a failure that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as a failure that retrying cannot fix, such as a rejected recipient
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// Queue runs jobs stored in the database on worker goroutines. Jobs survive restarts and
+// are shared by every instance: each job is claimed by one worker, and a job whose worker
+// died is claimed again once its lease expires.
+type Queue struct {
+	repo   *database.JobRepository
+	lease  time.Duration
+	poll   time.Duration
+	worker string
+
+	mu    sync.RWMutex
+	kinds map[string]kind
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// New creates a queue whose workers look for due jobs every poll interval and may run a
+// job for up to lease before another worker takes it over
+func New(repo *database.JobRepository, lease, poll time.Duration) *Queue {
+	hostname, _ := os.Hostname()
+	return &Queue{
+		repo:   repo,
+		lease:  lease,
+		poll:   poll,
+		worker: fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		kinds:  make(map[string]kind),
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+}
+
+// Register sets the handler and retry options of a kind of job
+func (q *Queue) Register(name string, options Options, handler Handler) {
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = 1
+	}
+
+	q.mu.Lock()
+	q.kinds[name] = kind{options: options, handler: handler}
+	q.mu.Unlock()
+}
+
+// Enqueue stores a job of a registered kind with payload encoded as JSON, due right away
+func (q *Queue) Enqueue(ctx context.Context, name string, payload interface{}) (int64, error) {
+	q.mu.RLock()
+	k, ok := q.kinds[name]
+	q.mu.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("unknown job kind '%s'", name)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode %s job: %v", name, err)
+	}
+
+	id, err := q.repo.EnqueueJob(ctx, name, data, k.options.MaxAttempts, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	// Wake an idle worker instead of waiting for its next poll
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+// Start starts the worker goroutines. Each has its own name, so a job taken over by another
+// goroutine of this process is fenced off from the one whose lease expired.
+func (q *Queue) Start(workers int) {
+	for i := 0; i < workers; i++ {
+		q.wg.Add(1)
+		go q.run(fmt.Sprintf("%s/%d", q.worker, i))
+	}
+}
+
+// Stop stops claiming jobs and waits for the running ones to finish
+func (q *Queue) Stop() {
+	close(q.stop)
+	q.wg.Wait()
+}
+
+// run claims and runs due jobs as worker until the queue is stopped, waiting for a poll
+// interval or a new job whenever none is due
+func (q *Queue) run(worker string) {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.stop:
+			return
+		default:
+		}
+
+		now := time.Now()
+		job, err := q.repo.ClaimJob(context.Background(), worker, now, now.Add(-q.lease))
+		if err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+		if job != nil {
+			q.process(job, worker)
+			continue
+		}
+
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-time.After(q.poll):
+		}
+	}
+}
+
+// process runs a job claimed by worker and records the outcome: done jobs are deleted,
+// failed ones retried with exponential backoff or, out of attempts, moved to the dead-letter
+// list. An outcome is dropped when the lease expired and another worker claimed the job.
+func (q *Queue) process(job *database.Job, worker string) {
+	q.mu.RLock()
+	k, ok := q.kinds[job.Kind]
+	q.mu.RUnlock()
+
+	var err error
+	if ok {
+		err = q.call(k.handler, job)
+	} else {
+		// Possibly enqueued by a newer release; another instance may know the kind
+		err = fmt.Errorf("no handler for job kind '%s'", job.Kind)
+		k.options.Backoff = q.lease
+	}
+
+	ctx := context.Background()
+	if err == nil {
+		if err := q.repo.CompleteJob(ctx, job.ID, worker); err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+		return
+	}
+
+	var permanent permanentError
+	if errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts {
+		log.Printf("Job %d (%s) failed for good after %d attempt(s), moved to the dead-letter list: %v",
+			job.ID, job.Kind, job.Attempts, err)
+		if err := q.repo.BuryJob(ctx, job.ID, worker, err.Error()); err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+		return
+	}
+
+	delay := backoff(k.options.Backoff, job.Attempts)
+	log.Printf("Job %d (%s) failed (attempt %d/%d), retrying in %s: %v",
+		job.ID, job.Kind, job.Attempts, job.MaxAttempts, delay, err)
+	if err := q.repo.RescheduleJob(ctx, job.ID, worker, err.Error(), time.Now().Add(delay)); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+}
+
+// call runs a handler within the lease, turning a panic into an error
+func (q *Queue) call(handler Handler, job *database.Job) (err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), q.lease)
+	defer cancel()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// Helper function to compute the delay after the given failed attempt
+func backoff(initial time.Duration, attempt int) time.Duration {
+	delay := initial
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"hoctap-api/database"
+)
+
+// jsonAPIMediaType is the media type of JSON:API documents
+const jsonAPIMediaType = "application/vnd.api+json"
+
+// jsonAPIResource describes how a model is exposed as a JSON:API resource
+type jsonAPIResource struct {
+	Type    string
+	IDField string
+	// Self is the path of a single resource, with %s standing for its ID ("" when not addressable)
+	Self string
+	// Relationships maps attribute names holding foreign keys to the related resource type
+	Relationships map[string]string
+}
+
+// jsonAPIResources lists the models that are rendered as resource objects.
+// Any other payload (stats, health, results) is returned as top-level meta.
+var jsonAPIResources = map[reflect.Type]jsonAPIResource{
+	reflect.TypeOf(database.User{}):            {Type: "users", IDField: "id", Self: "/api/v1/users/%s"},
+	reflect.TypeOf(sparseUser{}):               {Type: "users", IDField: "id", Self: "/api/v1/users/%s"},
+	reflect.TypeOf(database.Announcement{}):    {Type: "announcements", IDField: "id"},
+	reflect.TypeOf(database.Webhook{}):         {Type: "webhooks", IDField: "id"},
+	reflect.TypeOf(database.WebhookDelivery{}): {Type: "webhook-deliveries", IDField: "id", Relationships: map[string]string{"webhook_id": "webhooks"}},
+	reflect.TypeOf(database.ShortLink{}):       {Type: "short-links", IDField: "id"},
+	reflect.TypeOf(database.AuditEntry{}):      {Type: "audit-entries", IDField: "id"},
+	reflect.TypeOf(database.RetentionPolicy{}): {Type: "retention-policies", IDField: "entity", Self: "/api/v1/retention/policies/%s"},
+	reflect.TypeOf(database.ValidationRule{}):  {Type: "validation-rules", IDField: "id"},
+	reflect.TypeOf(database.Notification{}):    {Type: "notifications", IDField: "id", Relationships: map[string]string{"user_id": "users"}},
+	reflect.TypeOf(database.Job{}):             {Type: "jobs", IDField: "id", Self: "/api/v1/jobs/%s"},
+}
+
+// Helper function to write a response envelope as a JSON:API document
+func writeJSONAPIResponse(w http.ResponseWriter, statusCode int, response Response, self string) {
+	document := map[string]interface{}{
+		"jsonapi": map[string]interface{}{"version": "1.1"},
+		"links":   map[string]interface{}{"self": self},
+	}
+	meta := map[string]interface{}{"message": response.Message, "timestamp": response.Timestamp}
+	for key, value := range response.Meta {
+		meta[key] = value
+	}
+
+	if statusCode >= 400 {
+		apiError := map[string]interface{}{
+			"status": strconv.Itoa(statusCode),
+			"title":  http.StatusText(statusCode),
+			"detail": response.Message,
+		}
+		if response.Code != "" {
+			apiError["code"] = response.Code
+		}
+		document["errors"] = []map[string]interface{}{apiError}
+	} else if response.Data == nil {
+		document["data"] = nil
+	} else if data, ok := jsonAPIData(response.Data); ok {
+		document["data"] = data
+	} else {
+		meta["data"] = response.Data
+	}
+	document["meta"] = meta
+
+	json.NewEncoder(w).Encode(document)
+}
+
+// Helper function to convert a model or slice of models into resource objects
+func jsonAPIData(data interface{}) (interface{}, bool) {
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if value.Kind() == reflect.Slice {
+		resource, ok := jsonAPIResources[value.Type().Elem()]
+		if !ok {
+			return nil, false
+		}
+		list := make([]interface{}, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			object, err := jsonAPIObject(resource, value.Index(i).Interface())
+			if err != nil {
+				return nil, false
+			}
+			list = append(list, object)
+		}
+		return list, true
+	}
+
+	resource, ok := jsonAPIResources[value.Type()]
+	if !ok {
+		return nil, false
+	}
+	object, err := jsonAPIObject(resource, value.Interface())
+	if err != nil {
+		return nil, false
+	}
+	return object, true
+}
+
+// Helper function to build one resource object from a model's JSON representation
+func jsonAPIObject(resource jsonAPIResource, model interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(model)
+	if err != nil {
+		return nil, err
+	}
+	var attributes map[string]interface{}
+	if err := json.Unmarshal(raw, &attributes); err != nil {
+		return nil, err
+	}
+
+	id := fmt.Sprint(attributes[resource.IDField])
+	delete(attributes, resource.IDField)
+
+	object := map[string]interface{}{"type": resource.Type, "id": id}
+	if resource.Self != "" {
+		object["links"] = map[string]interface{}{"self": fmt.Sprintf(resource.Self, id)}
+	}
+
+	if len(resource.Relationships) > 0 {
+		relationships := map[string]interface{}{}
+		for attribute, relatedType := range resource.Relationships {
+			foreignKey, ok := attributes[attribute]
+			if !ok {
+				continue
+			}
+			delete(attributes, attribute)
+
+			relationships[strings.TrimSuffix(attribute, "_id")] = map[string]interface{}{
+				"data": map[string]interface{}{"type": relatedType, "id": fmt.Sprint(foreignKey)},
+			}
+		}
+		object["relationships"] = relationships
+	}
+
+	object["attributes"] = attributes
+	return object, nil
+}
+
+// Helper function to decode a JSON:API request document ({"data": {"type", "attributes"}})
+// into v using the attribute names as JSON field names
+func decodeJSONAPIBody(r *http.Request, v interface{}) error {
+	var document struct {
+		Data *struct {
+			Type       string                     `json:"type"`
+			ID         string                     `json:"id"`
+			Attributes map[string]json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&document); err != nil {
+		return err
+	}
+	if document.Data == nil {
+		return errors.New("JSON:API document has no primary data")
+	}
+
+	attributes, err := json.Marshal(document.Data.Attributes)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(attributes, v)
+}
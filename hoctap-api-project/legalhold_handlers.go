@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/webhooks"
+)
+
+// legalHoldInput is the request body for placing or lifting a legal hold
+type legalHoldInput struct {
+	Hold   *bool  `json:"hold"`
+	Reason string `json:"reason"`
+}
+
+// Place or lift a legal hold on a user; held users cannot be deleted or purged by retention
+func setUserLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	var holdData legalHoldInput
+	if err := decodeRequestBody(r, &holdData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+		return
+	}
+
+	// Validation
+	if holdData.Hold == nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("legal_hold_field_required"), nil)
+		return
+	}
+	if *holdData.Hold && holdData.Reason == "" {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("legal_hold_reason_required"), nil)
+		return
+	}
+
+	user, err := userRepo.WithActor(requestActor(r)).SetLegalHold(r.Context(), userID, *holdData.Hold, holdData.Reason)
+	if err != nil {
+		log.Printf("Error updating legal hold: %v", err)
+		if errors.Is(err, database.ErrUserNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("user_id_not_found", "id", userID), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("legal_hold_update_failed"), nil)
+		}
+		return
+	}
+
+	message := i18n.M("legal_hold_lifted")
+	if user.LegalHold {
+		message = i18n.M("legal_hold_placed")
+	}
+
+	publishUserEvent(webhooks.EventUserUpdated, user)
+	sendJSONResponse(w, http.StatusOK, message, user)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"hoctap-api/config"
+)
+
+// Helper function to open the API listeners: TCP on SERVER_PORT and the unix socket
+// LISTEN_SOCKET, either or both. Opening them up front reports a taken port at startup.
+func openAPIListeners(cfg config.ServerConfig) ([]net.Listener, error) {
+	var listeners []net.Listener
+	if cfg.ListensTCP() {
+		listener, err := listenTCP(cfg.Port)
+		if err != nil {
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+
+	if cfg.ListenSocket != "" {
+		mode, _ := strconv.ParseUint(cfg.ListenSocketMode, 8, 32)
+		listener, err := listenUnix(cfg.ListenSocket, os.FileMode(mode))
+		if err != nil {
+			closeListeners(listeners)
+			return nil, err
+		}
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// Helper function to listen on a TCP port on every interface
+func listenTCP(port string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %s: %v", port, err)
+	}
+	return listener, nil
+}
+
+// Helper function to listen on a unix socket with the given file mode. A socket file left
+// behind by a crashed process is replaced; one with a live server behind it is not.
+func listenUnix(path string, mode os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("failed to listen on %s: file exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to listen on %s: socket is in use", path)
+		}
+		os.Remove(path)
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %v", path, err)
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to set permissions of %s: %v", path, err)
+	}
+	return listener, nil
+}
+
+// Helper function to serve server on listener. TCP listeners speak TLS when configureTLS
+// enabled it; unix sockets always speak plain HTTP to the local proxy.
+func serve(server *http.Server, listener net.Listener) error {
+	if server.TLSConfig != nil && listener.Addr().Network() == "tcp" {
+		// The certificates are already in TLSConfig
+		return server.ServeTLS(listener, "", "")
+	}
+	return server.Serve(listener)
+}
+
+// Helper function to close listeners that will not be served
+func closeListeners(listeners []net.Listener) {
+	for _, listener := range listeners {
+		listener.Close()
+	}
+}
+
+// Helper function to describe a listener for the boot report, e.g. "tcp [::]:8080 (tls)"
+func describeListener(listener net.Listener, tls bool) string {
+	addr := listener.Addr()
+	if tls && addr.Network() == "tcp" {
+		return fmt.Sprintf("%s %s (tls)", addr.Network(), addr)
+	}
+	return fmt.Sprintf("%s %s", addr.Network(), addr)
+}
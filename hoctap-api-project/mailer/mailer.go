@@ -0,0 +1,380 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// Emails sent by the application; each has a templates/<name>.txt defining the subject
+// and text body, and a templates/<name>.html content block
+const (
+	EmailWelcome       = "welcome"
+	EmailVerification  = "verify_email"
+	EmailPasswordReset = "password_reset"
+	EmailNotification  = "notification"
+)
+
+// Delivery settings
+const (
+	maxAttempts    = 5
+	initialBackoff = 5 * time.Second
+	dialTimeout    = 10 * time.Second
+	sendTimeout    = 30 * time.Second
+	queueSize      = 256
+	workerCount    = 2
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// ErrQueueFull is returned by Send when emails are queued faster than the server accepts them
+var ErrQueueFull = errors.New("email queue is full")
+
+// Config is the SMTP server and sender. TLS is "starttls", "tls" (implicit TLS, usually
+// port 465) or "none", which only suits relays on the local network. BaseURL is the public
+// URL of the application that links in emails point to.
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	TLS      string
+	From     string
+	BaseURL  string
+}
+
+// Data is what the templates render: the recipient's Name and, for verification and
+// password reset, the action Link and how long it stays valid (e.g. "24 hours").
+// Notifications render their Title and Body, with an optional Link. BaseURL defaults to
+// the configured one.
+type Data struct {
+	Name     string
+	BaseURL  string
+	Link     string
+	ValidFor string
+	Title    string
+	Body     string
+}
+
+// Message is a rendered email for one recipient
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Text    string `json:"text"`
+	HTML    string `json:"html"`
+}
+
+// email holds the parsed templates of one email
+type email struct {
+	text *texttemplate.Template
+	html *htmltemplate.Template
+}
+
+// Mailer renders templated emails and sends them over SMTP asynchronously, retrying
+// temporary failures with exponential backoff
+type Mailer struct {
+	cfg    Config
+	from   *mail.Address
+	emails map[string]email
+
+	queue chan Message
+	stop  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// New parses the templates and starts the sending workers
+func New(cfg Config) (*Mailer, error) {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender '%s': %v", cfg.From, err)
+	}
+
+	m := &Mailer{
+		cfg:    cfg,
+		from:   from,
+		emails: map[string]email{},
+		queue:  make(chan Message, queueSize),
+		stop:   make(chan struct{}),
+	}
+	for _, name := range []string{EmailWelcome, EmailVerification, EmailPasswordReset, EmailNotification} {
+		text, err := texttemplate.ParseFS(templateFiles, "templates/"+name+".txt")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s email: %v", name, err)
+		}
+		html, err := htmltemplate.ParseFS(templateFiles, "templates/layout.html", "templates/"+name+".html")
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s email: %v", name, err)
+		}
+		m.emails[name] = email{text: text, html: html}
+	}
+
+	for i := 0; i < workerCount; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	return m, nil
+}
+
+// Send renders the named email with data and queues it for to. It never waits for the
+// SMTP server; delivery failures are logged.
+func (m *Mailer) Send(to, name string, data Data) error {
+	msg, err := m.Render(to, name, data)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case m.queue <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Render builds the named email for to without sending it
+func (m *Mailer) Render(to, name string, data Data) (Message, error) {
+	tmpl, ok := m.emails[name]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email '%s'", name)
+	}
+	if _, err := mail.ParseAddress(to); err != nil {
+		return Message{}, fmt.Errorf("invalid recipient '%s': %v", to, err)
+	}
+	if data.BaseURL == "" {
+		data.BaseURL = m.cfg.BaseURL
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s email: %v", name, err)
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s email: %v", name, err)
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, fmt.Errorf("failed to render %s email: %v", name, err)
+	}
+
+	return Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// Stop stops accepting emails and waits for the ones being sent. Queued emails and
+// pending retries are abandoned.
+func (m *Mailer) Stop() {
+	close(m.stop)
+	m.wg.Wait()
+}
+
+// Ping checks that the SMTP server answers and accepts the credentials
+func (m *Mailer) Ping(ctx context.Context) error {
+	client, err := m.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return client.Quit()
+}
+
+// worker sends queued emails until the mailer is stopped
+func (m *Mailer) worker() {
+	defer m.wg.Done()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case msg := <-m.queue:
+			m.deliver(msg)
+		}
+	}
+}
+
+// deliver sends an email, retrying temporary failures with exponential backoff. Permanent
+// rejections (5xx replies, such as an unknown recipient) are not retried.
+func (m *Mailer) deliver(msg Message) {
+	backoff := initialBackoff
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		err := m.send(msg)
+		if err == nil {
+			return
+		}
+
+		permanent := IsPermanent(err)
+		log.Printf("Email '%s' to %s failed (attempt %d/%d): %v", msg.Subject, msg.To, attempt, maxAttempts, err)
+		if permanent || attempt == maxAttempts {
+			return
+		}
+
+		select {
+		case <-m.stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Deliver sends a rendered email right away, once, for callers that retry on their own
+func (m *Mailer) Deliver(msg Message) error {
+	return m.send(msg)
+}
+
+// IsPermanent reports whether a delivery error is a permanent rejection (a 5xx reply, such
+// as an unknown recipient) that retrying cannot fix
+func IsPermanent(err error) bool {
+	var reply *textproto.Error
+	return errors.As(err, &reply) && reply.Code >= 500
+}
+
+// send delivers one email in its own SMTP session
+func (m *Mailer) send(msg Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	client, err := m.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	body, err := m.encode(msg)
+	if err != nil {
+		return err
+	}
+
+	if err := client.Mail(m.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(msg.To); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// connect opens an SMTP session, secured and authenticated as configured
+func (m *Mailer) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	tlsConfig := &tls.Config{ServerName: m.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	if m.cfg.TLS == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.cfg.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("%s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	if m.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+// encode builds the multipart/alternative MIME message with a text and an HTML part
+func (m *Mailer) encode(msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	headers := []string{
+		"From: " + m.from.String(),
+		"To: " + msg.To,
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + newMessageID(m.from.Address),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + parts.Boundary(),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Helper function to generate a unique Message-ID in the sender's domain
+func newMessageID(sender string) string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	_, domain, _ := strings.Cut(sender, "@")
+	return "<" + hex.EncodeToString(buf) + "@" + domain + ">"
+}
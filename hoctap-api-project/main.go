@@ -1,48 +1,83 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"crypto/subtle"
+	"crypto/x509"
+	"errors"
+	"expvar"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
-	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"hoctap-api/cache"
+	"hoctap-api/concurrency"
+	"hoctap-api/config"
 	"hoctap-api/database"
+	"hoctap-api/experiments"
+	"hoctap-api/grpcserver"
+	"hoctap-api/health"
+	"hoctap-api/i18n"
+	"hoctap-api/jobs"
+	"hoctap-api/outbox"
+	"hoctap-api/realtime"
+	"hoctap-api/retention"
+	"hoctap-api/rules"
+	"hoctap-api/secrets"
+	"hoctap-api/tracking"
+	"hoctap-api/webhooks"
 
-	"github.com/gorilla/mux"
-	"github.com/joho/godotenv"
+	"google.golang.org/grpc"
 )
 
-// Response represents a standard API response
+// apiVersion is the version reported by /health, the OpenAPI document and the boot report
+const apiVersion = "1.0.0"
+
+// Response represents a standard API response. Code identifies the errors that clients are
+// expected to handle specifically.
 type Response struct {
-	Message   string      `json:"message"`
-	Data      interface{} `json:"data,omitempty"`
-	Timestamp string      `json:"timestamp"`
+	Message   string                 `json:"message"`
+	Code      string                 `json:"code,omitempty"`
+	Data      interface{}            `json:"data,omitempty"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+	Timestamp string                 `json:"timestamp"`
 }
 
-// Global user repository
-var userRepo *database.UserRepository
+// userInput is the request body for creating or updating a user
+type userInput struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
 
-// Middleware for CORS
-func enableCORS(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+// healthMonitor checks the dependencies reported by /health
+var healthMonitor *health.Monitor
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// Global user store used by the handlers; main injects the database-backed
+// repository and tests can swap in a mock (see database/mocks)
+var userRepo database.UserStore
 
+// Middleware for CORS. Preflight OPTIONS requests are answered by serveRouteMethods,
+// which knows the methods of each path.
+func enableCORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// Helper function to set the CORS headers of every response
+func setCORSHeaders(w http.ResponseWriter) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, Idempotency-Key, X-Timezone")
+}
+
 // Middleware for logging requests
 func logRequest(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -52,304 +87,939 @@ func logRequest(next http.Handler) http.Handler {
 	})
 }
 
-// Helper function to send JSON response
-func sendJSONResponse(w http.ResponseWriter, statusCode int, message string, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+// Middleware restricting admin endpoints to requests carrying ADMIN_API_TOKEN as a bearer token,
+// or a client certificate named in MTLS_ADMIN_IDENTITIES
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Services authenticated by client certificate need no token
+		if isAdminIdentity(r) {
+			next(w, r)
+			return
+		}
+
+		if config.Current().Admin.APIToken == "" {
+			sendJSONResponse(w, http.StatusForbidden, i18n.M("admin_api_disabled"), nil)
+			return
+		}
+
+		if !hasAdminToken(r) {
+			sendJSONResponse(w, http.StatusUnauthorized, i18n.M("admin_authorization_required"), nil)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// Helper function to tell whether the request carries ADMIN_API_TOKEN as a bearer token
+func hasAdminToken(r *http.Request) bool {
+	token := config.Current().Admin.APIToken
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
+
+// databaseRetryAfter is the Retry-After hint while the database circuit is open
+const databaseRetryAfter = "5"
+
+// Middleware answering 503 while the database circuit breaker is open, instead of letting
+// every handler fail with a 500
+func failFastWithoutDatabase(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !database.Available() {
+			w.Header().Set("Retry-After", databaseRetryAfter)
+			sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("database_unavailable"), nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireDatabase applies failFastWithoutDatabase to a single handler
+func requireDatabase(next http.HandlerFunc) http.HandlerFunc {
+	return failFastWithoutDatabase(next).ServeHTTP
+}
+
+// Helper function to send JSON response with a message of the catalog, such as
+// i18n.M("user_id_not_found", "id", id)
+func sendJSONResponse(w http.ResponseWriter, statusCode int, message i18n.Message, data interface{}) {
+	sendJSONResponseWithMeta(w, statusCode, message, data, nil)
+}
 
+// Helper function to send a response carrying metadata about data, such as pagination
+func sendJSONResponseWithMeta(w http.ResponseWriter, statusCode int, message i18n.Message, data interface{}, meta map[string]interface{}) {
 	response := Response{
-		Message:   message,
 		Data:      data,
+		Meta:      meta,
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
 
-	json.NewEncoder(w).Encode(response)
+	writeResponse(w, statusCode, message, response)
 }
 
-// Health check endpoint
+// Helper function to send an error response carrying a machine-readable code
+func sendJSONErrorWithCode(w http.ResponseWriter, statusCode int, code string, message i18n.Message) {
+	writeResponse(w, statusCode, message, Response{
+		Code:      code,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// Health check endpoint, reporting every dependency with its latency and last success
 func healthHandler(w http.ResponseWriter, r *http.Request) {
-	// Check database connection
+	report := healthMonitor.Report(r.Context())
+
+	// database keeps the summary older clients read
 	dbStatus := "healthy"
 	if database.DB == nil {
 		dbStatus = "disconnected"
-	} else if err := database.DB.Ping(); err != nil {
-		dbStatus = "error: " + err.Error()
+	} else if !database.Available() {
+		dbStatus = "unavailable (circuit open)"
+	}
+	for _, dependency := range report.Dependencies {
+		if dependency.Name == "database" && dependency.Status != "up" && dbStatus == "healthy" {
+			dbStatus = "error: " + dependency.Error
+		}
 	}
 
-	sendJSONResponse(w, http.StatusOK, "API is running successfully", map[string]interface{}{
-		"status":    "healthy",
-		"version":   "1.0.0",
-		"database":  dbStatus,
-		"timestamp": time.Now().Format(time.RFC3339),
+	sendJSONResponse(w, http.StatusOK, i18n.M("api_running"), map[string]interface{}{
+		"status":       report.Status,
+		"version":      apiVersion,
+		"database":     dbStatus,
+		"dependencies": report.Dependencies,
+		"checked_at":   report.CheckedAt.Format(time.RFC3339),
+		"timestamp":    time.Now().Format(time.RFC3339),
 	})
 }
 
-// Get all users
+// Get all users, or a page of them when ?limit= or ?cursor= is given, optionally
+// reduced to the ?fields= listed
 func getUsersHandler(w http.ResponseWriter, r *http.Request) {
-	users, err := userRepo.GetAllUsers()
+	fields, err := requestedUserFields(r)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.MessageOf(err), nil)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Has("limit") || query.Has("cursor") {
+		sendUsersPage(w, r, fields)
+		return
+	}
+
+	var users []database.User
+	if fields == nil {
+		users, err = userRepo.GetAllUsers(r.Context())
+	} else {
+		users, err = userRepo.ListUsers(r.Context(), database.UserFilter{Fields: fields})
+	}
 	if err != nil {
 		log.Printf("Error getting users: %v", err)
-		sendJSONResponse(w, http.StatusInternalServerError, "Failed to retrieve users", nil)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("users_retrieve_failed"), nil)
 		return
 	}
 
-	sendJSONResponse(w, http.StatusOK, "Users retrieved successfully", users)
+	sendJSONResponse(w, http.StatusOK, i18n.M("users_retrieved"), sparseUsers(users, fields))
 }
 
-// Get user by ID
+// Get user by ID, optionally reduced to the ?fields= listed
 func getUserByIDHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.Atoi(vars["id"])
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return
+	}
+
+	fields, err := requestedUserFields(r)
 	if err != nil {
-		sendJSONResponse(w, http.StatusBadRequest, "Invalid user ID", nil)
+		sendJSONResponse(w, http.StatusBadRequest, i18n.MessageOf(err), nil)
 		return
 	}
 
-	user, err := userRepo.GetUserByID(userID)
+	user, err := userRepo.GetUserByID(r.Context(), userID)
 	if err != nil {
-		log.Printf("Error getting user by ID %d: %v", userID, err)
-		sendJSONResponse(w, http.StatusNotFound, "User not found", nil)
+		sendUserLookupError(w, userID, err)
 		return
 	}
 
-	sendJSONResponse(w, http.StatusOK, "User found", user)
+	if fields != nil {
+		sendJSONResponse(w, http.StatusOK, i18n.M("user_found"), sparseUserOf(*user, fields))
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, i18n.M("user_found"), user)
 }
 
 // Create new user
 func createUserHandler(w http.ResponseWriter, r *http.Request) {
-	var userData struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
-	}
+	var userData userInput
 
-	if err := json.NewDecoder(r.Body).Decode(&userData); err != nil {
-		sendJSONResponse(w, http.StatusBadRequest, "Invalid JSON format", nil)
+	if err := decodeRequestBody(r, &userData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
 		return
 	}
 
 	// Validation
 	if userData.Name == "" || userData.Email == "" {
-		sendJSONResponse(w, http.StatusBadRequest, "Name and email are required", nil)
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("user_name_email_required"), nil)
+		return
+	}
+	email, err := checkUserEmail(r.Context(), userData.Email)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.MessageOf(err), nil)
 		return
 	}
+	userData.Email = email
 
-	user, err := userRepo.CreateUser(userData.Name, userData.Email)
+	user, err := userRepo.WithActor(requestActor(r)).CreateUser(r.Context(), userData.Name, userData.Email)
 	if err != nil {
 		log.Printf("Error creating user: %v", err)
-		if err.Error() == fmt.Sprintf("user with email '%s' already exists", userData.Email) {
-			sendJSONResponse(w, http.StatusConflict, err.Error(), nil)
+		var violation *rules.Violation
+		if errors.As(err, &violation) {
+			sendJSONErrorWithCode(w, http.StatusBadRequest, errorCodeValidationRule, violationMessage(violation))
+		} else if errors.Is(err, database.ErrEmailExists) {
+			sendJSONResponse(w, http.StatusConflict, i18n.M("user_email_exists", "email", userData.Email), nil)
 		} else {
-			sendJSONResponse(w, http.StatusInternalServerError, "Failed to create user", nil)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("user_create_failed"), nil)
 		}
 		return
 	}
 
-	sendJSONResponse(w, http.StatusCreated, "User created successfully", user)
+	publishUserEvent(webhooks.EventUserCreated, user)
+	sendJSONResponse(w, http.StatusCreated, i18n.M("user_created"), user)
 }
 
 // Update user
 func updateUserHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		sendJSONResponse(w, http.StatusBadRequest, "Invalid user ID", nil)
+	userID, ok := userIDParam(w, r)
+	if !ok {
 		return
 	}
 
-	var userData struct {
-		Name  string `json:"name"`
-		Email string `json:"email"`
-	}
+	var userData userInput
 
-	if err := json.NewDecoder(r.Body).Decode(&userData); err != nil {
-		sendJSONResponse(w, http.StatusBadRequest, "Invalid JSON format", nil)
+	if err := decodeRequestBody(r, &userData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
 		return
 	}
 
 	// Validation
 	if userData.Name == "" || userData.Email == "" {
-		sendJSONResponse(w, http.StatusBadRequest, "Name and email are required", nil)
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("user_name_email_required"), nil)
+		return
+	}
+	email, err := checkUserEmail(r.Context(), userData.Email)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.MessageOf(err), nil)
 		return
 	}
+	userData.Email = email
 
-	user, err := userRepo.UpdateUser(userID, userData.Name, userData.Email)
+	user, err := userRepo.WithActor(requestActor(r)).UpdateUser(r.Context(), userID, userData.Name, userData.Email)
 	if err != nil {
 		log.Printf("Error updating user: %v", err)
-		if err.Error() == fmt.Sprintf("user with ID %d not found", userID) {
-			sendJSONResponse(w, http.StatusNotFound, err.Error(), nil)
-		} else if err.Error() == fmt.Sprintf("user with email '%s' already exists", userData.Email) {
-			sendJSONResponse(w, http.StatusConflict, err.Error(), nil)
+		var violation *rules.Violation
+		if errors.As(err, &violation) {
+			sendJSONErrorWithCode(w, http.StatusBadRequest, errorCodeValidationRule, violationMessage(violation))
+		} else if errors.Is(err, database.ErrUserNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("user_id_not_found", "id", userID), nil)
+		} else if errors.Is(err, database.ErrEmailExists) {
+			sendJSONResponse(w, http.StatusConflict, i18n.M("user_email_exists", "email", userData.Email), nil)
 		} else {
-			sendJSONResponse(w, http.StatusInternalServerError, "Failed to update user", nil)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("user_update_failed"), nil)
 		}
 		return
 	}
 
-	sendJSONResponse(w, http.StatusOK, "User updated successfully", user)
+	publishProfileEvent(webhooks.EventUserUpdated, user)
+	sendJSONResponse(w, http.StatusOK, i18n.M("user_updated"), user)
 }
 
 // Delete user
 func deleteUserHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.Atoi(vars["id"])
-	if err != nil {
-		sendJSONResponse(w, http.StatusBadRequest, "Invalid user ID", nil)
+	userID, ok := userIDParam(w, r)
+	if !ok {
 		return
 	}
 
-	err = userRepo.DeleteUser(userID)
+	err := userRepo.WithActor(requestActor(r)).DeleteUser(r.Context(), userID)
 	if err != nil {
 		log.Printf("Error deleting user: %v", err)
-		if err.Error() == fmt.Sprintf("user with ID %d not found", userID) {
-			sendJSONResponse(w, http.StatusNotFound, err.Error(), nil)
+		if errors.Is(err, database.ErrUserNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("user_id_not_found", "id", userID), nil)
+		} else if errors.Is(err, database.ErrUserUnderLegalHold) {
+			sendJSONResponse(w, http.StatusConflict, i18n.M("user_under_legal_hold", "id", userID), nil)
 		} else {
-			sendJSONResponse(w, http.StatusInternalServerError, "Failed to delete user", nil)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("user_delete_failed"), nil)
 		}
 		return
 	}
 
-	sendJSONResponse(w, http.StatusOK, "User deleted successfully", nil)
+	publishUserEvent(webhooks.EventUserDeleted, map[string]interface{}{"id": userID})
+	sendJSONResponse(w, http.StatusOK, i18n.M("user_deleted"), nil)
 }
 
 // Get users statistics
 func getUsersStatsHandler(w http.ResponseWriter, r *http.Request) {
-	count, err := userRepo.GetUsersCount()
+	location, err := requestLocation(r)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.MessageOf(err), nil)
+		return
+	}
+
+	// "Today" is the client's day
+	now := time.Now()
+	series, err := signupSeriesParam(r, location, now)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.MessageOf(err), nil)
+		return
+	}
+
+	count, err := userRepo.GetUsersCount(r.Context())
 	if err != nil {
 		log.Printf("Error getting users count: %v", err)
-		sendJSONResponse(w, http.StatusInternalServerError, "Failed to get users statistics", nil)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("users_stats_failed"), nil)
+		return
+	}
+
+	today, err := userRepo.CountUsers(r.Context(), database.UserFilter{CreatedSince: startOfDay(now, location)})
+	if err != nil {
+		log.Printf("Error counting today's signups: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("users_stats_failed"), nil)
 		return
 	}
 
 	stats := map[string]interface{}{
-		"total_users": count,
-		"timestamp":   time.Now().Format(time.RFC3339),
+		"total_users":   count,
+		"signups_today": today,
+		"timezone":      location.String(),
+		"timestamp":     now.UTC().Format(time.RFC3339),
 	}
 
-	sendJSONResponse(w, http.StatusOK, "Users statistics retrieved successfully", stats)
-}
+	if series != nil {
+		counts, err := userRepo.CountSignups(r.Context(), series.starts[0], series.starts[len(series.starts)-1])
+		if err != nil {
+			log.Printf("Error counting signups: %v", err)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("users_stats_failed"), nil)
+			return
+		}
+		series.fill(counts)
+		stats["signups"] = series
+	}
 
-// Serve the main HTML page
-func serveIndexHandler(w http.ResponseWriter, r *http.Request) {
-	http.ServeFile(w, r, "index.html")
+	sendJSONResponse(w, http.StatusOK, i18n.M("users_stats_retrieved"), stats)
 }
 
 // Welcome endpoint (moved to /welcome)
 func welcomeHandler(w http.ResponseWriter, r *http.Request) {
-	sendJSONResponse(w, http.StatusOK, "Welcome to HocTap API!", map[string]interface{}{
+	sendJSONResponse(w, http.StatusOK, i18n.M("welcome"), map[string]interface{}{
 		"endpoints": map[string]string{
-			"health":      "GET /health",
-			"users":       "GET /api/users",
-			"user_by_id":  "GET /api/users/{id}",
-			"create_user": "POST /api/users",
-			"update_user": "PUT /api/users/{id}",
-			"delete_user": "DELETE /api/users/{id}",
-			"users_stats": "GET /api/users/stats",
-			"dashboard":   "GET / (HTML Dashboard)",
+			"health":        "GET /health",
+			"probes":        "GET /livez, /readyz, /startupz, /lb-health",
+			"users":         "GET /api/v1/users",
+			"user_by_id":    "GET /api/v1/users/{id}",
+			"create_user":   "POST /api/v1/users",
+			"update_user":   "PUT /api/v1/users/{id}",
+			"delete_user":   "DELETE /api/v1/users/{id}",
+			"users_stats":   "GET /api/v1/users/stats",
+			"users_search":  "GET /api/v1/users/search?q=...",
+			"analytics":     "GET /api/v1/analytics/summary",
+			"audit_log":     "GET /api/v1/audit",
+			"qr_code":       "GET /api/v1/qr?data=...",
+			"webhooks":      "GET/POST /api/v1/webhooks",
+			"jobs":          "GET /api/v1/jobs",
+			"short_links":   "GET/POST /api/v1/short-links",
+			"short_link":    "GET /s/{code}",
+			"announcements": "GET /api/v1/announcements/active",
+			"notifications": "GET /api/v1/notifications?user_id={id}",
+			"live_updates":  "GET /ws (WebSocket)",
+			"graphql":       "POST /graphql",
+			"experiments":   "GET /api/v1/users/{id}/experiments",
+			"openapi":       "GET /openapi.json",
+			"docs":          "GET /docs (Swagger UI)",
+			"track_events":  "POST /api/v1/events/track",
+			"dashboard":     "GET / (HTML Dashboard)",
 		},
-		"database":      "MySQL with environment configuration",
-		"documentation": "Use the endpoints above to interact with the API, or visit / for the web dashboard",
+		"database":      databaseLabel() + " with environment configuration",
+		"documentation": "See /docs for the interactive API reference, or visit / for the web dashboard",
 	})
 }
 
-// Helper function to get environment variable with fallback
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// Helper function to determine the client IP. X-Forwarded-For is only honored from the
+// TRUSTED_PROXIES and from the local proxy of LISTEN_SOCKET: the client is the last address
+// before the trusted ones, as anything earlier is whatever the client chose to send.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	server := config.Current().Server
+	viaSocket := net.ParseIP(host) == nil
+	if !viaSocket && !server.TrustsProxy(host) {
+		return host
+	}
+
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		addr := strings.TrimSpace(forwarded[i])
+		if addr != "" && (i == 0 || !server.TrustsProxy(addr)) {
+			return addr
+		}
+	}
+	return host
+}
+
+// Helper function to identify who is making a change, for the audit log. Only what the request
+// authenticated as counts: its client certificate, then the admin token; no header a client
+// could set names the actor.
+func requestActor(r *http.Request) database.AuditActor {
+	name := "anonymous"
+	if identity, ok := clientIdentity(r.Context()); ok {
+		name = "service:" + identity
+	} else if hasAdminToken(r) {
+		name = "admin"
+	}
+	return database.AuditActor{Name: name, IP: clientIP(r)}
+}
+
+// Helper function to name the configured database for display
+func databaseLabel() string {
+	if database.CurrentDialect() == database.DialectPostgres {
+		return "PostgreSQL"
+	}
+	return "MySQL"
+}
+
+// Helper function to reload the configuration and apply the settings that can change live
+func reloadConfig() bool {
+	_, next, err := config.Reload()
+	if err != nil {
+		log.Printf("⚠️ Warning: configuration not reloaded: %v", err)
+		return false
+	}
+
+	// The admin token and API keys are read per request; the limits are pushed to their owners
+	trackingSampler.SetRate(next.Events.SampleRate)
+	trackingQuota.SetLimit(next.Events.QuotaPerMinute)
+	concurrencyLimiter.SetLimit(next.API.ConcurrencyPerClient)
+	publicStatsQuota.SetLimit(next.PublicStats.QuotaPerHour)
+	log.Println("✅ Configuration reloaded")
+	return true
+}
+
+// secretRetryDelay is how long to wait before fetching secrets again after a failure
+const secretRetryDelay = 30 * time.Second
+
+// Helper function to fetch secrets again when their lease is due (or every interval for
+// secrets without one) and reload the configuration with them
+func refreshSecrets(interval time.Duration) {
+	for {
+		next, ok := secrets.NextRefresh(interval)
+		if !ok {
+			return
+		}
+		time.Sleep(time.Until(next))
+
+		secrets.ExpireDue(interval)
+		if !reloadConfig() {
+			time.Sleep(secretRetryDelay)
+		}
 	}
-	return fallback
 }
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load("config.env"); err != nil {
-		log.Printf("Warning: Could not load config.env file: %v", err)
-		log.Println("Using system environment variables or defaults")
+	// Work in UTC whatever the host's time zone, so timestamps in responses, logs and
+	// schedules do not shift when the server moves
+	time.Local = time.UTC
+
+	// Load config.yaml, config.env and the environment
+	if _, err := config.Init(); err != nil {
+		log.Fatalf("❌ %v", err)
 	}
 
-	// Initialize database
-	log.Println("🔧 Initializing database connection...")
-	if err := database.InitDB(); err != nil {
-		log.Fatalf("❌ Failed to initialize database: %v", err)
+	if err := runCommand(os.Args[1:]); err != nil {
+		log.Fatalf("❌ %v", err)
 	}
-	defer database.CloseDB()
+}
 
-	// Initialize user repository
-	userRepo = database.NewUserRepository()
+// Run the API server (`hoctap-api serve`)
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cfg := config.Current()
 
-	// Seed initial users
-	log.Println("🌱 Seeding initial users...")
-	if err := userRepo.SeedUsers(); err != nil {
-		log.Printf("⚠️ Warning: Failed to seed users: %v", err)
-	} else {
-		log.Println("✅ Initial users seeded successfully")
+	migrate := flags.Bool("migrate", cfg.Database.AutoMigrate, "apply pending migrations before serving")
+	seed := flags.Bool("seed", false, "seed the initial users before serving")
+	mock := flags.Bool("mock", false, "serve generated users from memory, without a database")
+	assetsDir := flags.String("assets", "", "serve the dashboard files from this directory instead of the embedded copies (live editing)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
 	}
 
-	// Create a new router
-	router := mux.NewRouter()
+	logPlugins()
+
+	lifecycle, err := cfg.API.Lifecycle()
+	if err != nil {
+		return err
+	}
+	apiLifecycle = lifecycle
+
+	if *assetsDir != "" {
+		useAssetsDir(*assetsDir)
+		log.Printf("🎨 Serving dashboard files from %s", *assetsDir)
+	}
 
-	// Apply middleware
-	router.Use(enableCORS)
-	router.Use(logRequest)
+	// The event, public stats and concurrency limits are reloadable, so they exist in every mode
+	trackingSampler = tracking.NewSampler(cfg.Events.SampleRate)
+	trackingQuota = tracking.NewQuota(cfg.Events.QuotaPerMinute, time.Minute)
+	publicStatsQuota = tracking.NewQuota(cfg.PublicStats.QuotaPerHour, time.Hour)
+	concurrencyLimiter = concurrency.New(cfg.API.ConcurrencyPerClient)
+	expvar.Publish("concurrency", expvar.Func(func() any { return concurrencyLimiter.Stats() }))
 
-	// Serve static files (CSS, JS)
-	router.HandleFunc("/static/styles.css", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "text/css")
-		http.ServeFile(w, r, "styles.css")
-	}).Methods("GET")
+	// Hub for live dashboard updates over WebSocket
+	liveHub = realtime.NewHub()
 
-	router.HandleFunc("/static/script.js", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/javascript")
-		http.ServeFile(w, r, "script.js")
-	}).Methods("GET")
+	// Dependencies reported by /health are registered as the services start
+	healthMonitor = health.NewMonitor(cfg.Health.CacheTTL)
 
-	// Serve the main HTML page at root
-	router.HandleFunc("/", serveIndexHandler).Methods("GET")
+	// Uploaded files are stored in every mode
+	done := timeBootStep("storage")
+	store, err := newBlobStore(cfg.Storage)
+	if err != nil {
+		return err
+	}
+	blobStore = store
+	healthMonitor.Register("storage", false, blobStore.Ping)
+	done()
 
-	// API routes
-	router.HandleFunc("/health", healthHandler).Methods("GET")
-	router.HandleFunc("/welcome", welcomeHandler).Methods("GET")
+	// Watch for goroutine, connection and file descriptor leaks
+	stopWatchdog := startWatchdog(cfg.Watchdog.Interval, cfg.Watchdog.Window)
+	defer stopWatchdog()
 
-	api := router.PathPrefix("/api").Subrouter()
-	api.HandleFunc("/users", getUsersHandler).Methods("GET")
-	api.HandleFunc("/users/stats", getUsersStatsHandler).Methods("GET")
-	api.HandleFunc("/users/{id:[0-9]+}", getUserByIDHandler).Methods("GET")
-	api.HandleFunc("/users", createUserHandler).Methods("POST")
-	api.HandleFunc("/users/{id:[0-9]+}", updateUserHandler).Methods("PUT")
-	api.HandleFunc("/users/{id:[0-9]+}", deleteUserHandler).Methods("DELETE")
+	// Account emails, when an SMTP server is configured
+	stopMailer, err := startMailer(cfg.Mail)
+	if err != nil {
+		return fmt.Errorf("failed to configure email: %v", err)
+	}
+	defer stopMailer()
 
-	// Server configuration
-	port := getEnv("SERVER_PORT", "8080")
+	// Listen right away so the probes answer while the database connects; other requests
+	// get 503 until the router is ready
+	startup := &startupHandler{}
 	server := &http.Server{
-		Addr:         ":" + port,
-		Handler:      router,
+		Handler:      startup,
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Graceful shutdown
+	done = timeBootStep("listeners")
+	redirectServer, err := configureTLS(server, cfg.Server)
+	if err != nil {
+		return err
+	}
+	var clientCAs *x509.CertPool
+	if len(cfg.MTLS.Listeners) > 0 {
+		if clientCAs, err = loadClientCAs(cfg.MTLS.ClientCA); err != nil {
+			return err
+		}
+	}
+	if cfg.MTLS.Requires("api") {
+		requireClientCerts(server, clientCAs)
+	}
+
+	listeners, err := openAPIListeners(cfg.Server)
+	if err != nil {
+		return err
+	}
+	servers := []*http.Server{server}
+
+	// Every listener reports at most one error
+	serveErr := make(chan error, len(listeners)+2)
+	for _, listener := range listeners {
+		go func() {
+			serveErr <- serve(server, listener)
+		}()
+		boot.Listeners = append(boot.Listeners, describeListener(listener, server.TLSConfig != nil))
+	}
+	if redirectServer != nil {
+		listener, err := listenTCP(cfg.Server.HTTPRedirectPort)
+		if err != nil {
+			server.Close()
+			return err
+		}
+		servers = append(servers, redirectServer)
+		go func() {
+			serveErr <- redirectServer.Serve(listener)
+		}()
+		boot.Listeners = append(boot.Listeners, "redirect "+describeListener(listener, false))
+		log.Printf("↪️ Redirecting HTTP on port %s to HTTPS", cfg.Server.HTTPRedirectPort)
+	}
+	done()
+
+	// Start the database-backed services, or serve generated users from memory
+	var stopWorkers func()
+	if *mock {
+		boot.Mode = "mock"
+		done := timeBootStep("mock_users")
+		stopWorkers = startMockServices(cfg.Mock)
+		done()
+	} else {
+		boot.Mode = strings.ToLower(databaseLabel())
+		stop, err := startServices(cfg, *migrate, *seed)
+		if err != nil {
+			for _, server := range servers {
+				server.Close()
+			}
+			return err
+		}
+		defer database.CloseDB()
+		stopWorkers = stop
+	}
+
+	// Until shutdown takes over, a failure closes the listeners and stops the workers
+	// before it is returned; the deferred calls close the database
+	var grpcServer *grpc.Server
+	abort := func(err error) error {
+		for _, server := range servers {
+			server.Close()
+		}
+		if grpcServer != nil {
+			grpcServer.Stop()
+		}
+		stopWorkers()
+		return err
+	}
+
+	// Build the GraphQL schema
+	done = timeBootStep("graphql")
+	graphQLSchema, err = newGraphQLSchema()
+	if err != nil {
+		return abort(fmt.Errorf("failed to build GraphQL schema: %v", err))
+	}
+	done()
+
+	done = timeBootStep("router")
+	router := newRouter()
+	if *mock {
+		router.Use(mockAPI(cfg.Mock))
+	}
+
+	// Generate the OpenAPI document from the complete route table
+	openAPISpec = buildOpenAPISpec(router)
+	done()
+
+	// Optional internal listener for the ops endpoints
+	if adminPort := cfg.Server.AdminPort; adminPort != "" {
+		listener, err := listenTCP(adminPort)
+		if err != nil {
+			return abort(fmt.Errorf("failed to start admin listener: %v", err))
+		}
+
+		// The moved ops API endpoints are not available in mock mode either
+		adminRouter := newAdminRouter()
+		if *mock {
+			adminRouter.Use(mockAPI(cfg.Mock))
+		}
+
+		// No write timeout: CPU profiles and traces stream for as long as requested
+		adminServer := &http.Server{
+			Handler:     adminRouter,
+			ReadTimeout: 15 * time.Second,
+			IdleTimeout: 60 * time.Second,
+		}
+		// With mTLS the admin listener serves the API's certificate and requires a client one
+		if cfg.MTLS.Requires("admin") {
+			adminServer.TLSConfig = server.TLSConfig
+			requireClientCerts(adminServer, clientCAs)
+		}
+		servers = append(servers, adminServer)
+
+		go func() {
+			serveErr <- serve(adminServer, listener)
+		}()
+		boot.Listeners = append(boot.Listeners, "admin "+describeListener(listener, adminServer.TLSConfig != nil))
+		log.Printf("🔒 Admin listener (pprof, health checks) on port %s", adminPort)
+	}
+
+	// Optional gRPC listener on a second port
+	if grpcPort := cfg.Server.GRPCPort; grpcPort != "" {
+		listener, err := net.Listen("tcp", ":"+grpcPort)
+		if err != nil {
+			return abort(fmt.Errorf("failed to listen on gRPC port %s: %v", grpcPort, err))
+		}
+
+		grpcServer = grpcserver.NewServer(userRepo, publishProfileEvent, checkUserEmail)
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Printf("gRPC server stopped: %v", err)
+			}
+		}()
+		boot.Listeners = append(boot.Listeners, "grpc "+describeListener(listener, false))
+		log.Printf("🔌 gRPC UserService listening on port %s", grpcPort)
+	}
+
+	// Fetch Vault / SSM secrets again before they expire
+	go refreshSecrets(cfg.Secrets.RefreshInterval)
+
+	// Reload the hot-reloadable settings on SIGHUP
 	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
-		<-sigint
+		sighup := make(chan os.Signal, 1)
+		signal.Notify(sighup, syscall.SIGHUP)
+		for range sighup {
+			reloadConfig()
+		}
+	}()
+
+	// Serve every route from now on
+	publishBootReport(router)
+	startup.Ready(serveRouteMethods(router))
+	log.Printf("🚀 HocTap API Server ready in %.0fms on %s", boot.DurationMs, strings.Join(boot.Listeners, ", "))
+
+	stop := make(chan os.Signal, 2)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	select {
+	case err := <-serveErr:
+		return abort(err)
+	case <-stop:
+	}
+
+	return shutdown(servers, grpcServer, stop, cfg.Server.PreStopDelay, cfg.Server.ShutdownTimeout, stopWorkers)
+}
 
-		log.Println("🛑 Shutting down server...")
+// Helper function to connect and migrate the database, create the repositories and start
+// the background workers. It returns a function stopping the workers; the caller closes
+// the database. On failure it stops what it started and closes the database itself.
+func startServices(cfg *config.Config, migrate, seed bool) (func(), error) {
+	// Initialize database
+	log.Println("🔧 Initializing database connection...")
+	done := timeBootStep("database")
+	if err := database.InitDB(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %v", err)
+	}
+	done()
+
+	// Workers are stopped in the reverse order they started
+	var stops []func()
+	stopAll := func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+	fail := func(err error) (func(), error) {
+		stopAll()
 		database.CloseDB()
-		os.Exit(0)
+		return nil, err
+	}
+
+	// With several instances, migrate once with `hoctap-api migrate up` and serve with -migrate=false
+	done = timeBootStep("migrations")
+	schemaBefore, _, err := database.MigrationVersion()
+	if err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+	if migrate {
+		if err := migrateAll(); err != nil {
+			return fail(fmt.Errorf("failed to migrate database: %v", err))
+		}
+	}
+	recordBootMigrations(schemaBefore)
+	done()
+
+	// Initialize repositories
+	users := database.NewUserRepository()
+	var store database.UserStore = users
+
+	// Cache user reads when a cache backend is configured
+	done = timeBootStep("cache")
+	userCache, err := newCacheBackend(cfg.Cache)
+	if err != nil {
+		return fail(err)
+	}
+	done()
+	if userCache != nil {
+		stops = append(stops, func() { userCache.Close() })
+		store = cache.NewUserStore(users, userCache, cfg.Cache.UserTTL, cfg.Cache.ListTTL)
+	}
+	userRepo = ruleCheckedUserStore{store}
+	healthMonitor.Register("database", true, checkDatabaseReady)
+	if userCache != nil {
+		healthMonitor.Register("cache", false, userCache.Ping)
+	}
+	auditRepo = database.NewAuditRepository()
+	webhookRepo = database.NewWebhookRepository()
+	shortLinkRepo = database.NewShortLinkRepository()
+	announcementRepo = database.NewAnnouncementRepository()
+	experimentRepo = database.NewExperimentRepository()
+	validationRuleRepo = database.NewValidationRuleRepository()
+	idempotencyRepo = database.NewIdempotencyRepository()
+	notificationRepo = database.NewNotificationRepository()
+	activityRepo = database.NewActivityRepository()
+
+	// Load the admin-defined validation rules
+	done = timeBootStep("validation_rules")
+	if err := reloadValidationRules(context.Background()); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+	done()
+
+	// Load experiment definitions
+	done = timeBootStep("experiments")
+	experimentService, err = experiments.LoadFile(cfg.Experiments.File)
+	if err != nil {
+		return fail(fmt.Errorf("failed to load experiments: %v", err))
+	}
+	done()
+
+	// Run emails and webhook deliveries as background jobs
+	jobRepo = database.NewJobRepository()
+	jobQueue = jobs.New(jobRepo, cfg.Jobs.Lease, cfg.Jobs.PollInterval)
+	webhookDispatcher = webhooks.NewDispatcher(webhookRepo, jobQueue)
+	registerEmailJobs(jobQueue)
+	jobQueue.Start(cfg.Jobs.Workers)
+	stops = append(stops, jobQueue.Stop)
+
+	// Publish the user events recorded by committed changes
+	eventBroker, err = newEventBroker(cfg.Broker)
+	if err != nil {
+		return fail(fmt.Errorf("failed to set up the message broker: %v", err))
+	}
+	if eventBroker != nil {
+		stops = append(stops, func() { eventBroker.Close() })
+		healthMonitor.Register("broker", false, eventBroker.Ping)
+	}
+	outboxRepo = database.NewOutboxRepository()
+	outboxPublishers := map[string]outbox.Publisher{database.OutboxWebhooks: publishToWebhooks}
+	if eventBroker != nil {
+		outboxPublishers[database.OutboxBroker] = publishToBroker
+	}
+	outboxRelay = outbox.NewRelay(outboxRepo, outboxPublishers, cfg.Outbox.PollInterval, cfg.Outbox.Lease, cfg.Outbox.MaxAttempts)
+	outboxRelay.Start()
+	stops = append(stops, outboxRelay.Stop)
+
+	// Start the batched analytics event pipeline
+	trackingBuffer = tracking.NewBuffer(database.NewTrackingRepository(), 200, 2*time.Second)
+	stops = append(stops, trackingBuffer.Stop)
+
+	// Schedule retention policies
+	retentionRepo = database.NewRetentionRepository()
+	retentionRunner = retention.NewRunner(retentionRepo, auditRepo)
+	retentionRunner.Start(cfg.Retention.Interval)
+	stops = append(stops, retentionRunner.Stop)
+
+	// Seed initial users
+	if seed {
+		if err := seedUsers(users); err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+	}
+
+	// Conditions for /readyz
+	readinessChecks = []readinessCheck{
+		{"database", checkDatabaseReady},
+		{"migrations", checkMigrationsReady},
+	}
+
+	// Run the recurring tasks on one instance at a time
+	stopScheduler, err := startScheduler(cfg.Scheduler)
+	if err != nil {
+		return fail(err)
+	}
+	stops = append(stops, stopScheduler)
+
+	return stopAll, nil
+}
+
+// Helper function to create the user cache backend selected by CACHE_BACKEND, or nil
+// when caching is off
+func newCacheBackend(cfg config.CacheConfig) (cache.Backend, error) {
+	backend := cfg.Backend
+	if backend == "" && cfg.RedisURL != "" {
+		backend = "redis"
+	}
+
+	switch backend {
+	case "redis":
+		userCache, err := cache.NewRedis(context.Background(), cfg.RedisURL)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("🗃️ Caching user reads in Redis (users %s, lists %s)", cfg.UserTTL, cfg.ListTTL)
+		return userCache, nil
+	case "memory":
+		log.Printf("🗃️ Caching user reads in memory (up to %d entries; users %s, lists %s)",
+			cfg.MaxEntries, cfg.UserTTL, cfg.ListTTL)
+		return cache.NewMemory(cfg.MaxEntries), nil
+	default:
+		return nil, nil
+	}
+}
+
+// Helper function to stop accepting requests, wait up to timeout for the active ones and
+// stop the background workers. Requests are still served for preStopDelay first, while
+// /lb-health reports draining; a second signal cuts the delay short. Another signal or the
+// timeout forces the remaining connections closed, which is reported as an error. The
+// database is closed by the caller.
+func shutdown(servers []*http.Server, grpcServer *grpc.Server, stop <-chan os.Signal, preStopDelay, timeout time.Duration, stopWorkers func()) error {
+	shuttingDown.Store(true)
+
+	if preStopDelay > 0 {
+		log.Printf("⏳ Waiting %s for load balancers to stop routing to this instance...", preStopDelay)
+		// Clients on keep-alive connections reconnect through the load balancer
+		for _, server := range servers {
+			server.SetKeepAlivesEnabled(false)
+		}
+		select {
+		case <-time.After(preStopDelay):
+		case <-stop:
+			log.Println("⚠️ Warning: second signal received, skipping the rest of PRE_STOP_DELAY")
+		}
+	}
+
+	log.Printf("🛑 Shutting down server (draining for up to %s)...", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			log.Println("⚠️ Warning: second signal received, forcing shutdown")
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
 
-	fmt.Printf("🚀 HocTap API Server starting on port %s\n", port)
-	fmt.Printf("📍 Available endpoints:\n")
-	fmt.Printf("   • http://localhost:%s/ (HTML Dashboard)\n", port)
-	fmt.Printf("   • http://localhost:%s/health (Health check)\n", port)
-	fmt.Printf("   • http://localhost:%s/welcome (API welcome)\n", port)
-	fmt.Printf("   • http://localhost:%s/api/users (Users API)\n", port)
-	fmt.Printf("   • http://localhost:%s/api/users/stats (Users statistics)\n", port)
-	fmt.Printf("   • http://localhost:%s/static/* (Static files)\n", port)
-	fmt.Printf("\n💾 Database: MySQL with environment configuration\n")
-	fmt.Printf("💡 Press Ctrl+C to stop the server\n")
-	fmt.Printf("🌐 Open http://localhost:%s in your browser to use the dashboard\n\n", port)
-
-	// Start server
-	log.Fatal(server.ListenAndServe())
+	// WebSocket connections are hijacked, so server.Shutdown does not wait for them
+	liveHub.Shutdown()
+
+	var forced bool
+	for _, server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			forced = true
+			server.Close()
+		}
+	}
+
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			forced = true
+			grpcServer.Stop()
+		}
+	}
+
+	stopWorkers()
+
+	if forced {
+		return fmt.Errorf("shutdown did not finish in time; active requests were dropped")
+	}
+	log.Println("✅ Server stopped")
+	return nil
 }
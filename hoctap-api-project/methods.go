@@ -0,0 +1,115 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hoctap-api/i18n"
+
+	"github.com/gorilla/mux"
+)
+
+// probedMethods are the methods probed against the route table to build Allow headers
+var probedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+
+// headWriter serves a HEAD request through its GET route: the body is counted for the
+// Content-Length header and then dropped
+type headWriter struct {
+	http.ResponseWriter
+	status int
+	length int
+}
+
+// Unwrap exposes the underlying writer to content negotiation and http.ResponseController
+func (w *headWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// WriteHeader holds the status back until the body length is known
+func (w *headWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+// Write counts and discards the body
+func (w *headWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.length += len(data)
+	return len(data), nil
+}
+
+// Helper function to send the headers of the GET response
+func (w *headWriter) finish() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.Header().Get("Content-Length") == "" && w.Header().Get("Transfer-Encoding") == "" {
+		w.Header().Set("Content-Length", strconv.Itoa(w.length))
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// Helper function to list the methods router serves for the path of r, from its route table
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var allowed []string
+	for _, method := range probedMethods {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+
+		var match mux.RouteMatch
+		if router.Match(probe, &match) && match.MatchErr == nil {
+			allowed = append(allowed, method)
+			if method == http.MethodGet {
+				allowed = append(allowed, http.MethodHead)
+			}
+		}
+	}
+	return append(allowed, http.MethodOptions)
+}
+
+// Wrap router to answer methods from its route table: HEAD is served by the GET route
+// without a body, OPTIONS lists the allowed methods (also as the CORS preflight answer)
+// and methods a path has no route for get 405 with an Allow header. mux cannot do this
+// itself, as a later route in a subrouter hides the method mismatch of an earlier one.
+func serveRouteMethods(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var match mux.RouteMatch
+		if r.Method != http.MethodOptions && router.Match(r, &match) && match.MatchErr == nil {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		allowed := allowedMethods(router, r)
+		if len(allowed) == 1 {
+			// Only OPTIONS: no route has this path
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		if r.Method == http.MethodHead && slices.Contains(allowed, http.MethodGet) {
+			get := r.Clone(r.Context())
+			get.Method = http.MethodGet
+
+			head := &headWriter{ResponseWriter: w}
+			router.ServeHTTP(head, get)
+			head.finish()
+			return
+		}
+
+		setCORSHeaders(w)
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowed, ", "))
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		sendJSONResponse(w, http.StatusMethodNotAllowed, i18n.M("method_not_allowed"), nil)
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"expvar"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/watchdog"
+)
+
+// leakWatchdog samples goroutines, database connections and file descriptors; nil when
+// WATCHDOG_INTERVAL is 0
+var leakWatchdog *watchdog.Watchdog
+
+// Helper function to start the leak watchdog and publish its samples with the runtime
+// metrics of /debug/vars. It returns a function stopping the watchdog.
+func startWatchdog(interval time.Duration, window int) func() {
+	if interval <= 0 {
+		return func() {}
+	}
+
+	leakWatchdog = watchdog.New(window, openDBConnections)
+	leakWatchdog.Start(interval)
+	expvar.Publish("watchdog", expvar.Func(func() any { return leakWatchdog.Status() }))
+	return leakWatchdog.Stop
+}
+
+// Helper function to count the open connections of the active database, 0 without one
+func openDBConnections() int {
+	if database.DB == nil {
+		return 0
+	}
+	return database.DB.Stats().OpenConnections
+}
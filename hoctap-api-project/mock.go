@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"time"
+
+	"hoctap-api/config"
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+
+	"github.com/gorilla/mux"
+)
+
+// mockSeed makes `serve -mock` generate the same users on every start
+const mockSeed = 1
+
+// mockFamilyNames and mockGivenNames build the generated users; each entry pairs the display
+// name with its ASCII form for the email address
+var (
+	mockFamilyNames = [][2]string{
+		{"Nguyễn", "nguyen"}, {"Trần", "tran"}, {"Lê", "le"}, {"Phạm", "pham"}, {"Hoàng", "hoang"},
+		{"Huỳnh", "huynh"}, {"Phan", "phan"}, {"Vũ", "vu"}, {"Võ", "vo"}, {"Đặng", "dang"},
+		{"Bùi", "bui"}, {"Đỗ", "do"}, {"Hồ", "ho"}, {"Ngô", "ngo"}, {"Dương", "duong"},
+	}
+	mockGivenNames = [][2]string{
+		{"Văn An", "an"}, {"Thị Bình", "binh"}, {"Minh Châu", "chau"}, {"Quốc Dũng", "dung"},
+		{"Thu Hà", "ha"}, {"Gia Huy", "huy"}, {"Ngọc Lan", "lan"}, {"Đức Long", "long"},
+		{"Thảo My", "my"}, {"Hoài Nam", "nam"}, {"Bảo Ngọc", "ngoc"}, {"Thanh Phong", "phong"},
+		{"Minh Quân", "quan"}, {"Kim Sơn", "son"}, {"Anh Thư", "thu"}, {"Xuân Trường", "truong"},
+		{"Khánh Vy", "vy"}, {"Hải Yến", "yen"},
+	}
+	mockEmailDomains = []string{"example.com", "example.edu.vn", "mail.example.org"}
+)
+
+// Helper function to generate count users created over the past year
+func generateMockUsers(count int) []database.User {
+	rng := rand.New(rand.NewPCG(mockSeed, mockSeed))
+	now := time.Now()
+
+	users := make([]database.User, 0, count)
+	for i := 1; i <= count; i++ {
+		family := mockFamilyNames[rng.IntN(len(mockFamilyNames))]
+		given := mockGivenNames[rng.IntN(len(mockGivenNames))]
+		domain := mockEmailDomains[rng.IntN(len(mockEmailDomains))]
+
+		createdAt := now.Add(-time.Duration(rng.Int64N(int64(365 * 24 * time.Hour)))).Truncate(time.Second)
+		updatedAt := createdAt
+		if rng.IntN(3) == 0 {
+			updatedAt = createdAt.Add(time.Duration(rng.Int64N(int64(now.Sub(createdAt))))).Truncate(time.Second)
+		}
+
+		users = append(users, database.User{
+			ID:        i,
+			Name:      family[0] + " " + given[0],
+			Email:     fmt.Sprintf("%s.%s%d@%s", given[1], family[1], i, domain),
+			CreatedAt: createdAt,
+			UpdatedAt: updatedAt,
+		})
+	}
+
+	// A few held users exercise the legal hold paths of the dashboard
+	for i := 0; i < len(users); i += 20 {
+		users[i].LegalHold = true
+		users[i].LegalHoldReason = "Pending dispute (mock data)"
+	}
+
+	return users
+}
+
+// Helper function to serve generated users from memory instead of the database. It returns
+// a function stopping the (absent) background workers.
+func startMockServices(cfg config.MockConfig) func() {
+	userRepo = database.NewMemoryUserStore(generateMockUsers(cfg.Users)...)
+
+	log.Printf("🧪 Mock mode: %d generated users, latency up to %s, error rate %.0f%%",
+		cfg.Users, cfg.Latency, cfg.ErrorRate*100)
+	return func() {}
+}
+
+// mockRouteServed tells whether a route (template relative to the API version prefix) is
+// backed by the user store and therefore works in mock mode
+func mockRouteServed(template string) bool {
+	switch {
+	case template == "/graphql", template == "/qr", template == "/public/stats", template == "/analytics/summary", template == "/admin/boot-report":
+		return true
+	case strings.HasSuffix(template, "/experiments"), strings.HasSuffix(template, "/activity"):
+		return false
+	default:
+		return template == "/users" || strings.HasPrefix(template, "/users/")
+	}
+}
+
+// Middleware simulating a real backend in mock mode: API routes wait a random time up to
+// cfg.Latency and fail with a 500 at cfg.ErrorRate. Routes that need the database answer 503.
+func mockAPI(cfg config.MockConfig) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/graphql" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			template := r.URL.Path
+			if route := mux.CurrentRoute(r); route != nil {
+				template, _ = route.GetPathTemplate()
+			}
+			template = strings.TrimPrefix(strings.TrimPrefix(template, "/api/v1"), "/api")
+			if !mockRouteServed(template) {
+				sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("not_available_in_mock_mode"), nil)
+				return
+			}
+
+			if cfg.Latency > 0 {
+				select {
+				case <-time.After(rand.N(cfg.Latency)):
+				case <-r.Context().Done():
+					return
+				}
+			}
+
+			if rand.Float64() < cfg.ErrorRate {
+				sendJSONResponse(w, http.StatusInternalServerError, i18n.M("simulated_failure"), nil)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
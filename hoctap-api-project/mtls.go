@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"hoctap-api/config"
+)
+
+// clientIdentityContextKey carries the common name of a verified client certificate
+type clientIdentityContextKey struct{}
+
+// Helper function to require client certificates signed by one of clientCAs on a listener
+// already serving TLS
+func requireClientCerts(server *http.Server, clientCAs *x509.CertPool) {
+	tlsConfig := server.TLSConfig.Clone()
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsConfig.ClientCAs = clientCAs
+	server.TLSConfig = tlsConfig
+}
+
+// Helper function to build the CA pool from a PEM bundle given inline or as a file path
+func loadClientCAs(bundle string) (*x509.CertPool, error) {
+	pem := []byte(bundle)
+	if !strings.HasPrefix(strings.TrimSpace(bundle), "-----BEGIN") {
+		data, err := os.ReadFile(bundle)
+		if err != nil {
+			return nil, errors.New("failed to read MTLS_CLIENT_CA: " + err.Error())
+		}
+		pem = data
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("MTLS_CLIENT_CA contains no PEM certificates")
+	}
+	return pool, nil
+}
+
+// Middleware putting the common name of a verified client certificate into the request
+// context. Requests without one (plain HTTP, or TLS without mTLS) pass through unchanged.
+func withClientIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+			if name := r.TLS.VerifiedChains[0][0].Subject.CommonName; name != "" {
+				r = r.WithContext(context.WithValue(r.Context(), clientIdentityContextKey{}, name))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Helper function to get the client certificate identity of a request, if any
+func clientIdentity(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(clientIdentityContextKey{}).(string)
+	return name, ok
+}
+
+// Helper function to tell whether the request comes from a service allowed on the admin
+// endpoints by MTLS_ADMIN_IDENTITIES
+func isAdminIdentity(r *http.Request) bool {
+	name, ok := clientIdentity(r.Context())
+	return ok && slices.Contains(config.Current().MTLS.AdminIdentities, name)
+}
@@ -0,0 +1,470 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"hoctap-api/i18n"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Supported representations
+const (
+	formatJSON    = "json"
+	formatXML     = "xml"
+	formatMsgPack = "msgpack"
+	formatJSONAPI = "jsonapi"
+)
+
+// mediaTypeFormats maps accepted media types to representations
+var mediaTypeFormats = map[string]string{
+	"application/json":        formatJSON,
+	"application/xml":         formatXML,
+	"text/xml":                formatXML,
+	"application/msgpack":     formatMsgPack,
+	"application/x-msgpack":   formatMsgPack,
+	"application/vnd.msgpack": formatMsgPack,
+	jsonAPIMediaType:          formatJSONAPI,
+}
+
+// formatContentTypes is the Content-Type written for each representation
+var formatContentTypes = map[string]string{
+	formatJSON:    "application/json",
+	formatXML:     "application/xml; charset=utf-8",
+	formatMsgPack: "application/msgpack",
+	formatJSONAPI: jsonAPIMediaType,
+}
+
+// messageCatalog translates response messages into the languages clients accept
+var messageCatalog = i18n.MustLoad()
+
+// negotiatedWriter carries the response format chosen from the Accept header and the
+// message language chosen from the Accept-Language header
+type negotiatedWriter struct {
+	http.ResponseWriter
+	format   string
+	language string
+	// self is the request URI, used as the JSON:API self link
+	self string
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *negotiatedWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack keeps WebSocket upgrades working through the wrapper
+func (w *negotiatedWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return hijacker.Hijack()
+}
+
+// Flush keeps streaming responses working through the wrapper
+func (w *negotiatedWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Middleware choosing the response representation from the Accept header and the message
+// language from the Accept-Language header
+func negotiateContent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept, Accept-Language")
+		next.ServeHTTP(&negotiatedWriter{
+			ResponseWriter: w,
+			format:         negotiateFormat(r.Header.Get("Accept")),
+			language:       messageCatalog.Negotiate(r.Header.Get("Accept-Language")),
+			self:           r.URL.RequestURI(),
+		}, r)
+	})
+}
+
+// Helper function to pick the supported format with the highest quality in an Accept header
+func negotiateFormat(accept string) string {
+	best, bestQuality := formatJSON, 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		format, ok := mediaTypeFormats[mediaType]
+		if !ok {
+			continue
+		}
+		quality := 1.0
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			quality = q
+		}
+		if quality > bestQuality {
+			best, bestQuality = format, quality
+		}
+	}
+	return best
+}
+
+// Helper function to find the negotiated writer behind a (possibly wrapped) response writer
+func negotiated(w http.ResponseWriter) *negotiatedWriter {
+	for {
+		switch writer := w.(type) {
+		case *negotiatedWriter:
+			return writer
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = writer.Unwrap()
+		default:
+			return &negotiatedWriter{ResponseWriter: w, format: formatJSON, language: i18n.DefaultLanguage}
+		}
+	}
+}
+
+// Helper function to encode a response envelope in the negotiated format, with message in
+// the negotiated language. Errors get the message's code unless they carry one already.
+func writeResponse(w http.ResponseWriter, statusCode int, message i18n.Message, response Response) {
+	negotiation := negotiated(w)
+
+	response.Message = messageCatalog.Localize(message, negotiation.language)
+	if response.Code == "" && statusCode >= 400 {
+		response.Code = message.Code
+	}
+
+	w.Header().Set("Content-Type", formatContentTypes[negotiation.format])
+	w.Header().Set("Content-Language", negotiation.language)
+	w.WriteHeader(statusCode)
+
+	switch negotiation.format {
+	case formatJSONAPI:
+		writeJSONAPIResponse(w, statusCode, response, negotiation.self)
+	case formatXML:
+		writeXMLResponse(w, response)
+	case formatMsgPack:
+		encoder := msgpack.NewEncoder(w)
+		encoder.SetCustomStructTag("json")
+		encoder.Encode(response)
+	default:
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// Helper function to decode a request body sent as JSON, JSON:API, XML or MessagePack (by Content-Type)
+// into v, honoring v's JSON field names in every format
+func decodeRequestBody(r *http.Request, v interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	switch mediaTypeFormats[mediaType] {
+	case formatJSONAPI:
+		return decodeJSONAPIBody(r, v)
+	case formatXML:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		return decodeXML(data, v)
+	case formatMsgPack:
+		// Decode generically and re-map through encoding/json so json.RawMessage,
+		// time.Time and embedded structs behave exactly as they do for JSON bodies
+		var generic interface{}
+		if err := msgpack.NewDecoder(r.Body).Decode(&generic); err != nil {
+			return err
+		}
+		data, err := json.Marshal(generic)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, v)
+	default:
+		return json.NewDecoder(r.Body).Decode(v)
+	}
+}
+
+// XML responses are produced from the JSON representation, so element names match JSON
+// field names: objects become child elements and arrays become repeated <item> elements.
+func writeXMLResponse(w io.Writer, response Response) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+
+	var generic interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return
+	}
+
+	encoder := xml.NewEncoder(w)
+	io.WriteString(w, xml.Header)
+	writeXMLValue(encoder, "response", generic)
+	encoder.Flush()
+}
+
+// Helper function to write a generic JSON value as an XML element
+func writeXMLValue(encoder *xml.Encoder, name string, value interface{}) {
+	start := xml.StartElement{Name: xml.Name{Local: xmlElementName(name)}}
+
+	switch v := value.(type) {
+	case nil:
+		start.Attr = []xml.Attr{{Name: xml.Name{Local: "nil"}, Value: "true"}}
+		encoder.EncodeToken(start)
+	case map[string]interface{}:
+		encoder.EncodeToken(start)
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			writeXMLValue(encoder, key, v[key])
+		}
+	case []interface{}:
+		encoder.EncodeToken(start)
+		for _, item := range v {
+			writeXMLValue(encoder, "item", item)
+		}
+	default:
+		encoder.EncodeToken(start)
+		encoder.EncodeToken(xml.CharData(fmt.Sprint(v)))
+	}
+
+	encoder.EncodeToken(start.End())
+}
+
+// Helper function to turn a JSON key into a valid XML element name
+func xmlElementName(key string) string {
+	var b strings.Builder
+	for i, c := range key {
+		valid := c == '_' || c == '-' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !valid || (i == 0 && (c == '-' || c == '.' || (c >= '0' && c <= '9'))) {
+			b.WriteByte('_')
+			if valid {
+				b.WriteRune(c)
+			}
+			continue
+		}
+		b.WriteRune(c)
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
+
+// xmlNode is a parsed XML element
+type xmlNode struct {
+	name     string
+	text     string
+	children []*xmlNode
+}
+
+// Helper function to decode an XML document into v using v's JSON field names
+func decodeXML(data []byte, v interface{}) error {
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return err
+	}
+	return assignXML(root, reflect.ValueOf(v).Elem())
+}
+
+// Helper function to parse an XML document into a tree of elements
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var stack []*xmlNode
+	var root *xmlNode
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{name: t.Name.Local}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].text += string(t)
+			}
+		}
+	}
+
+	if root == nil {
+		return nil, errors.New("empty XML document")
+	}
+	return root, nil
+}
+
+// Helper function to assign an XML element to a Go value, converting text by the target kind
+func assignXML(node *xmlNode, v reflect.Value) error {
+	text := strings.TrimSpace(node.text)
+
+	switch {
+	case v.Type() == reflect.TypeOf(time.Time{}):
+		parsed, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return fmt.Errorf("<%s>: %v", node.name, err)
+		}
+		v.Set(reflect.ValueOf(parsed))
+		return nil
+	case v.Type() == reflect.TypeOf(json.RawMessage{}):
+		data, err := json.Marshal(xmlToGeneric(node))
+		if err != nil {
+			return err
+		}
+		v.SetBytes(data)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return assignXML(node, v.Elem())
+	case reflect.Struct:
+		return assignXMLStruct(node, v)
+	case reflect.Slice:
+		items := node.children
+		slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, child := range items {
+			if err := assignXML(child, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	case reflect.String:
+		v.SetString(text)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(text)
+		if err != nil {
+			return fmt.Errorf("<%s>: expected a boolean", node.name)
+		}
+		v.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		parsed, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return fmt.Errorf("<%s>: expected an integer", node.name)
+		}
+		v.SetInt(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return fmt.Errorf("<%s>: expected a number", node.name)
+		}
+		v.SetFloat(parsed)
+	case reflect.Interface, reflect.Map:
+		generic := reflect.ValueOf(xmlToGeneric(node))
+		if !generic.Type().AssignableTo(v.Type()) {
+			return fmt.Errorf("<%s>: unsupported value", node.name)
+		}
+		v.Set(generic)
+	default:
+		return fmt.Errorf("<%s>: unsupported field type %s", node.name, v.Type())
+	}
+	return nil
+}
+
+// Helper function to assign child elements to struct fields by their JSON names.
+// A slice field accepts either repeated elements or a wrapper element with <item> children.
+func assignXMLStruct(node *xmlNode, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && field.Tag.Get("json") == "" {
+			// Embedded structs are flattened, as encoding/json does
+			if err := assignXMLStruct(node, v.Field(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if name == "-" {
+			continue
+		}
+
+		var matches []*xmlNode
+		for _, child := range node.children {
+			if child.name == name {
+				matches = append(matches, child)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		target := v.Field(i)
+		isList := field.Type.Kind() == reflect.Slice && field.Type != reflect.TypeOf(json.RawMessage{})
+		if isList && (len(matches) > 1 || len(matches[0].children) == 0) {
+			// Repeated elements: <events>a</events><events>b</events>
+			if err := assignXML(&xmlNode{name: name, children: matches}, target); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := assignXML(matches[0], target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper function to convert an element into generic JSON-like values
+func xmlToGeneric(node *xmlNode) interface{} {
+	if len(node.children) == 0 {
+		return strings.TrimSpace(node.text)
+	}
+
+	allItems := true
+	for _, child := range node.children {
+		if child.name != "item" {
+			allItems = false
+		}
+	}
+	if allItems {
+		list := make([]interface{}, 0, len(node.children))
+		for _, child := range node.children {
+			list = append(list, xmlToGeneric(child))
+		}
+		return list
+	}
+
+	object := map[string]interface{}{}
+	for _, child := range node.children {
+		object[child.name] = xmlToGeneric(child)
+	}
+	return object
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+
+	"github.com/gorilla/mux"
+)
+
+// Page size limits for GET /notifications
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+// Get a page of a user's notifications, newest first (?user_id=, optionally ?unread=true,
+// ?limit= and ?before= the next_before of the previous page)
+func getNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := notificationUserID(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	filter := database.NotificationFilter{
+		UserID:     userID,
+		UnreadOnly: query.Get("unread") == "true",
+		Limit:      defaultNotificationPageSize,
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxNotificationPageSize {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_limit_100"), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("before"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || before <= 0 {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_notification_before"), nil)
+			return
+		}
+		filter.Before = before
+	}
+
+	// Fetch one extra notification to know whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	notifications, err := notificationRepo.GetNotifications(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error getting notifications: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("notifications_retrieve_failed"), nil)
+		return
+	}
+
+	var nextBefore interface{}
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		nextBefore = notifications[limit-1].ID
+	}
+
+	sendJSONResponseWithMeta(w, http.StatusOK, i18n.M("notifications_retrieved"), notifications, map[string]interface{}{
+		"limit":       limit,
+		"next_before": nextBefore,
+	})
+}
+
+// Count a user's unread notifications (?user_id=), for the badge on the bell icon
+func getUnreadNotificationCountHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := notificationUserID(w, r)
+	if !ok {
+		return
+	}
+
+	count, err := notificationRepo.GetUnreadCount(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error counting unread notifications: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("notifications_count_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("notifications_unread_counted"), map[string]interface{}{
+		"user_id": userID,
+		"unread":  count,
+	})
+}
+
+// Mark a notification as read
+func markNotificationReadHandler(w http.ResponseWriter, r *http.Request) {
+	notificationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_notification_id"), nil)
+		return
+	}
+
+	notification, err := notificationRepo.MarkRead(r.Context(), notificationID)
+	if err != nil {
+		log.Printf("Error marking notification as read: %v", err)
+		if errors.Is(err, database.ErrNotificationNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("notification_not_found", "id", notificationID), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("notification_mark_read_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("notification_marked_read"), notification)
+}
+
+// Mark every unread notification of a user as read (?user_id=)
+func markAllNotificationsReadHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := notificationUserID(w, r)
+	if !ok {
+		return
+	}
+
+	marked, err := notificationRepo.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error marking notifications as read: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("notifications_mark_read_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("notifications_marked_read"), map[string]interface{}{
+		"user_id": userID,
+		"marked":  marked,
+	})
+}
+
+// Get a user's delivery channels for every notification type
+func getNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := notificationPreferencesUser(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := notificationPreferences(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting notification preferences: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("notification_preferences_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("notification_preferences_retrieved"), preferences)
+}
+
+// Choose the delivery channels of some notification types; types left out keep theirs
+func saveNotificationPreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := notificationPreferencesUser(w, r)
+	if !ok {
+		return
+	}
+
+	var input []database.NotificationPreference
+	if err := decodeRequestBody(r, &input); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_notification_preferences"), nil)
+		return
+	}
+	for _, preference := range input {
+		if _, ok := notificationTypes[preference.Type]; !ok {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("unknown_notification_type", "type", preference.Type), nil)
+			return
+		}
+	}
+
+	if err := notificationRepo.SavePreferences(r.Context(), userID, input); err != nil {
+		log.Printf("Error saving notification preferences: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("notification_preferences_save_failed"), nil)
+		return
+	}
+
+	preferences, err := notificationPreferences(r.Context(), userID)
+	if err != nil {
+		log.Printf("Error getting notification preferences: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("notification_preferences_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("notification_preferences_saved"), preferences)
+}
+
+// Helper function to read the required ?user_id= of the notification endpoints, answering
+// 400 when it is missing or invalid
+func notificationUserID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, err := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if err != nil || userID <= 0 {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("notification_user_id_required"), nil)
+		return 0, false
+	}
+	return userID, true
+}
+
+// Helper function to read the user of the notification preference endpoints, answering
+// 404 when the user does not exist
+func notificationPreferencesUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := userIDParam(w, r)
+	if !ok {
+		return 0, false
+	}
+
+	if _, err := userRepo.GetUserByID(r.Context(), userID); err != nil {
+		sendUserLookupError(w, userID, err)
+		return 0, false
+	}
+	return userID, true
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sort"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/mailer"
+)
+
+// Notification types users can set delivery preferences for
+const (
+	notificationWelcome        = "account.welcome"
+	notificationProfileUpdated = "account.profile_updated"
+)
+
+// notificationTimeout bounds the preference lookup and insert of one notification
+const notificationTimeout = 5 * time.Second
+
+// notificationType is a kind of notification with the channels it uses until the user
+// chooses otherwise, and the email template it is sent with
+type notificationType struct {
+	email        string
+	defaultEmail bool
+	defaultInApp bool
+}
+
+// notificationTypes lists every notification type. A feature adds its types here and
+// calls notifyUser; the user's preferences decide where each notification goes.
+var notificationTypes = map[string]notificationType{
+	notificationWelcome:        {email: mailer.EmailWelcome, defaultEmail: true, defaultInApp: true},
+	notificationProfileUpdated: {email: mailer.EmailNotification, defaultEmail: true, defaultInApp: true},
+}
+
+// notificationRepo stores in-app notifications and preferences; nil in mock mode, where
+// notifications are only emailed
+var notificationRepo *database.NotificationRepository
+
+// notification is the content of one notification, the same on every channel
+type notification struct {
+	Title string
+	Body  string
+	Link  string
+}
+
+// Helper function to deliver a notification of a type to a user, in the app and by email
+// as the user's preferences for that type say. Failures are logged, never returned: a
+// notification must not fail the change that caused it.
+func notifyUser(user *database.User, kind string, n notification) {
+	ctx, cancel := context.WithTimeout(context.Background(), notificationTimeout)
+	defer cancel()
+
+	preference, err := notificationPreference(ctx, user.ID, kind)
+	if err != nil {
+		log.Printf("⚠️ Warning: using default notification preferences for user %d: %v", user.ID, err)
+	}
+
+	if preference.InApp && notificationRepo != nil {
+		if _, err := notificationRepo.CreateNotification(ctx, user.ID, kind, n.Title, n.Body, n.Link); err != nil {
+			log.Printf("⚠️ Warning: %s notification for user %d not stored: %v", kind, user.ID, err)
+		}
+	}
+
+	if preference.Email && accountMailer != nil {
+		data := mailer.Data{Name: user.Name, Title: n.Title, Body: n.Body, Link: n.Link}
+		if err := sendAccountEmail(user.Email, notificationTypes[kind].email, data); err != nil {
+			log.Printf("⚠️ Warning: %s email to user %d not sent: %v", kind, user.ID, err)
+		}
+	}
+}
+
+// Helper function to get a user's channels for one notification type; on error the
+// defaults are returned with it
+func notificationPreference(ctx context.Context, userID int, kind string) (database.NotificationPreference, error) {
+	preferences, err := notificationPreferences(ctx, userID)
+	for _, preference := range preferences {
+		if preference.Type == kind {
+			return preference, err
+		}
+	}
+	return database.NotificationPreference{Type: kind}, err
+}
+
+// Helper function to list a user's channels for every notification type, sorted by type:
+// the saved preferences, and the defaults for types the user never changed. On error the
+// defaults are returned with it.
+func notificationPreferences(ctx context.Context, userID int) ([]database.NotificationPreference, error) {
+	var saved map[string]database.NotificationPreference
+	var err error
+	if notificationRepo != nil {
+		saved, err = notificationRepo.GetPreferences(ctx, userID)
+	}
+
+	preferences := make([]database.NotificationPreference, 0, len(notificationTypes))
+	for kind, defaults := range notificationTypes {
+		preference, ok := saved[kind]
+		if !ok {
+			preference = database.NotificationPreference{Type: kind, Email: defaults.defaultEmail, InApp: defaults.defaultInApp}
+		}
+		preferences = append(preferences, preference)
+	}
+	sort.Slice(preferences, func(i, j int) bool { return preferences[i].Type < preferences[j].Type })
+	return preferences, err
+}
+
+// Helper function to welcome a new user
+func notifyWelcome(user *database.User) {
+	notifyUser(user, notificationWelcome, notification{
+		Title: "Welcome to HocTap",
+		Body:  "Your account is ready. Start learning whenever you like.",
+	})
+}
+
+// Helper function to tell a user their name or email address changed, so a change they did
+// not make does not go unnoticed
+func notifyProfileUpdated(user *database.User) {
+	notifyUser(user, notificationProfileUpdated, notification{
+		Title: "Your profile was updated",
+		Body:  "The name or email address of your HocTap account was changed. If you did not make this change, contact support.",
+	})
+}
@@ -0,0 +1,469 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/retention"
+	"hoctap-api/scheduler"
+
+	"github.com/gorilla/mux"
+)
+
+// paramDoc documents a query parameter
+type paramDoc struct {
+	Name        string
+	Type        string
+	Description string
+}
+
+// routeDoc documents a route for the OpenAPI spec. Request, Response and Meta are example
+// values whose types are reflected into JSON schemas; Response is the envelope's data field
+// and Meta its meta field. Upload names the file field of a multipart/form-data request.
+type routeDoc struct {
+	Summary     string
+	Tag         string
+	Admin       bool
+	Query       []paramDoc
+	Request     interface{}
+	Upload      string
+	Response    interface{}
+	Meta        interface{}
+	Status      int
+	ContentType string
+}
+
+// routeDocs describes the routes registered in main, keyed by "METHOD /path/{param}"
+var routeDocs = map[string]routeDoc{
+	"GET /health":         {Summary: "Health check with dependency status, latency and last success", Tag: "System", Response: map[string]interface{}{}},
+	"GET /livez":          {Summary: "Liveness probe: the process is up", Tag: "System", Response: map[string]interface{}{}},
+	"GET /readyz":         {Summary: "Readiness probe: database, migrations and caches are ready (503 otherwise)", Tag: "System", Response: map[string]interface{}{}},
+	"GET /startupz":       {Summary: "Startup probe: startup has finished (503 until then)", Tag: "System", Response: map[string]interface{}{}},
+	"GET /lb-health":      {Summary: "Load balancer health: 503 from the moment shutdown begins", Tag: "System", Response: map[string]interface{}{}},
+	"GET /welcome":        {Summary: "API welcome message and endpoint list", Tag: "System", Response: map[string]interface{}{}},
+	"GET /openapi.json":   {Summary: "This OpenAPI document", Tag: "System", ContentType: "application/json"},
+	"GET /docs":           {Summary: "Interactive Swagger UI", Tag: "System", ContentType: "text/html"},
+	"GET /s/{code}":       {Summary: "Follow a short link", Tag: "Short links", Status: http.StatusFound},
+	"GET /avatars/{file}": {Summary: "Redirect to a signed download URL of a stored avatar image", Tag: "Users", Status: http.StatusFound},
+	"GET /files/{key}": {Summary: "Download a file of the local storage backend through a signed URL", Tag: "Files", ContentType: "application/octet-stream", Query: []paramDoc{
+		{"expires", "integer", "Unix time the URL expires at"},
+		{"signature", "string", "HMAC of the key and expiry"},
+	}},
+	"GET /ws":       {Summary: "WebSocket stream of live user and stats updates", Tag: "Live updates", Status: http.StatusSwitchingProtocols},
+	"GET /graphql":  {Summary: "Execute a GraphQL query from ?query=", Tag: "GraphQL", ContentType: "application/json"},
+	"POST /graphql": {Summary: "Execute a GraphQL query or mutation", Tag: "GraphQL", ContentType: "application/json"},
+
+	"GET /api/v1/users": {Summary: "Get all users, or a page of them with limit or cursor", Tag: "Users", Response: []database.User{}, Meta: pageMetaDoc{}, Query: []paramDoc{
+		{"limit", "integer", "Page size (default 20, max 100); enables pagination"},
+		{"cursor", "string", "Opaque next_cursor of the previous page; enables pagination"},
+		{"search", "string", "Filter paginated users by name or email"},
+		{"fields", "string", "Comma-separated fields to return, e.g. id,name (id and uuid are always included)"},
+	}},
+	"POST /api/v1/users": {Summary: "Create a new user", Tag: "Users", Request: userInput{}, Response: database.User{}, Status: http.StatusCreated},
+	"GET /api/v1/users/stats": {Summary: "Get user statistics", Tag: "Users", Response: map[string]interface{}{}, Query: []paramDoc{
+		{"tz", "string", "IANA time zone the day of signups_today and the signups buckets are counted in, e.g. Asia/Ho_Chi_Minh; also read from X-Timezone (default UTC)"},
+		{"interval", "string", "Adds signups per day, week or month (default day when from or to is given)"},
+		{"from", "string", "First day of the signups series, YYYY-MM-DD (default 30 days, 12 weeks or 12 months before to)"},
+		{"to", "string", "Last day of the signups series, YYYY-MM-DD (default today)"},
+	}},
+	"GET /api/v1/users/search": {Summary: "Typeahead search: users whose name or email starts with q, ordered by name", Tag: "Users", Response: []userSuggestion{}, Query: []paramDoc{
+		{"q", "string", "Name or email prefix, ignoring case (required)"},
+		{"limit", "integer", "Maximum results (default 10, max 50)"},
+	}},
+	"GET /api/v1/analytics/summary": {Summary: "Dashboard analytics: user totals, new users, 30-day growth and recent signups (cached)", Tag: "Users", Response: analyticsSummary{}},
+	"GET /api/v1/users/{id}": {Summary: "Get user by ID", Tag: "Users", Response: database.User{}, Query: []paramDoc{
+		{"fields", "string", "Comma-separated fields to return, e.g. id,name (id and uuid are always included)"},
+	}},
+	"PUT /api/v1/users/{id}":         {Summary: "Update user by ID", Tag: "Users", Request: userInput{}, Response: database.User{}},
+	"DELETE /api/v1/users/{id}":      {Summary: "Delete user by ID", Tag: "Users"},
+	"GET /api/v1/users/{id}/avatar":  {Summary: "Redirect to the user's avatar image", Tag: "Users", Status: http.StatusFound},
+	"POST /api/v1/users/{id}/avatar": {Summary: "Upload an avatar (JPEG, PNG or GIF; cropped and scaled to a square JPEG)", Tag: "Users", Upload: "avatar", Response: database.User{}},
+	"GET /api/v1/users/{id}/activity": {Summary: "Get a page of the user's activity feed, newest first", Tag: "Administration", Admin: true, Response: []database.Activity{}, Meta: notificationPageMetaDoc{}, Query: []paramDoc{
+		{"limit", "integer", "Page size (default 20, max 100)"},
+		{"before", "integer", "next_before of the previous page"},
+	}},
+	"PUT /api/v1/users/{id}/legal-hold":  {Summary: "Place or lift a legal hold on a user", Tag: "Administration", Admin: true, Request: legalHoldInput{}, Response: database.User{}},
+	"GET /api/v1/users/{id}/experiments": {Summary: "Get the user's experiment variants", Tag: "Experiments", Response: []experimentsAssignmentDoc{}},
+
+	"GET /api/v1/notifications": {Summary: "Get a page of a user's notifications, newest first", Tag: "Notifications", Response: []database.Notification{}, Meta: notificationPageMetaDoc{}, Query: []paramDoc{
+		{"user_id", "integer", "User whose notifications to return (required)"},
+		{"unread", "boolean", "Only unread notifications"},
+		{"limit", "integer", "Page size (default 20, max 100)"},
+		{"before", "integer", "next_before of the previous page"},
+	}},
+	"GET /api/v1/notifications/unread-count": {Summary: "Count a user's unread notifications", Tag: "Notifications", Response: map[string]interface{}{}, Query: []paramDoc{
+		{"user_id", "integer", "User whose notifications to count (required)"},
+	}},
+	"POST /api/v1/notifications/read-all": {Summary: "Mark all of a user's notifications as read", Tag: "Notifications", Response: map[string]interface{}{}, Query: []paramDoc{
+		{"user_id", "integer", "User whose notifications to mark (required)"},
+	}},
+	"POST /api/v1/notifications/{id}/read":            {Summary: "Mark a notification as read", Tag: "Notifications", Response: database.Notification{}},
+	"GET /api/v1/users/{id}/notification-preferences": {Summary: "Get the user's email and in-app channels per notification type", Tag: "Notifications", Response: []database.NotificationPreference{}},
+	"PUT /api/v1/users/{id}/notification-preferences": {Summary: "Choose the channels of some notification types", Tag: "Notifications", Request: []database.NotificationPreference{}, Response: []database.NotificationPreference{}},
+
+	"GET /api/v1/public/stats": {Summary: "Public platform stats for partners (X-API-Key, hourly quota per key, cached)", Tag: "Public", Response: publicStatsDoc{}},
+
+	"GET /api/v1/audit": {Summary: "Query the audit log", Tag: "Administration", Admin: true, Response: []database.AuditEntry{}, Query: []paramDoc{
+		{"actor", "string", "Filter by actor"},
+		{"action", "string", "Filter by action (create, update, delete)"},
+		{"entity", "string", "Filter by entity name"},
+		{"entity_id", "integer", "Filter by entity ID"},
+		{"from", "string", "Only entries at or after this RFC3339 time"},
+		{"to", "string", "Only entries at or before this RFC3339 time"},
+		{"limit", "integer", "Maximum entries (default 100, max 500)"},
+	}},
+	"GET /api/v1/qr": {Summary: "Generate a QR code", Tag: "Utilities", ContentType: "image/png", Query: []paramDoc{
+		{"data", "string", "Content to encode (required)"},
+		{"format", "string", "png (default) or svg"},
+		{"size", "integer", "Image size in pixels (64-1024)"},
+		{"ec", "string", "Error correction level: L, M (default), Q or H"},
+	}},
+	"POST /api/v1/events/track": {Summary: "Track client analytics events", Tag: "Event tracking", Request: trackedEventInput{}, Response: map[string]interface{}{}, Status: http.StatusAccepted},
+
+	"GET /api/v1/webhooks":                 {Summary: "List registered webhooks", Tag: "Webhooks", Admin: true, Response: []database.Webhook{}},
+	"POST /api/v1/webhooks":                {Summary: "Register a webhook", Tag: "Webhooks", Admin: true, Request: webhookInput{}, Response: database.Webhook{}, Status: http.StatusCreated},
+	"DELETE /api/v1/webhooks/{id}":         {Summary: "Remove a webhook", Tag: "Webhooks", Admin: true},
+	"GET /api/v1/webhooks/{id}/deliveries": {Summary: "Delivery log of a webhook", Tag: "Webhooks", Admin: true, Response: []database.WebhookDelivery{}, Query: []paramDoc{{"limit", "integer", "Maximum entries (default 100, max 500)"}}},
+	"GET /api/v1/jobs": {Summary: "List background jobs waiting, running or dead", Tag: "Background jobs", Admin: true, Response: []database.Job{}, Query: []paramDoc{
+		{"status", "string", "queued, running or dead (the dead-letter list)"},
+		{"kind", "string", "Job kind, e.g. webhook.deliver or email.send"},
+		{"limit", "integer", "Maximum entries (default 100, max 500)"},
+	}},
+	"GET /api/v1/jobs/stats":                     {Summary: "Count background jobs by kind and status", Tag: "Background jobs", Admin: true, Response: []database.JobCount{}},
+	"GET /api/v1/jobs/{id}":                      {Summary: "Get a background job", Tag: "Background jobs", Admin: true, Response: database.Job{}},
+	"DELETE /api/v1/jobs/{id}":                   {Summary: "Discard a dead job", Tag: "Background jobs", Admin: true},
+	"POST /api/v1/jobs/{id}/retry":               {Summary: "Queue a dead job again", Tag: "Background jobs", Admin: true, Response: database.Job{}},
+	"GET /api/v1/scheduler":                      {Summary: "Scheduled tasks and whether this instance runs them", Tag: "Scheduler", Admin: true, Response: scheduler.Status{}},
+	"GET /api/v1/short-links":                    {Summary: "List short links", Tag: "Short links", Admin: true, Response: []database.ShortLink{}},
+	"POST /api/v1/short-links":                   {Summary: "Create a short link", Tag: "Short links", Admin: true, Request: shortLinkInput{}, Response: database.ShortLink{}, Status: http.StatusCreated},
+	"DELETE /api/v1/short-links/{code}":          {Summary: "Revoke a short link", Tag: "Short links", Admin: true},
+	"GET /api/v1/announcements/active":           {Summary: "Currently published announcements", Tag: "Announcements", Response: []database.Announcement{}, Query: []paramDoc{{"audience", "string", "Audience to match in addition to 'all'"}}},
+	"GET /api/v1/announcements":                  {Summary: "List all announcements", Tag: "Announcements", Admin: true, Response: []database.Announcement{}},
+	"POST /api/v1/announcements":                 {Summary: "Create an announcement", Tag: "Announcements", Admin: true, Request: announcementInput{}, Response: database.Announcement{}, Status: http.StatusCreated},
+	"DELETE /api/v1/announcements/{id}":          {Summary: "Delete an announcement", Tag: "Announcements", Admin: true},
+	"GET /api/v1/retention/policies":             {Summary: "List retention policies", Tag: "Data retention", Admin: true, Response: retentionPoliciesDoc{}},
+	"PUT /api/v1/retention/policies/{entity}":    {Summary: "Create or replace a retention policy", Tag: "Data retention", Admin: true, Request: retentionPolicyInput{}, Response: database.RetentionPolicy{}},
+	"DELETE /api/v1/retention/policies/{entity}": {Summary: "Remove a retention policy", Tag: "Data retention", Admin: true},
+	"POST /api/v1/retention/run":                 {Summary: "Apply the enabled retention policies now", Tag: "Data retention", Admin: true, Response: []retention.Result{}},
+	"GET /api/v1/validation-rules":               {Summary: "List validation rules", Tag: "Validation rules", Admin: true, Response: []database.ValidationRule{}},
+	"POST /api/v1/validation-rules":              {Summary: "Create a Lua validation rule", Tag: "Validation rules", Admin: true, Request: validationRuleInput{}, Response: database.ValidationRule{}, Status: http.StatusCreated},
+	"DELETE /api/v1/validation-rules/{id}":       {Summary: "Delete a validation rule", Tag: "Validation rules", Admin: true},
+	"GET /api/v1/database/failover":              {Summary: "Database failover status", Tag: "Administration", Admin: true, Response: database.FailoverStatus{}},
+	"POST /api/v1/database/switchover":           {Summary: "Switch to the promoted standby database", Tag: "Administration", Admin: true, Request: switchoverInput{}, Response: database.FailoverStatus{}},
+}
+
+// experimentsAssignmentDoc mirrors experiments.Assignment for documentation
+type experimentsAssignmentDoc struct {
+	Experiment string `json:"experiment"`
+	Variant    string `json:"variant"`
+}
+
+// publicStatsDoc documents the public stats document
+type publicStatsDoc struct {
+	TotalLearners int    `json:"total_learners"`
+	GeneratedAt   string `json:"generated_at"`
+}
+
+// pageMetaDoc documents the pagination metadata of GET /users?limit=&cursor=
+type pageMetaDoc struct {
+	Limit      int     `json:"limit"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// notificationPageMetaDoc documents the pagination metadata of notification and activity
+// listings
+type notificationPageMetaDoc struct {
+	Limit      int    `json:"limit"`
+	NextBefore *int64 `json:"next_before"`
+}
+
+// retentionPoliciesDoc documents the retention policy listing
+type retentionPoliciesDoc struct {
+	Policies          []database.RetentionPolicy `json:"policies"`
+	SupportedEntities []string                   `json:"supported_entities"`
+}
+
+// muxVariablePattern matches gorilla/mux path variables with an optional regexp, e.g. {id:[0-9]+}
+var muxVariablePattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// openAPISpec is built once the router is complete
+var openAPISpec map[string]interface{}
+
+// Build the OpenAPI 3 document from the router's registered routes
+func buildOpenAPISpec(router *mux.Router) map[string]interface{} {
+	schemas := map[string]interface{}{}
+	paths := map[string]map[string]interface{}{}
+
+	router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+
+		path := muxVariablePattern.ReplaceAllString(template, "{$1}")
+		if path == "/" || strings.HasPrefix(path, "/static/") {
+			return nil
+		}
+		// The unversioned /api alias is deprecated and left out of the document
+		if strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/api/v1/") {
+			return nil
+		}
+
+		for _, method := range methods {
+			doc, ok := routeDocs[method+" "+path]
+			if !ok {
+				doc = routeDoc{Summary: method + " " + path}
+			}
+			if paths[path] == nil {
+				paths[path] = map[string]interface{}{}
+			}
+			paths[path][strings.ToLower(method)] = buildOperation(template, doc, schemas)
+		}
+		return nil
+	})
+
+	schemas["ErrorResponse"] = map[string]interface{}{
+		"type":     "object",
+		"required": []string{"message", "timestamp"},
+		"properties": map[string]interface{}{
+			"message": map[string]interface{}{"type": "string"},
+			"code": map[string]interface{}{
+				"type":        "string",
+				"description": "Machine-readable error code, the same in every language (e.g. user_email_exists, " + errorCodeConcurrencyLimit + ")",
+			},
+			"timestamp": map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "HocTap API",
+			"version":     apiVersion,
+			"description": "REST API for the HocTap learning platform. JSON responses are wrapped in {message, data, timestamp}; messages follow Accept-Language (en, vi).",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"adminToken": map[string]interface{}{
+					"type":        "http",
+					"scheme":      "bearer",
+					"description": "ADMIN_API_TOKEN configured on the server",
+				},
+			},
+		},
+	}
+}
+
+// Build a single OpenAPI operation object for a route path template; variables restricted to
+// digits are integers
+func buildOperation(template string, doc routeDoc, schemas map[string]interface{}) map[string]interface{} {
+	operation := map[string]interface{}{"summary": doc.Summary}
+	if doc.Tag != "" {
+		operation["tags"] = []string{doc.Tag}
+	}
+	if doc.Admin {
+		operation["security"] = []map[string][]string{{"adminToken": {}}}
+	}
+
+	var parameters []map[string]interface{}
+	for _, match := range muxVariablePattern.FindAllStringSubmatch(template, -1) {
+		paramType := "string"
+		if match[2] == ":[0-9]+" {
+			paramType = "integer"
+		}
+		parameters = append(parameters, map[string]interface{}{
+			"name": match[1], "in": "path", "required": true,
+			"schema": map[string]interface{}{"type": paramType},
+		})
+	}
+	for _, q := range doc.Query {
+		parameters = append(parameters, map[string]interface{}{
+			"name": q.Name, "in": "query", "description": q.Description,
+			"schema": map[string]interface{}{"type": q.Type},
+		})
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if doc.Upload != "" {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"multipart/form-data": map[string]interface{}{"schema": map[string]interface{}{
+					"type":       "object",
+					"required":   []string{doc.Upload},
+					"properties": map[string]interface{}{doc.Upload: map[string]interface{}{"type": "string", "format": "binary"}},
+				}},
+			},
+		}
+	}
+	if doc.Request != nil {
+		operation["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemaFor(reflect.TypeOf(doc.Request), schemas)},
+			},
+		}
+	}
+
+	status := doc.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	success := map[string]interface{}{"description": http.StatusText(status)}
+	switch {
+	case doc.ContentType != "":
+		success["content"] = map[string]interface{}{doc.ContentType: map[string]interface{}{}}
+	case status < 300:
+		envelope := map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"message":   map[string]interface{}{"type": "string"},
+				"timestamp": map[string]interface{}{"type": "string", "format": "date-time"},
+			},
+		}
+		if doc.Response != nil {
+			envelope["properties"].(map[string]interface{})["data"] = schemaFor(reflect.TypeOf(doc.Response), schemas)
+		}
+		if doc.Meta != nil {
+			envelope["properties"].(map[string]interface{})["meta"] = schemaFor(reflect.TypeOf(doc.Meta), schemas)
+		}
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": envelope}}
+	}
+
+	errorResponse := map[string]interface{}{
+		"description": "Error",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/ErrorResponse"}},
+		},
+	}
+
+	operation["responses"] = map[string]interface{}{
+		strconv.Itoa(status): success,
+		"default":            errorResponse,
+	}
+
+	return operation
+}
+
+// Helper function to derive a JSON schema from a Go type, registering named structs as components
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema map[string]interface{}
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		schema = map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == reflect.TypeOf(json.RawMessage{}):
+		schema = map[string]interface{}{"description": "Arbitrary JSON"}
+	case t.Kind() == reflect.Struct:
+		if t.Name() == "" {
+			return structSchema(t, schemas)
+		}
+		// Unexported helper types become exported-looking component names, e.g. userInput -> UserInput
+		name := strings.TrimSuffix(strings.ToUpper(t.Name()[:1])+t.Name()[1:], "Doc")
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = map[string]interface{}{} // placeholder guards against recursion
+			schemas[name] = structSchema(t, schemas)
+		}
+		schema = map[string]interface{}{"$ref": "#/components/schemas/" + name}
+		if nullable {
+			return map[string]interface{}{"allOf": []interface{}{schema}, "nullable": true}
+		}
+		return schema
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		schema = map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map:
+		schema = map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case t.Kind() == reflect.Bool:
+		schema = map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		schema = map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		schema = map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.String:
+		schema = map[string]interface{}{"type": "string"}
+	default:
+		schema = map[string]interface{}{}
+	}
+
+	if nullable {
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+// Helper function to build an object schema from a struct's JSON fields
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			name = strings.Split(tag, ",")[0]
+		}
+		if name == "-" {
+			continue
+		}
+		// Embedded structs without a JSON name are flattened, as encoding/json does
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			embedded := structSchema(field.Type, schemas)
+			for k, v := range embedded["properties"].(map[string]interface{}) {
+				properties[k] = v
+			}
+			continue
+		}
+		properties[name] = schemaFor(field.Type, schemas)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+// Serve the OpenAPI document
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(openAPISpec)
+}
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+    <meta charset="UTF-8">
+    <title>HocTap API Docs</title>
+    <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+    <div id="swagger-ui"></div>
+    <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+    <script>
+        window.ui = SwaggerUIBundle({ url: '/openapi.json', dom_id: '#swagger-ui' });
+    </script>
+</body>
+</html>`
+
+// Serve the interactive Swagger UI
+func swaggerUIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerUIPage))
+}
@@ -0,0 +1,181 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hoctap-api/database"
+)
+
+// batchSize is how many events the relay claims at once
+const batchSize = 100
+
+// maxBackoff caps the delay between publish attempts of an event
+const maxBackoff = time.Hour
+
+// Publisher publishes one event to one destination. An error retries the event for that
+// destination only, after a backoff.
+type Publisher func(ctx context.Context, event database.OutboxEvent) error
+
+// Relay publishes the events recorded in the outbox once the transactions recording them
+// committed, oldest first, to each destination independently. Every instance runs a relay;
+// each event is claimed by one of them at a time. An event failing maxAttempts times is
+// moved to the dead letters instead of being retried forever.
+type Relay struct {
+	repo         *database.OutboxRepository
+	publishers   map[string]Publisher
+	destinations []string
+	poll         time.Duration
+	lease        time.Duration
+	maxAttempts  int
+
+	wake chan struct{}
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewRelay creates a relay publishing to the given destinations, looking for due events every
+// poll interval. Claimed events are hidden from other relays for lease.
+func NewRelay(repo *database.OutboxRepository, publishers map[string]Publisher, poll, lease time.Duration, maxAttempts int) *Relay {
+	destinations := make([]string, 0, len(publishers))
+	for destination := range publishers {
+		destinations = append(destinations, destination)
+	}
+	sort.Strings(destinations)
+
+	return &Relay{
+		repo:         repo,
+		publishers:   publishers,
+		destinations: destinations,
+		poll:         poll,
+		lease:        lease,
+		maxAttempts:  maxAttempts,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+	}
+}
+
+// Start starts relaying events
+func (r *Relay) Start() {
+	r.wg.Add(1)
+	go r.run()
+}
+
+// Stop stops relaying and waits for the batch being published
+func (r *Relay) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// Wake makes the relay look for events right away instead of at its next poll, such as
+// after a change recorded one
+func (r *Relay) Wake() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run publishes batches of events until the relay is stopped, waiting for a poll interval
+// or a wake-up whenever no more events are due
+func (r *Relay) run() {
+	defer r.wg.Done()
+	ctx := context.Background()
+	for {
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+
+		events, err := r.repo.Claim(ctx, batchSize, time.Now().Add(r.lease))
+		if err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		} else {
+			r.publishBatch(ctx, events)
+			if len(events) == batchSize {
+				continue
+			}
+		}
+
+		select {
+		case <-r.stop:
+			return
+		case <-r.wake:
+		case <-time.After(r.poll):
+		}
+	}
+}
+
+// publishBatch publishes claimed events and settles each of them. Once a destination fails,
+// the rest of the batch is not attempted for it, so an unreachable destination costs one
+// failed attempt per batch rather than one per event, and events behind the failing one are
+// released to the next claim rather than counted as failed.
+func (r *Relay) publishBatch(ctx context.Context, events []database.OutboxEvent) {
+	failing := map[string]bool{}
+	for _, event := range events {
+		attempt := r.publishEvent(ctx, event, failing)
+		if err := r.repo.Settle(ctx, event.ID, attempt); err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+		}
+	}
+}
+
+// publishEvent publishes an event to the destinations still missing it, and returns how it
+// went: done, failed with a retry or a move to the dead letters, or not attempted
+func (r *Relay) publishEvent(ctx context.Context, event database.OutboxEvent, failing map[string]bool) database.OutboxAttempt {
+	var attempt database.OutboxAttempt
+	var failures []string
+	pending := 0
+
+	for _, destination := range r.destinations {
+		if !event.Pending(destination) {
+			continue
+		}
+		if failing[destination] {
+			pending++
+			continue
+		}
+		if err := r.publishers[destination](ctx, event); err != nil {
+			failing[destination] = true
+			failures = append(failures, fmt.Sprintf("%s: %v", destination, err))
+			pending++
+			continue
+		}
+		attempt.Published = append(attempt.Published, destination)
+	}
+
+	if len(failures) == 0 {
+		attempt.Done = pending == 0
+		return attempt
+	}
+
+	attempt.Error = strings.Join(failures, "; ")
+	attempts := event.Attempts + 1
+	if attempts >= r.maxAttempts {
+		log.Printf("❌ %s event %s failed %d times, moved to the dead letters: %s",
+			event.Event, event.EventID, attempts, attempt.Error)
+		return attempt
+	}
+
+	delay := backoff(r.poll, attempts)
+	log.Printf("⚠️ Warning: failed to publish %s event %s (attempt %d/%d), retrying in %s: %s",
+		event.Event, event.EventID, attempts, r.maxAttempts, delay, attempt.Error)
+	attempt.RetryAt = time.Now().Add(delay)
+	return attempt
+}
+
+// backoff returns the delay after the given failed attempt: initial after the first, doubling
+// after every next one, up to maxBackoff
+func backoff(initial time.Duration, attempt int) time.Duration {
+	delay := initial
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"hoctap-api/database"
+)
+
+func TestPublishEvent(t *testing.T) {
+	published := time.Now()
+	tests := []struct {
+		name      string
+		event     database.OutboxEvent
+		failing   map[string]bool
+		errs      map[string]error
+		want      database.OutboxAttempt
+		wantCalls []string
+		wantRetry time.Duration
+	}{
+		{
+			name:      "all destinations ok",
+			event:     database.OutboxEvent{},
+			want:      database.OutboxAttempt{Published: []string{database.OutboxBroker, database.OutboxWebhooks}, Done: true},
+			wantCalls: []string{database.OutboxBroker, database.OutboxWebhooks},
+		},
+		{
+			name:      "already published to one destination",
+			event:     database.OutboxEvent{BrokerPublishedAt: &published},
+			want:      database.OutboxAttempt{Published: []string{database.OutboxWebhooks}, Done: true},
+			wantCalls: []string{database.OutboxWebhooks},
+		},
+		{
+			name:      "one destination failing",
+			event:     database.OutboxEvent{Attempts: 1},
+			errs:      map[string]error{database.OutboxBroker: errors.New("connection refused")},
+			want:      database.OutboxAttempt{Published: []string{database.OutboxWebhooks}, Error: "broker: connection refused"},
+			wantCalls: []string{database.OutboxBroker, database.OutboxWebhooks},
+			wantRetry: 2 * time.Minute,
+		},
+		{
+			name:      "destination failing earlier in the batch",
+			event:     database.OutboxEvent{Attempts: 1},
+			failing:   map[string]bool{database.OutboxBroker: true},
+			want:      database.OutboxAttempt{Published: []string{database.OutboxWebhooks}},
+			wantCalls: []string{database.OutboxWebhooks},
+		},
+		{
+			name:      "every destination failing earlier in the batch",
+			event:     database.OutboxEvent{},
+			failing:   map[string]bool{database.OutboxBroker: true, database.OutboxWebhooks: true},
+			want:      database.OutboxAttempt{},
+			wantCalls: nil,
+		},
+		{
+			name:  "out of attempts",
+			event: database.OutboxEvent{Attempts: 2},
+			errs: map[string]error{
+				database.OutboxBroker:   errors.New("connection refused"),
+				database.OutboxWebhooks: errors.New("timeout"),
+			},
+			want:      database.OutboxAttempt{Error: "broker: connection refused; webhooks: timeout"},
+			wantCalls: []string{database.OutboxBroker, database.OutboxWebhooks},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			publisher := func(destination string) Publisher {
+				return func(ctx context.Context, event database.OutboxEvent) error {
+					calls = append(calls, destination)
+					return tt.errs[destination]
+				}
+			}
+			relay := NewRelay(nil, map[string]Publisher{
+				database.OutboxBroker:   publisher(database.OutboxBroker),
+				database.OutboxWebhooks: publisher(database.OutboxWebhooks),
+			}, time.Minute, time.Minute, 3)
+
+			failing := map[string]bool{}
+			for destination := range tt.failing {
+				failing[destination] = true
+			}
+
+			before := time.Now()
+			got := relay.publishEvent(context.Background(), tt.event, failing)
+			after := time.Now()
+
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("published to %v, want %v", calls, tt.wantCalls)
+			}
+			if tt.wantRetry == 0 {
+				if !got.RetryAt.IsZero() {
+					t.Errorf("RetryAt = %v, want zero", got.RetryAt)
+				}
+			} else if got.RetryAt.Before(before.Add(tt.wantRetry)) || got.RetryAt.After(after.Add(tt.wantRetry)) {
+				t.Errorf("RetryAt = %v, want %s from now", got.RetryAt, tt.wantRetry)
+			}
+
+			got.RetryAt = time.Time{}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("publishEvent() = %+v, want %+v", got, tt.want)
+			}
+			for destination, err := range tt.errs {
+				if err != nil && !failing[destination] {
+					t.Errorf("%s not marked failing for the rest of the batch", destination)
+				}
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{name: "first attempt", initial: time.Minute, attempt: 1, want: time.Minute},
+		{name: "doubles", initial: time.Minute, attempt: 4, want: 8 * time.Minute},
+		{name: "capped", initial: time.Minute, attempt: 10, want: maxBackoff},
+		{name: "initial above cap", initial: 2 * time.Hour, attempt: 1, want: maxBackoff},
+		{name: "many attempts", initial: time.Second, attempt: 1000, want: maxBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoff(tt.initial, tt.attempt); got != tt.want {
+				t.Errorf("backoff(%s, %d) = %s, want %s", tt.initial, tt.attempt, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// REST pagination limits for GET /users?limit=&cursor=
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// userCursor is the JSON form of database.UserCursor inside the opaque cursor
+type userCursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        int       `json:"i"`
+}
+
+// Helper function to encode the position after user as an opaque, URL-safe cursor
+func encodeUserCursor(user database.User) string {
+	data, _ := json.Marshal(userCursor{CreatedAt: user.CreatedAt, ID: user.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Helper function to decode a cursor returned as next_cursor by a previous page
+func decodeUserCursor(cursor string) (*database.UserCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded userCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+	if decoded.CreatedAt.IsZero() || decoded.ID <= 0 {
+		return nil, errors.New("incomplete cursor")
+	}
+
+	return &database.UserCursor{CreatedAt: decoded.CreatedAt, ID: decoded.ID}, nil
+}
+
+// Helper function to send a page of users, newest first, using keyset pagination on
+// (created_at, id). Unlike offsets, a cursor stays stable while users are created or
+// deleted between pages.
+func sendUsersPage(w http.ResponseWriter, r *http.Request, fields []string) {
+	query := r.URL.Query()
+	filter := database.UserFilter{
+		Search: query.Get("search"),
+		Limit:  defaultPageSize,
+		Fields: withField(fields, "created_at"), // the next cursor needs it
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxPageSize {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_limit_100"), nil)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		after, err := decodeUserCursor(value)
+		if err != nil {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_cursor"), nil)
+			return
+		}
+		filter.After = after
+	}
+
+	// Fetch one extra user to know whether another page follows
+	limit := filter.Limit
+	filter.Limit++
+	users, err := userRepo.ListUsers(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error listing users: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("users_retrieve_failed"), nil)
+		return
+	}
+
+	var nextCursor interface{}
+	if len(users) > limit {
+		users = users[:limit]
+		nextCursor = encodeUserCursor(users[limit-1])
+	}
+
+	sendJSONResponseWithMeta(w, http.StatusOK, i18n.M("users_retrieved"), sparseUsers(users, fields), map[string]interface{}{
+		"limit":       limit,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log"
+	"strings"
+
+	"hoctap-api/database"
+	"hoctap-api/plugins"
+	// Compiled-in plugins are enabled by blank-importing their package here, e.g.
+	// _ "hoctap-api/plugins/attendance"
+)
+
+// Helper function to log the enabled plugins at startup
+func logPlugins() {
+	enabled := plugins.All()
+	if len(enabled) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(enabled))
+	for _, plugin := range enabled {
+		names = append(names, plugin.Name())
+	}
+	log.Printf("🧩 Plugins enabled: %s", strings.Join(names, ", "))
+}
+
+// Helper function to apply the core migrations followed by those of every plugin
+func migrateAll() error {
+	if err := database.MigrateUp(); err != nil {
+		return err
+	}
+
+	for _, plugin := range plugins.All() {
+		if provider, ok := plugin.(plugins.MigrationProvider); ok {
+			if err := database.MigratePluginUp(plugin.Name(), provider.Migrations()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package plugins
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/gorilla/mux"
+)
+
+// Plugin is a compiled-in extension. A plugin package calls Register from its init function
+// and is enabled by blank-importing it in the main package. On top of Name, a plugin
+// implements whichever of RouteProvider, MiddlewareProvider, EventSubscriber and
+// MigrationProvider it needs.
+type Plugin interface {
+	Name() string
+}
+
+// RouteProvider adds HTTP routes. Routes are registered after the core routes, so a plugin
+// cannot shadow them.
+type RouteProvider interface {
+	RegisterRoutes(router *mux.Router)
+}
+
+// MiddlewareProvider wraps every request, after the core middleware
+type MiddlewareProvider interface {
+	Middleware() []mux.MiddlewareFunc
+}
+
+// EventSubscriber receives the same events as webhooks (e.g. "user.created"). It is called
+// synchronously and must not block.
+type EventSubscriber interface {
+	HandleEvent(event string, data interface{})
+}
+
+// MigrationProvider ships schema migrations in the same layout as the core ones: one
+// directory per dialect ("mysql", "postgres") of <version>_<name>.up.sql / .down.sql files.
+// Versions are tracked separately for every plugin.
+type MigrationProvider interface {
+	Migrations() fs.FS
+}
+
+// namePattern keeps plugin names usable in migration table names
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,31}$`)
+
+var (
+	mu       sync.RWMutex
+	registry = map[string]Plugin{}
+)
+
+// Register makes a plugin available. It panics if the name is invalid or already taken,
+// like database/sql.Register, since both are programming errors found at startup.
+func Register(plugin Plugin) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	name := plugin.Name()
+	if !namePattern.MatchString(name) {
+		panic(fmt.Sprintf("plugins: invalid plugin name '%s' (lowercase letters, digits and _)", name))
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("plugins: Register called twice for plugin '%s'", name))
+	}
+
+	registry[name] = plugin
+}
+
+// All returns the registered plugins sorted by name
+func All() []Plugin {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	list := make([]Plugin, 0, len(registry))
+	for _, plugin := range registry {
+		list = append(list, plugin)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+
+	return list
+}
+
+// RegisterRoutes lets every RouteProvider add its routes
+func RegisterRoutes(router *mux.Router) {
+	for _, plugin := range All() {
+		if provider, ok := plugin.(RouteProvider); ok {
+			provider.RegisterRoutes(router)
+		}
+	}
+}
+
+// Middleware returns the middleware of every MiddlewareProvider, in plugin name order
+func Middleware() []mux.MiddlewareFunc {
+	var middleware []mux.MiddlewareFunc
+	for _, plugin := range All() {
+		if provider, ok := plugin.(MiddlewareProvider); ok {
+			middleware = append(middleware, provider.Middleware()...)
+		}
+	}
+	return middleware
+}
+
+// Publish delivers an event to every EventSubscriber. A panicking subscriber is reported
+// and does not stop delivery to the others.
+func Publish(event string, data interface{}) []error {
+	var errs []error
+	for _, plugin := range All() {
+		subscriber, ok := plugin.(EventSubscriber)
+		if !ok {
+			continue
+		}
+
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					errs = append(errs, fmt.Errorf("plugin %s panicked handling %s: %v", plugin.Name(), event, recovered))
+				}
+			}()
+			subscriber.HandleEvent(event, data)
+		}()
+	}
+	return errs
+}
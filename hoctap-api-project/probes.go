@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// readinessTimeout bounds all checks of one /readyz request
+const readinessTimeout = 2 * time.Second
+
+// readinessCheck is one condition /readyz requires before the instance gets traffic
+type readinessCheck struct {
+	name  string
+	check func(ctx context.Context) error
+}
+
+var (
+	// startupComplete is set once the services and router are up
+	startupComplete atomic.Bool
+	// shuttingDown is set when draining starts, so /readyz takes the instance out of rotation
+	shuttingDown atomic.Bool
+	// readinessChecks are registered by startServices; mock mode has none
+	readinessChecks []readinessCheck
+	// schemaCurrent remembers that the schema reached the latest migration
+	schemaCurrent atomic.Bool
+)
+
+// startupHandler answers requests while the server is still starting: only the probes
+// respond, every other request gets 503 until Ready installs the router
+type startupHandler struct {
+	next atomic.Pointer[http.Handler]
+}
+
+// Ready installs the handler serving all requests from now on
+func (h *startupHandler) Ready(next http.Handler) {
+	h.next.Store(&next)
+	startupComplete.Store(true)
+}
+
+func (h *startupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if next := h.next.Load(); next != nil {
+		(*next).ServeHTTP(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/livez":
+		livezHandler(w, r)
+	case "/startupz":
+		startupzHandler(w, r)
+	case "/readyz":
+		readyzHandler(w, r)
+	case "/lb-health":
+		lbHealthHandler(w, r)
+	default:
+		w.Header().Set("Retry-After", "5")
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("server_starting"), nil)
+	}
+}
+
+// Liveness probe: the process is up and serving HTTP
+func livezHandler(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, http.StatusOK, i18n.M("alive"), map[string]interface{}{"status": "alive"})
+}
+
+// Startup probe: the database is connected, migrations have run and the routes are served
+func startupzHandler(w http.ResponseWriter, r *http.Request) {
+	if !startupComplete.Load() {
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("starting"), map[string]interface{}{"status": "starting"})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, i18n.M("started"), map[string]interface{}{"status": "started"})
+}
+
+// Readiness probe: the instance can serve traffic right now. Every registered check must
+// pass; the instance also reports not ready while starting and while shutting down.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case shuttingDown.Load():
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("not_ready"), map[string]interface{}{"status": "shutting down"})
+		return
+	case !startupComplete.Load():
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("not_ready"), map[string]interface{}{"status": "starting"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+	defer cancel()
+
+	ready := true
+	checks := map[string]string{}
+	for _, readiness := range readinessChecks {
+		if err := readiness.check(ctx); err != nil {
+			ready = false
+			checks[readiness.name] = err.Error()
+		} else {
+			checks[readiness.name] = "ok"
+		}
+	}
+
+	if !ready {
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("not_ready"), map[string]interface{}{"status": "not ready", "checks": checks})
+		return
+	}
+	sendJSONResponse(w, http.StatusOK, i18n.M("ready"), map[string]interface{}{"status": "ready", "checks": checks})
+}
+
+// Load balancer health check: 200 while the instance takes traffic, 503 from the moment
+// shutdown begins, so the balancer stops sending new requests during PRE_STOP_DELAY and the
+// drain. Dependencies are not checked; a load balancer failing them all would drop every instance.
+func lbHealthHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case shuttingDown.Load():
+		w.Header().Set("Connection", "close")
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("draining"), map[string]interface{}{"status": "draining"})
+	case !startupComplete.Load():
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("starting"), map[string]interface{}{"status": "starting"})
+	default:
+		sendJSONResponse(w, http.StatusOK, i18n.M("serving"), map[string]interface{}{"status": "serving"})
+	}
+}
+
+// Helper function to check that the database answers
+func checkDatabaseReady(ctx context.Context) error {
+	if !database.Available() {
+		return database.ErrDatabaseUnavailable
+	}
+	return database.DB.PingContext(ctx)
+}
+
+// Helper function to check that the schema is at the latest migration this build knows.
+// A newer schema (during a rolling deploy) is fine; once current, the result is kept.
+func checkMigrationsReady(ctx context.Context) error {
+	if schemaCurrent.Load() {
+		return nil
+	}
+
+	version, dirty, err := database.MigrationVersion()
+	if err != nil {
+		return err
+	}
+	latest, err := database.LatestMigrationVersion()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case dirty:
+		return fmt.Errorf("migration %d failed halfway", version)
+	case version < latest:
+		return fmt.Errorf("schema at version %d, expected %d", version, latest)
+	}
+	schemaCurrent.Store(true)
+	return nil
+}
@@ -0,0 +1,4 @@
+// Package proto holds the protobuf service definitions and their generated Go code.
+package proto
+
+//go:generate protoc -I . --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative user/v1/user.proto
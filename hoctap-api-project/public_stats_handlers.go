@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"hoctap-api/config"
+	"hoctap-api/i18n"
+	"hoctap-api/tracking"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// publicStatsQuota limits requests per API key, separately from every other quota
+var publicStatsQuota *tracking.Quota
+
+// publicStatsDocument is a computed public stats document; it is not changed once shared
+type publicStatsDocument struct {
+	stats       map[string]interface{}
+	generatedAt time.Time
+}
+
+// publicStatsSnapshot is the cached public stats document shared by every key
+var publicStatsSnapshot struct {
+	sync.Mutex
+	document *publicStatsDocument
+}
+
+// publicStatsRefresh computes a stale document once for every request waiting on it
+var publicStatsRefresh singleflight.Group
+
+// Middleware requiring one of PUBLIC_STATS_API_KEYS in the X-API-Key header (or as a bearer
+// token) and charging the request to that key's hourly quota
+func requirePublicStatsKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := config.Current().PublicStats.APIKeys
+		if len(keys) == 0 {
+			sendJSONResponse(w, http.StatusForbidden, i18n.M("public_stats_disabled"), nil)
+			return
+		}
+
+		provided := r.Header.Get("X-API-Key")
+		if provided == "" {
+			provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+
+		var key string
+		for _, candidate := range keys {
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(candidate)) == 1 {
+				key = candidate
+			}
+		}
+		if key == "" {
+			sendJSONResponse(w, http.StatusUnauthorized, i18n.M("api_key_required"), nil)
+			return
+		}
+
+		if publicStatsQuota.Allow(key, 1) == 0 {
+			w.Header().Set("Retry-After", "3600")
+			sendJSONResponse(w, http.StatusTooManyRequests, i18n.M("api_key_quota_exceeded"), nil)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// Get the public platform stats. The document is computed at most once per
+// PUBLIC_STATS_CACHE_TTL for all keys and may be cached by clients and CDNs as long.
+func getPublicStatsHandler(w http.ResponseWriter, r *http.Request) {
+	ttl := config.Current().PublicStats.CacheTTL
+
+	document, err := currentPublicStats(r.Context(), ttl)
+	if err != nil {
+		if r.Context().Err() != nil {
+			// The client is gone; the refresh goes on for the others
+			return
+		}
+		log.Printf("Error getting public stats: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("stats_failed"), nil)
+		return
+	}
+
+	maxAge := ttl - time.Since(document.generatedAt)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	sendJSONResponse(w, http.StatusOK, i18n.M("stats_retrieved"), document.stats)
+}
+
+// Helper function to return the public stats document, computing it again once it is ttl
+// old. The lock only guards the snapshot, so a slow query or client does not hold up the
+// other keys. The refresh is shared by concurrent requests and detached from the
+// cancellation of whichever started it; each request still stops waiting when it is done.
+func currentPublicStats(ctx context.Context, ttl time.Duration) (*publicStatsDocument, error) {
+	publicStatsSnapshot.Lock()
+	document := publicStatsSnapshot.document
+	publicStatsSnapshot.Unlock()
+	if document != nil && time.Since(document.generatedAt) < ttl {
+		return document, nil
+	}
+
+	shared := context.WithoutCancel(ctx)
+	results := publicStatsRefresh.DoChan("public-stats", func() (interface{}, error) {
+		learners, err := userRepo.GetUsersCount(shared)
+		if err != nil {
+			return nil, err
+		}
+
+		generatedAt := time.Now()
+		document := &publicStatsDocument{
+			stats: map[string]interface{}{
+				"total_learners": learners,
+				"generated_at":   generatedAt.Format(time.RFC3339),
+			},
+			generatedAt: generatedAt,
+		}
+
+		publicStatsSnapshot.Lock()
+		publicStatsSnapshot.document = document
+		publicStatsSnapshot.Unlock()
+		return document, nil
+	})
+
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*publicStatsDocument), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
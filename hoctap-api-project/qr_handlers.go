@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hoctap-api/i18n"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// QR code limits
+const (
+	qrDefaultSize = 256
+	qrMinSize     = 64
+	qrMaxSize     = 1024
+	qrMaxDataLen  = 2048
+)
+
+// qrLevels maps the standard error-correction letters to go-qrcode recovery levels
+var qrLevels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// Generate a QR code image: GET /api/qr?data=...&format=png|svg&size=256&ec=L|M|Q|H
+func qrCodeHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	data := query.Get("data")
+	if data == "" {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("qr_data_required"), nil)
+		return
+	}
+	if len(data) > qrMaxDataLen {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("qr_data_too_long", "max", qrMaxDataLen), nil)
+		return
+	}
+
+	size := qrDefaultSize
+	if value := query.Get("size"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < qrMinSize || parsed > qrMaxSize {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("qr_invalid_size", "min", qrMinSize, "max", qrMaxSize), nil)
+			return
+		}
+		size = parsed
+	}
+
+	level := qrcode.Medium
+	if value := query.Get("ec"); value != "" {
+		parsed, ok := qrLevels[strings.ToUpper(value)]
+		if !ok {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("qr_invalid_error_correction"), nil)
+			return
+		}
+		level = parsed
+	}
+
+	code, err := qrcode.New(data, level)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("qr_data_unencodable"), nil)
+		return
+	}
+
+	switch format := strings.ToLower(query.Get("format")); format {
+	case "", "png":
+		image, err := code.PNG(size)
+		if err != nil {
+			log.Printf("Error rendering QR code: %v", err)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("qr_generate_failed"), nil)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Write(image)
+	case "svg":
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.Write([]byte(renderQRCodeSVG(code, size)))
+	default:
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("qr_invalid_format"), nil)
+	}
+}
+
+// Helper function to render a QR code as an SVG document, one rect per dark module
+func renderQRCodeSVG(code *qrcode.QRCode, size int) string {
+	bitmap := code.Bitmap()
+	modules := len(bitmap)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		size, size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#ffffff"/>`, modules, modules)
+	for y, row := range bitmap {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="1" height="1" fill="#000000"/>`, x, y)
+			}
+		}
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
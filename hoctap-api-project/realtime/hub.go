@@ -0,0 +1,249 @@
+package realtime
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Topics clients can subscribe to
+const (
+	TopicUsers = "users"
+	TopicStats = "stats"
+)
+
+// Connection settings
+const (
+	writeWait      = 10 * time.Second
+	pongWait       = 60 * time.Second
+	pingPeriod     = (pongWait * 9) / 10
+	maxMessageSize = 4096
+	sendBufferSize = 64
+)
+
+// Message is pushed to subscribed clients as a JSON text frame
+type Message struct {
+	Topic     string      `json:"topic"`
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data,omitempty"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// subscriptionRequest is sent by clients to change the topics they receive
+type subscriptionRequest struct {
+	Action string   `json:"action"`
+	Topics []string `json:"topics"`
+}
+
+// client is a single WebSocket connection and the topics it listens to
+type client struct {
+	hub    *Hub
+	conn   *websocket.Conn
+	send   chan []byte
+	mu     sync.RWMutex
+	topics map[string]bool
+}
+
+// Hub tracks connected dashboard clients and fans out messages to their subscriptions
+type Hub struct {
+	upgrader websocket.Upgrader
+	mu       sync.RWMutex
+	clients  map[*client]bool
+	closed   bool
+	writers  sync.WaitGroup
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// The API is served with permissive CORS, so any dashboard origin may connect
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		clients: make(map[*client]bool),
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection subscribed to all topics
+func (h *Hub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.RLock()
+	closed := h.closed
+	h.mu.RUnlock()
+	if closed {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("WebSocket upgrade failed: %v", err)
+		return
+	}
+
+	c := &client{
+		hub:    h,
+		conn:   conn,
+		send:   make(chan []byte, sendBufferSize),
+		topics: map[string]bool{TopicUsers: true, TopicStats: true},
+	}
+
+	h.mu.Lock()
+	h.clients[c] = true
+	h.mu.Unlock()
+
+	h.writers.Add(1)
+	go c.writePump()
+	go c.readPump()
+}
+
+// Publish sends a message to every client subscribed to topic.
+// Clients that cannot keep up are disconnected rather than blocking the publisher.
+func (h *Hub) Publish(topic, messageType string, data interface{}) {
+	payload, err := json.Marshal(Message{
+		Topic:     topic,
+		Type:      messageType,
+		Data:      data,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		log.Printf("⚠️ Warning: failed to encode %s message: %v", messageType, err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if !c.subscribed(topic) {
+			continue
+		}
+		select {
+		case c.send <- payload:
+		default:
+			h.removeLocked(c)
+		}
+	}
+}
+
+// ClientCount returns the number of connected clients
+func (h *Hub) ClientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
+// Shutdown rejects new connections and closes existing ones with a going-away close frame,
+// waiting up to writeWait for the close frames to be written
+func (h *Hub) Shutdown() {
+	h.mu.Lock()
+	h.closed = true
+	for c := range h.clients {
+		h.removeLocked(c)
+	}
+	h.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.writers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(writeWait):
+	}
+}
+
+// removeLocked unregisters a client and closes its send channel; the caller must hold h.mu
+func (h *Hub) removeLocked(c *client) {
+	if _, ok := h.clients[c]; ok {
+		delete(h.clients, c)
+		close(c.send)
+	}
+}
+
+// remove unregisters a client
+func (h *Hub) remove(c *client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c)
+}
+
+// subscribed reports whether the client listens to topic
+func (c *client) subscribed(topic string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.topics[topic]
+}
+
+// readPump handles subscription changes and keeps the connection alive with pongs
+func (c *client) readPump() {
+	defer func() {
+		c.hub.remove(c)
+		c.conn.Close()
+	}()
+
+	c.conn.SetReadLimit(maxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+
+	for {
+		var req subscriptionRequest
+		if err := c.conn.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("WebSocket read error: %v", err)
+			}
+			return
+		}
+
+		c.mu.Lock()
+		switch req.Action {
+		case "subscribe":
+			for _, topic := range req.Topics {
+				c.topics[topic] = true
+			}
+		case "unsubscribe":
+			for _, topic := range req.Topics {
+				delete(c.topics, topic)
+			}
+		}
+		c.mu.Unlock()
+	}
+}
+
+// writePump delivers queued messages and pings; it sends a close frame once the hub drops the client
+func (c *client) writePump() {
+	ticker := time.NewTicker(pingPeriod)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+		c.hub.writers.Done()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if !ok {
+				c.conn.WriteMessage(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseGoingAway, "server closing connection"))
+				return
+			}
+			if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package retention
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"hoctap-api/database"
+)
+
+// Result is the outcome of applying one policy
+type Result struct {
+	Entity   string `json:"entity"`
+	Action   string `json:"action"`
+	Affected int64  `json:"affected"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Runner periodically applies the enabled retention policies
+type Runner struct {
+	repo  *database.RetentionRepository
+	audit *database.AuditRepository
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRunner creates a runner; call Start to schedule it
+func NewRunner(repo *database.RetentionRepository, audit *database.AuditRepository) *Runner {
+	return &Runner{repo: repo, audit: audit}
+}
+
+// Start applies the policies every interval until Stop is called
+func (r *Runner) Start(interval time.Duration) {
+	r.stop = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				r.RunOnce(context.Background())
+			case <-r.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the schedule, waiting for a running pass to finish
+func (r *Runner) Stop() {
+	if r.stop == nil {
+		return
+	}
+	close(r.stop)
+	<-r.done
+}
+
+// RunOnce applies every enabled policy now. Concurrent calls are serialized.
+func (r *Runner) RunOnce(ctx context.Context) ([]Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	policies, err := r.repo.GetPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	results := []Result{}
+	for _, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+
+		result := Result{Entity: policy.Entity, Action: policy.Action}
+		result.Affected, err = r.repo.ApplyPolicy(ctx, policy, now)
+		if err != nil {
+			result.Error = err.Error()
+			log.Printf("⚠️ Warning: %v", err)
+		} else if result.Affected > 0 {
+			log.Printf("🧹 Retention: %s %d %s record(s) older than %d days",
+				pastTense(policy.Action), result.Affected, policy.Entity, policy.Days)
+			r.recordAudit(ctx, policy, result.Affected)
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// recordAudit logs a retention pass that changed data
+func (r *Runner) recordAudit(ctx context.Context, policy database.RetentionPolicy, affected int64) {
+	action := database.AuditActionDelete
+	if policy.Action == database.RetentionActionAnonymize {
+		action = database.AuditActionUpdate
+	}
+
+	err := r.audit.Record(context.WithoutCancel(ctx), database.AuditActor{Name: "retention"}, action, policy.Entity, 0, nil, map[string]interface{}{
+		"policy":   policy,
+		"affected": affected,
+	})
+	if err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+}
+
+// Helper function for log messages
+func pastTense(action string) string {
+	if action == database.RetentionActionAnonymize {
+		return "anonymized"
+	}
+	return "deleted"
+}
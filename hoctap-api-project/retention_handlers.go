@@ -0,0 +1,103 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/retention"
+
+	"github.com/gorilla/mux"
+)
+
+// Global retention policy repository and runner
+var (
+	retentionRepo   *database.RetentionRepository
+	retentionRunner *retention.Runner
+)
+
+// Get the configured retention policies and the entities that support them
+func getRetentionPoliciesHandler(w http.ResponseWriter, r *http.Request) {
+	policies, err := retentionRepo.GetPolicies(r.Context())
+	if err != nil {
+		log.Printf("Error getting retention policies: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("retention_policies_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("retention_policies_retrieved"), map[string]interface{}{
+		"policies":           policies,
+		"supported_entities": database.RetentionEntities(),
+	})
+}
+
+// retentionPolicyInput is the request body for saving a retention policy
+type retentionPolicyInput struct {
+	Action  string `json:"action"`
+	Days    int    `json:"days"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// Create or replace the retention policy of an entity
+func saveRetentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	var policyData retentionPolicyInput
+
+	if err := decodeRequestBody(r, &policyData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+		return
+	}
+
+	policy := database.RetentionPolicy{
+		Entity:  mux.Vars(r)["entity"],
+		Action:  policyData.Action,
+		Days:    policyData.Days,
+		Enabled: policyData.Enabled == nil || *policyData.Enabled,
+	}
+
+	// Validation
+	if err := database.ValidateRetentionPolicy(policy); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.MessageOf(err), nil)
+		return
+	}
+
+	if err := retentionRepo.SavePolicy(r.Context(), policy); err != nil {
+		log.Printf("Error saving retention policy: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("retention_policy_save_failed"), nil)
+		return
+	}
+
+	recordAuditEntry(r, database.AuditActionUpdate, "retention_policy", 0, policy)
+	sendJSONResponse(w, http.StatusOK, i18n.M("retention_policy_saved"), policy)
+}
+
+// Delete the retention policy of an entity
+func deleteRetentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	entity := mux.Vars(r)["entity"]
+
+	if err := retentionRepo.DeletePolicy(r.Context(), entity); err != nil {
+		log.Printf("Error deleting retention policy: %v", err)
+		if errors.Is(err, database.ErrRetentionPolicyNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("retention_policy_not_found", "entity", entity), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("retention_policy_delete_failed"), nil)
+		}
+		return
+	}
+
+	recordAuditEntry(r, database.AuditActionDelete, "retention_policy", 0, map[string]string{"entity": entity})
+	sendJSONResponse(w, http.StatusOK, i18n.M("retention_policy_deleted"), nil)
+}
+
+// Apply the enabled retention policies immediately
+func runRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	results, err := retentionRunner.RunOnce(r.Context())
+	if err != nil {
+		log.Printf("Error running retention policies: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("retention_run_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("retention_policies_applied"), results)
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+
+	"hoctap-api/config"
+	"hoctap-api/plugins"
+
+	"github.com/gorilla/mux"
+)
+
+// Build the HTTP router with middleware, pages and every API route
+func newRouter() *mux.Router {
+	router := mux.NewRouter()
+
+	// Apply middleware
+	router.Use(withClientIdentity)
+	router.Use(enableCORS)
+	router.Use(logRequest)
+	router.Use(negotiateContent)
+	router.Use(limitConcurrentRequests)
+	router.Use(plugins.Middleware()...)
+
+	// Serve static files (CSS, JS). Only these paths are routed, so a live assets directory
+	// exposes nothing else.
+	assets := newAssetHandler()
+	router.Handle("/static/styles.css", http.StripPrefix("/static", assets)).Methods("GET")
+	router.Handle("/static/script.js", http.StripPrefix("/static", assets)).Methods("GET")
+
+	// Render the dashboard at root
+	router.HandleFunc("/", dashboardHandler).Methods("GET")
+
+	// API routes
+	router.HandleFunc("/health", healthHandler).Methods("GET")
+	router.HandleFunc("/livez", livezHandler).Methods("GET")
+	router.HandleFunc("/readyz", readyzHandler).Methods("GET")
+	router.HandleFunc("/startupz", startupzHandler).Methods("GET")
+	router.HandleFunc("/lb-health", lbHealthHandler).Methods("GET")
+	router.HandleFunc("/welcome", welcomeHandler).Methods("GET")
+	router.HandleFunc("/s/{code}", redirectShortLinkHandler).Methods("GET")
+	router.HandleFunc(avatarPathPrefix+`{file:[0-9]+-[0-9a-f]+\.jpg}`, serveAvatarFileHandler).Methods("GET")
+	router.HandleFunc(storedFilePathPrefix+"{key:.+}", serveStoredFileHandler).Methods("GET")
+	router.Handle("/ws", liveHub).Methods("GET")
+	router.HandleFunc("/graphql", requireDatabase(graphQLHandler)).Methods("GET", "POST")
+	router.HandleFunc("/openapi.json", openAPIHandler).Methods("GET")
+	router.HandleFunc("/docs", swaggerUIHandler).Methods("GET")
+
+	// Ops endpoints and profiles move to the admin listener when ADMIN_PORT is set
+	if config.Current().Server.AdminPort == "" {
+		registerOpsRoutes(router)
+		registerProfilingRoutes(router, publicProfile)
+	}
+
+	registerAPIRoutes(router, registerAPIv1Routes)
+	plugins.RegisterRoutes(router)
+
+	return router
+}
+
+// Build the router of the internal admin listener (ADMIN_PORT): the ops endpoints plus the
+// health checks, so internal monitoring does not need the public port
+func newAdminRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.Use(withClientIdentity)
+	router.Use(logRequest)
+
+	router.HandleFunc("/health", healthHandler).Methods("GET")
+	router.HandleFunc("/livez", livezHandler).Methods("GET")
+	router.HandleFunc("/readyz", readyzHandler).Methods("GET")
+	router.HandleFunc("/startupz", startupzHandler).Methods("GET")
+	router.HandleFunc("/lb-health", lbHealthHandler).Methods("GET")
+	registerOpsRoutes(router)
+	registerAPIRoutes(router, registerOpsAPIv1Routes)
+	registerProfilingRoutes(router, pprof.Profile)
+
+	return router
+}
+
+// Register the ops endpoints outside the API, which are served either on the public router
+// or on the admin listener. They still require the admin token on either.
+func registerOpsRoutes(router *mux.Router) {
+	// How this process started, for debugging slow startups
+	router.HandleFunc("/api/admin/boot-report", requireAdmin(getBootReportHandler)).Methods("GET")
+
+	// Runtime and leak watchdog metrics
+	router.HandleFunc("/debug/vars", requireAdmin(expvar.Handler().ServeHTTP)).Methods("GET")
+}
+
+// Register the ops endpoints of the v1 API: background jobs, the scheduler, retention runs
+// and database failover. Like the other ops endpoints they move to the admin listener when
+// ADMIN_PORT is set, under the same /api/v1 and /api paths.
+func registerOpsAPIv1Routes(api *mux.Router) {
+	api.HandleFunc("/jobs", requireAdmin(getJobsHandler)).Methods("GET")
+	api.HandleFunc("/jobs/stats", requireAdmin(getJobStatsHandler)).Methods("GET")
+	api.HandleFunc("/jobs/{id:[0-9]+}", requireAdmin(getJobHandler)).Methods("GET")
+	api.HandleFunc("/jobs/{id:[0-9]+}", requireAdmin(deleteJobHandler)).Methods("DELETE")
+	api.HandleFunc("/jobs/{id:[0-9]+}/retry", requireAdmin(retryJobHandler)).Methods("POST")
+	api.HandleFunc("/scheduler", requireAdmin(getSchedulerHandler)).Methods("GET")
+	api.HandleFunc("/retention/run", requireAdmin(runRetentionHandler)).Methods("POST")
+	api.HandleFunc("/database/failover", requireAdmin(getFailoverStatusHandler)).Methods("GET")
+	api.HandleFunc("/database/switchover", requireAdmin(switchoverHandler)).Methods("POST")
+}
+
+// Register the runtime profiles for performance work, with profile serving the CPU profile.
+// They are served on the admin listener, which has no write timeout, so CPU profiles and
+// traces stream for as long as requested; without one, on the public router.
+func registerProfilingRoutes(router *mux.Router, profile http.HandlerFunc) {
+	router.HandleFunc("/debug/pprof/cmdline", requireAdmin(pprof.Cmdline))
+	router.HandleFunc("/debug/pprof/profile", requireAdmin(profile))
+	router.HandleFunc("/debug/pprof/symbol", requireAdmin(pprof.Symbol))
+	router.HandleFunc("/debug/pprof/trace", requireAdmin(pprof.Trace))
+	router.PathPrefix("/debug/pprof/").HandlerFunc(requireAdmin(pprof.Index))
+}
+
+// publicProfileSeconds is the default CPU profile duration on the public listener, whose
+// 15s WriteTimeout refuses pprof's default of 30s
+const publicProfileSeconds = "10"
+
+// Serve a CPU profile on the public router, defaulting to publicProfileSeconds
+func publicProfile(w http.ResponseWriter, r *http.Request) {
+	if query := r.URL.Query(); query.Get("seconds") == "" {
+		query.Set("seconds", publicProfileSeconds)
+		r.URL.RawQuery = query.Encode()
+	}
+	pprof.Profile(w, r)
+}
+
+// Register the versioned JSON API, with the v1 routes registered by registerV1. Every
+// version gets its own subrouter, so a future /api/v2 can change handlers or the response
+// envelope while v1 clients keep working. The unversioned /api prefix remains as a
+// deprecated alias of v1.
+func registerAPIRoutes(router *mux.Router, registerV1 func(api *mux.Router)) {
+	// /api/v1 must be registered before the /api alias, which would otherwise match it first
+	v1 := router.PathPrefix("/api/v1").Subrouter()
+	v1.Use(failFastWithoutDatabase, idempotentPOST)
+	registerV1(v1)
+
+	legacy := router.PathPrefix("/api").Subrouter()
+	legacy.Use(deprecatedAPIAlias("/api", "/api/v1"), failFastWithoutDatabase, idempotentPOST)
+	registerV1(legacy)
+}
+
+// Register the v1 API routes on api, with the ops endpoints unless ADMIN_PORT moves them to
+// the admin listener
+func registerAPIv1Routes(api *mux.Router) {
+	api.HandleFunc("/users", getUsersHandler).Methods("GET")
+	api.HandleFunc("/users/stats", getUsersStatsHandler).Methods("GET")
+	api.HandleFunc("/users/search", searchUsersHandler).Methods("GET")
+	api.HandleFunc("/analytics/summary", getAnalyticsSummaryHandler).Methods("GET")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}", getUserByIDHandler).Methods("GET")
+	api.HandleFunc("/users", createUserHandler).Methods("POST")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}", updateUserHandler).Methods("PUT")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}", deleteUserHandler).Methods("DELETE")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}/experiments", getUserExperimentsHandler).Methods("GET")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}/avatar", getUserAvatarHandler).Methods("GET")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}/avatar", uploadUserAvatarHandler).Methods("POST")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}/legal-hold", requireAdmin(setUserLegalHoldHandler)).Methods("PUT")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}/activity", requireAdmin(getUserActivityHandler)).Methods("GET")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}/notification-preferences", getNotificationPreferencesHandler).Methods("GET")
+	api.HandleFunc("/users/{id:"+userIDPattern+"}/notification-preferences", saveNotificationPreferencesHandler).Methods("PUT")
+	api.HandleFunc("/notifications", getNotificationsHandler).Methods("GET")
+	api.HandleFunc("/notifications/unread-count", getUnreadNotificationCountHandler).Methods("GET")
+	api.HandleFunc("/notifications/read-all", markAllNotificationsReadHandler).Methods("POST")
+	api.HandleFunc("/notifications/{id:[0-9]+}/read", markNotificationReadHandler).Methods("POST")
+	api.HandleFunc("/public/stats", requirePublicStatsKey(getPublicStatsHandler)).Methods("GET")
+	api.HandleFunc("/audit", requireAdmin(getAuditLogHandler)).Methods("GET")
+	api.HandleFunc("/qr", qrCodeHandler).Methods("GET")
+	api.HandleFunc("/events/track", trackEventsHandler).Methods("POST")
+	api.HandleFunc("/webhooks", requireAdmin(getWebhooksHandler)).Methods("GET")
+	api.HandleFunc("/webhooks", requireAdmin(createWebhookHandler)).Methods("POST")
+	api.HandleFunc("/webhooks/{id:[0-9]+}", requireAdmin(deleteWebhookHandler)).Methods("DELETE")
+	api.HandleFunc("/webhooks/{id:[0-9]+}/deliveries", requireAdmin(getWebhookDeliveriesHandler)).Methods("GET")
+	api.HandleFunc("/short-links", requireAdmin(getShortLinksHandler)).Methods("GET")
+	api.HandleFunc("/short-links", requireAdmin(createShortLinkHandler)).Methods("POST")
+	api.HandleFunc("/short-links/{code}", requireAdmin(deleteShortLinkHandler)).Methods("DELETE")
+	api.HandleFunc("/announcements/active", getActiveAnnouncementsHandler).Methods("GET")
+	api.HandleFunc("/announcements", requireAdmin(getAnnouncementsHandler)).Methods("GET")
+	api.HandleFunc("/announcements", requireAdmin(createAnnouncementHandler)).Methods("POST")
+	api.HandleFunc("/announcements/{id:[0-9]+}", requireAdmin(deleteAnnouncementHandler)).Methods("DELETE")
+	api.HandleFunc("/retention/policies", requireAdmin(getRetentionPoliciesHandler)).Methods("GET")
+	api.HandleFunc("/retention/policies/{entity}", requireAdmin(saveRetentionPolicyHandler)).Methods("PUT")
+	api.HandleFunc("/retention/policies/{entity}", requireAdmin(deleteRetentionPolicyHandler)).Methods("DELETE")
+	api.HandleFunc("/validation-rules", requireAdmin(getValidationRulesHandler)).Methods("GET")
+	api.HandleFunc("/validation-rules", requireAdmin(createValidationRuleHandler)).Methods("POST")
+	api.HandleFunc("/validation-rules/{id:[0-9]+}", requireAdmin(deleteValidationRuleHandler)).Methods("DELETE")
+
+	if config.Current().Server.AdminPort == "" {
+		registerOpsAPIv1Routes(api)
+	}
+}
+
+// apiLifecycle holds the API lifecycle dates. They need a restart to change, so serve parses
+// them once, after the configuration was validated.
+var apiLifecycle config.APILifecycle
+
+// Middleware marking responses from a deprecated route prefix with Deprecation (RFC 9745),
+// Sunset (RFC 8594) and a Link to the same resource under the successor prefix
+func deprecatedAPIAlias(prefix, successor string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", "@"+strconv.FormatInt(apiLifecycle.LegacyDeprecatedAt.Unix(), 10))
+			w.Header().Set("Sunset", apiLifecycle.LegacySunset.Format(http.TimeFormat))
+			w.Header().Set("Link", "<"+successor+r.URL.Path[len(prefix):]+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"hoctap-api/database"
+
+	lua "github.com/yuin/gopher-lua"
+	"github.com/yuin/gopher-lua/parse"
+)
+
+// Sandbox limits for a single evaluation
+const (
+	evaluationTimeout = 100 * time.Millisecond
+	callStackSize     = 64
+	registryMaxSize   = 64 * 1024
+)
+
+// Violation is returned when a record fails a validation rule
+type Violation struct {
+	Rule    string
+	Message string
+	// Code is the message code of a violation without a message of the script's own, which
+	// clients get translated; it is empty for the message the script chose
+	Code string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// compiledRule is a validation rule ready to run
+type compiledRule struct {
+	name  string
+	proto *lua.FunctionProto
+}
+
+// Engine evaluates the enabled validation rules of each entity. Every evaluation runs in a
+// fresh Lua state with only the base, string, table and math libraries, no file or module
+// access, and a time limit.
+type Engine struct {
+	mu    sync.RWMutex
+	rules map[string][]compiledRule
+}
+
+// NewEngine creates an engine without rules
+func NewEngine() *Engine {
+	return &Engine{rules: map[string][]compiledRule{}}
+}
+
+// Compile checks that a script is valid Lua and compiles it
+func Compile(name, script string) (*lua.FunctionProto, error) {
+	chunk, err := parse.Parse(strings.NewReader(script), name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %v", err)
+	}
+
+	proto, err := lua.Compile(chunk, name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid script: %v", err)
+	}
+
+	return proto, nil
+}
+
+// Load replaces the rules of the engine with the enabled ones in list. Rules that no
+// longer compile are skipped with a warning.
+func (e *Engine) Load(list []database.ValidationRule) {
+	rules := map[string][]compiledRule{}
+	for _, rule := range list {
+		if !rule.Enabled {
+			continue
+		}
+
+		proto, err := Compile(rule.Name, rule.Script)
+		if err != nil {
+			log.Printf("⚠️ Warning: skipping validation rule '%s': %v", rule.Name, err)
+			continue
+		}
+		rules[rule.Entity] = append(rules[rule.Entity], compiledRule{name: rule.Name, proto: proto})
+	}
+
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+}
+
+// Validate runs the rules of entity against record. A script sees the record as the global
+// table `record` and passes by returning true (or nothing); it fails by returning false and
+// an optional message. Scripts that raise errors or time out fail the record too.
+func (e *Engine) Validate(ctx context.Context, entity string, record map[string]string) error {
+	e.mu.RLock()
+	rules := e.rules[entity]
+	e.mu.RUnlock()
+
+	for _, rule := range rules {
+		if err := evaluate(ctx, rule, record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Helper function to run one rule in a sandboxed Lua state
+func evaluate(ctx context.Context, rule compiledRule, record map[string]string) error {
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:        true,
+		CallStackSize:       callStackSize,
+		RegistryMaxSize:     registryMaxSize,
+		MinimizeStackMemory: true,
+	})
+	defer L.Close()
+
+	openSandboxLibs(L)
+
+	ctx, cancel := context.WithTimeout(ctx, evaluationTimeout)
+	defer cancel()
+	L.SetContext(ctx)
+
+	table := L.NewTable()
+	for field, value := range record {
+		table.RawSetString(field, lua.LString(value))
+	}
+	L.SetGlobal("record", table)
+
+	L.Push(L.NewFunctionFromProto(rule.proto))
+	if err := L.PCall(0, 2, nil); err != nil {
+		log.Printf("⚠️ Warning: validation rule '%s' failed to run: %v", rule.name, err)
+		return &Violation{Rule: rule.name, Message: fmt.Sprintf("validation rule '%s' could not be evaluated", rule.name),
+			Code: "validation_rule_unevaluable"}
+	}
+
+	passed, message := L.Get(-2), L.Get(-1)
+	if passed == lua.LNil || lua.LVAsBool(passed) {
+		return nil
+	}
+
+	if message == lua.LNil {
+		return &Violation{Rule: rule.name, Message: fmt.Sprintf("validation rule '%s' failed", rule.name),
+			Code: "validation_rule_failed"}
+	}
+	return &Violation{Rule: rule.name, Message: lua.LVAsString(message)}
+}
+
+// Helper function to open the libraries scripts may use, without file, OS or module access
+func openSandboxLibs(L *lua.LState) {
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.StringLibName, lua.OpenString},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+
+	for _, name := range []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage", "print"} {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	// string.rep can allocate arbitrarily large strings
+	if str, ok := L.GetGlobal(lua.StringLibName).(*lua.LTable); ok {
+		str.RawSetString("rep", lua.LNil)
+	}
+}
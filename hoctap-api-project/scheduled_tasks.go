@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"hoctap-api/config"
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/scheduler"
+)
+
+// taskScheduler runs the recurring tasks on the instance holding the leader lock; nil in
+// mock mode
+var taskScheduler *scheduler.Scheduler
+
+// scheduledTasks are the recurring tasks by name. A feature adds its task here and its
+// cron expression to config.SchedulerConfig.
+var scheduledTasks = map[string]scheduler.Task{
+	"purge_idempotency_keys": purgeIdempotencyKeys,
+	"purge_outbox":           purgeOutbox,
+}
+
+// Helper function to schedule the enabled tasks and start electing the instance that runs
+// them. It returns a function stopping the scheduler.
+func startScheduler(cfg config.SchedulerConfig) (func(), error) {
+	taskScheduler = scheduler.New(database.NewLeaderLock("hoctap_scheduler"))
+	for name, expr := range cfg.Tasks() {
+		if expr == "" {
+			log.Printf("🕒 Scheduled task %s is disabled", name)
+			continue
+		}
+		if err := taskScheduler.Add(name, expr, scheduledTasks[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	taskScheduler.Start()
+	return taskScheduler.Stop, nil
+}
+
+// Delete the idempotency keys whose responses are no longer replayed
+func purgeIdempotencyKeys(ctx context.Context) error {
+	purged, err := idempotencyRepo.PurgeExpired(ctx)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("🧹 Purged %d expired idempotency key(s)", purged)
+	}
+	return nil
+}
+
+// Delete the outbox events published longer ago than the outbox retention
+func purgeOutbox(ctx context.Context) error {
+	cutoff := time.Now().Add(-config.Current().Outbox.Retention)
+	purged, err := outboxRepo.PurgePublished(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+	if purged > 0 {
+		log.Printf("🧹 Purged %d published outbox event(s)", purged)
+	}
+	return nil
+}
+
+// Get the scheduled tasks with their next run and last outcome, and whether this instance
+// is the one running them
+func getSchedulerHandler(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, http.StatusOK, i18n.M("scheduler_status_retrieved"), taskScheduler.Status())
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		same    string
+		wantErr bool
+	}{
+		{name: "yearly", expr: "@yearly", same: "0 0 1 1 *"},
+		{name: "annually", expr: "@annually", same: "0 0 1 1 *"},
+		{name: "monthly", expr: "@monthly", same: "0 0 1 * *"},
+		{name: "weekly", expr: "@weekly", same: "0 0 * * 0"},
+		{name: "daily", expr: " @daily ", same: "0 0 * * *"},
+		{name: "midnight", expr: "@midnight", same: "0 0 * * *"},
+		{name: "hourly", expr: "@hourly", same: "0 * * * *"},
+		{name: "step", expr: "*/20 * * * *", same: "0,20,40 * * * *"},
+		{name: "step from value", expr: "45/5 * * * *", same: "45,50,55 * * * *"},
+		{name: "range step", expr: "0 1-10/3 * * *", same: "0 1,4,7,10 * * *"},
+		{name: "list of ranges", expr: "0 0 * * 1-2,4-5", same: "0 0 * * 1,2,4,5"},
+		{name: "too few fields", expr: "* * * *", wantErr: true},
+		{name: "too many fields", expr: "* * * * * *", wantErr: true},
+		{name: "unknown descriptor", expr: "@every 5m", wantErr: true},
+		{name: "zero step", expr: "*/0 * * * *", wantErr: true},
+		{name: "negative step", expr: "*/-5 * * * *", wantErr: true},
+		{name: "non-numeric step", expr: "*/x * * * *", wantErr: true},
+		{name: "reversed range", expr: "30-10 * * * *", wantErr: true},
+		{name: "open range", expr: "10- * * * *", wantErr: true},
+		{name: "minute out of range", expr: "60 * * * *", wantErr: true},
+		{name: "hour out of range", expr: "0 24 * * *", wantErr: true},
+		{name: "day of month zero", expr: "0 0 0 * *", wantErr: true},
+		{name: "month out of range", expr: "0 0 1 13 *", wantErr: true},
+		{name: "day of week out of range", expr: "0 0 * * 8", wantErr: true},
+		{name: "range end out of range", expr: "0 0 * * 5-8", wantErr: true},
+		{name: "empty list item", expr: "1,,2 * * * *", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) succeeded, want an error", tt.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+			}
+
+			want, err := Parse(tt.same)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.same, err)
+			}
+			if !reflect.DeepEqual(schedule, want) {
+				t.Errorf("Parse(%q) = %+v, want %+v (%q)", tt.expr, schedule, want, tt.same)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every 15 minutes", "*/15 * * * *", date(2025, 6, 2, 10, 7), date(2025, 6, 2, 10, 15)},
+		{"strictly after", "0 12 * * *", date(2025, 6, 2, 12, 0), date(2025, 6, 3, 12, 0)},
+		{"seconds truncated", "0 12 * * *", date(2025, 6, 2, 11, 59).Add(30 * time.Second), date(2025, 6, 2, 12, 0)},
+		{"next hour", "30 * * * *", date(2025, 6, 2, 10, 45), date(2025, 6, 2, 11, 30)},
+		{"next month", "0 0 1 * *", date(2025, 1, 31, 12, 0), date(2025, 2, 1, 0, 0)},
+		{"next year", "0 0 1 1 *", date(2025, 6, 2, 0, 0), date(2026, 1, 1, 0, 0)},
+		{"end of year", "59 23 31 12 *", date(2025, 12, 31, 23, 58), date(2025, 12, 31, 23, 59)},
+		{"skips short months", "0 0 31 * *", date(2025, 4, 1, 0, 0), date(2025, 5, 31, 0, 0)},
+		{"leap day", "0 0 29 2 *", date(2025, 3, 1, 0, 0), date(2028, 2, 29, 0, 0)},
+		{"leap day across 2100", "0 0 29 2 *", date(2096, 3, 1, 0, 0), date(2104, 2, 29, 0, 0)},
+		{"day of month only", "0 0 13 * *", date(2025, 6, 2, 0, 0), date(2025, 6, 13, 0, 0)},
+		{"day of week only", "0 0 * * 1", date(2025, 6, 3, 0, 0), date(2025, 6, 9, 0, 0)},
+		{"both day fields, weekday first", "0 0 13 * 1", date(2025, 6, 3, 0, 0), date(2025, 6, 9, 0, 0)},
+		{"both day fields, date first", "0 0 13 * 1", date(2025, 6, 10, 0, 0), date(2025, 6, 13, 0, 0)},
+		{"day of week within month", "0 0 * 7 1", date(2025, 6, 10, 0, 0), date(2025, 7, 7, 0, 0)},
+		{"sunday as 0", "0 0 * * 0", date(2025, 6, 2, 0, 0), date(2025, 6, 8, 0, 0)},
+		{"sunday as 7", "0 0 * * 7", date(2025, 6, 2, 0, 0), date(2025, 6, 8, 0, 0)},
+		{"weekdays skip the weekend", "0 9 * * 1-5", date(2025, 6, 6, 10, 0), date(2025, 6, 9, 9, 0)},
+		{"never matches", "0 0 30 2 *", date(2025, 1, 1, 0, 0), time.Time{}},
+		{"april 31st", "0 0 31 4 *", date(2025, 1, 1, 0, 0), time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
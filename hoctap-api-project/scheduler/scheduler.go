@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// checkInterval is how often an instance checks or tries to take the leadership
+const checkInterval = 15 * time.Second
+
+// Task is recurring work; the context is cancelled when the scheduler stops
+type Task func(ctx context.Context) error
+
+// Leader elects the one instance running the tasks, such as a database advisory lock
+type Leader interface {
+	// Acquire reports whether this instance leads, taking the leadership when it is free
+	Acquire(ctx context.Context) (bool, error)
+	// Release gives up the leadership
+	Release(ctx context.Context) error
+}
+
+// TaskStatus is the schedule and last outcome of a task on this instance
+type TaskStatus struct {
+	Name       string     `json:"name"`
+	Schedule   string     `json:"schedule"`
+	NextRun    time.Time  `json:"next_run"`
+	Running    bool       `json:"running"`
+	Runs       int64      `json:"runs"`
+	LastRun    *time.Time `json:"last_run"`
+	DurationMs int64      `json:"last_duration_ms"`
+	LastError  string     `json:"last_error"`
+}
+
+// Status tells whether this instance leads and how its tasks went. Only the leader runs
+// tasks, so the runs of a follower are those of its past leaderships.
+type Status struct {
+	Leader bool         `json:"leader"`
+	Tasks  []TaskStatus `json:"tasks"`
+}
+
+// task is a registered task with its state
+type task struct {
+	name     string
+	expr     string
+	schedule *Schedule
+	run      Task
+
+	next     time.Time
+	running  bool
+	runs     int64
+	lastRun  *time.Time
+	duration time.Duration
+	lastErr  string
+}
+
+// Scheduler runs tasks on cron schedules on the one instance holding the leadership.
+// Runs missed while another instance leads, or while the previous run is still going, are
+// skipped rather than made up.
+type Scheduler struct {
+	leader Leader
+
+	mu       sync.Mutex
+	tasks    []*task
+	isLeader bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates a scheduler electing its leader with leader; add tasks, then call Start
+func New(leader Leader) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		leader: leader,
+		ctx:    ctx,
+		cancel: cancel,
+		stop:   make(chan struct{}),
+	}
+}
+
+// Add schedules a task with a cron expression (see Parse)
+func (s *Scheduler) Add(name, expr string, run Task) error {
+	schedule, err := Parse(expr)
+	if err != nil {
+		return fmt.Errorf("task %s: %v", name, err)
+	}
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("task %s: '%s' never matches", name, expr)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tasks = append(s.tasks, &task{name: name, expr: expr, schedule: schedule, run: run, next: next})
+	return nil
+}
+
+// Start starts electing the leader and running the due tasks
+func (s *Scheduler) Start() {
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop cancels the running tasks, waits for them to return and gives up the leadership
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.cancel()
+	s.wg.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.leader.Release(ctx); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+
+	s.mu.Lock()
+	s.isLeader = false
+	s.mu.Unlock()
+}
+
+// Status returns the leadership and the state of every task, sorted by name
+func (s *Scheduler) Status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := Status{Leader: s.isLeader, Tasks: make([]TaskStatus, 0, len(s.tasks))}
+	for _, t := range s.tasks {
+		status.Tasks = append(status.Tasks, TaskStatus{
+			Name:       t.name,
+			Schedule:   t.expr,
+			NextRun:    t.next,
+			Running:    t.running,
+			Runs:       t.runs,
+			LastRun:    t.lastRun,
+			DurationMs: t.duration.Milliseconds(),
+			LastError:  t.lastErr,
+		})
+	}
+	sort.Slice(status.Tasks, func(i, j int) bool { return status.Tasks[i].Name < status.Tasks[j].Name })
+	return status
+}
+
+// loop checks the leadership and starts the due tasks until the scheduler is stopped,
+// waking up for the next due task or the next leadership check
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	for {
+		wait := s.tick(time.Now())
+
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// tick updates the leadership and, leading, starts the tasks due at now. It returns how
+// long to wait for the next tick.
+func (s *Scheduler) tick(now time.Time) time.Duration {
+	ctx, cancel := context.WithTimeout(s.ctx, 5*time.Second)
+	leader, err := s.leader.Acquire(ctx)
+	cancel()
+	if err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if leader != s.isLeader {
+		if leader {
+			log.Printf("🕒 This instance now runs the scheduled tasks")
+		} else {
+			log.Printf("🕒 This instance no longer runs the scheduled tasks")
+		}
+		s.isLeader = leader
+	}
+
+	wait := checkInterval
+	for _, t := range s.tasks {
+		if !now.Before(t.next) {
+			t.next = t.schedule.Next(now)
+			if leader && !t.running {
+				t.running = true
+				s.wg.Add(1)
+				go s.runTask(t)
+			}
+		}
+		if until := t.next.Sub(now); !t.next.IsZero() && until < wait {
+			wait = until
+		}
+	}
+	return wait
+}
+
+// runTask runs a task and records the outcome
+func (s *Scheduler) runTask(t *task) {
+	defer s.wg.Done()
+
+	start := time.Now()
+	err := s.call(t)
+	duration := time.Since(start)
+	if err != nil {
+		log.Printf("❌ Scheduled task %s failed after %s: %v", t.name, duration.Round(time.Millisecond), err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.running = false
+	t.runs++
+	t.lastRun = &start
+	t.duration = duration
+	t.lastErr = ""
+	if err != nil {
+		t.lastErr = err.Error()
+	}
+}
+
+// call runs a task, turning a panic into an error
+func (s *Scheduler) call(t *task) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return t.run(s.ctx)
+}
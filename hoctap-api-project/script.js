@@ -4,11 +4,13 @@ const API_BASE_URL = 'http://localhost:8080';
 // DOM Elements
 const apiStatus = document.getElementById('api-status');
 const lastCheck = document.getElementById('last-check');
+const userCount = document.getElementById('user-count');
 const responseTime = document.getElementById('response-time');
 const checkHealthBtn = document.getElementById('check-health');
 const refreshUsersBtn = document.getElementById('refresh-users');
 const addUserForm = document.getElementById('add-user-form');
 const usersContainer = document.getElementById('users-container');
+const signupChart = document.getElementById('signup-chart');
 const responseContainer = document.getElementById('response-container');
 const toastContainer = document.getElementById('toast-container');
 
@@ -23,14 +25,22 @@ document.addEventListener('DOMContentLoaded', function() {
     // Set up event listeners
     setupEventListeners();
     
-    // Initial health check
-    checkApiHealth();
+    // Initial health check, unless the server rendered the status
+    if (!('rendered' in apiStatus.dataset)) {
+        checkApiHealth();
+    }
     
-    // Load users
-    loadUsers();
+    // Load users; the server renders the most recent ones, so refresh without a spinner
+    loadUsers({ quiet: 'rendered' in usersContainer.dataset });
+
+    // Growth chart
+    loadSignupChart();
     
     // Auto-refresh every 30 seconds
     setInterval(checkApiHealth, 30000);
+
+    // Live updates over WebSocket
+    connectLiveUpdates();
 });
 
 // Event Listeners Setup
@@ -71,6 +81,28 @@ async function checkApiHealth() {
     }
 }
 
+// Live Updates via WebSocket (reconnects with backoff when the connection drops)
+function connectLiveUpdates(delay = 1000) {
+    const wsUrl = API_BASE_URL.replace(/^http/, 'ws') + '/ws';
+    const socket = new WebSocket(wsUrl);
+
+    socket.addEventListener('open', () => {
+        delay = 1000;
+        console.log('🔌 Live updates connected');
+    });
+
+    socket.addEventListener('message', (event) => {
+        const message = JSON.parse(event.data);
+        if (message.topic === 'users') {
+            loadUsers();
+        }
+    });
+
+    socket.addEventListener('close', () => {
+        setTimeout(() => connectLiveUpdates(Math.min(delay * 2, 30000)), delay);
+    });
+}
+
 // Update API Status Display
 function updateApiStatus(status, text) {
     apiStatus.className = `status-badge ${status}`;
@@ -95,15 +127,18 @@ function updateResponseTime(ms) {
 }
 
 // Load Users from API
-async function loadUsers() {
+async function loadUsers({ quiet = false } = {}) {
     try {
-        showLoading(usersContainer);
+        if (!quiet) {
+            showLoading(usersContainer);
+        }
         
-        const response = await fetch(`${API_BASE_URL}/api/users`);
+        const response = await fetch(`${API_BASE_URL}/api/v1/users`);
         const data = await response.json();
         
         if (response.ok) {
             users = data.data || [];
+            userCount.textContent = users.length;
             renderUsers();
             console.log('📋 Users loaded:', users);
         } else {
@@ -116,6 +151,33 @@ async function loadUsers() {
     }
 }
 
+// Load the signups per day of the last 30 days, in the browser's time zone
+async function loadSignupChart() {
+    try {
+        const tz = Intl.DateTimeFormat().resolvedOptions().timeZone;
+        const response = await fetch(`${API_BASE_URL}/api/v1/users/stats?interval=day&tz=${encodeURIComponent(tz)}`);
+        const data = await response.json();
+
+        if (response.ok) {
+            renderSignupChart(data.data.signups.buckets);
+        } else {
+            throw new Error(data.message || `HTTP ${response.status}`);
+        }
+    } catch (error) {
+        console.error('❌ Failed to load signups:', error);
+        signupChart.innerHTML = '<div class="chart-empty">Failed to load signups</div>';
+    }
+}
+
+// Render the signup buckets as bars scaled to the busiest day
+function renderSignupChart(buckets) {
+    const max = Math.max(1, ...buckets.map(bucket => bucket.count));
+
+    signupChart.innerHTML = buckets.map(bucket => `
+        <div class="chart-bar" title="${bucket.start}: ${bucket.count}" style="height: ${Math.round(bucket.count / max * 100)}%"></div>
+    `).join('');
+}
+
 // Render Users in the UI
 function renderUsers() {
     if (users.length === 0) {
@@ -136,7 +198,7 @@ function renderUsers() {
                 <p><i class="fas fa-id-badge"></i> ID: ${user.id}</p>
             </div>
             <div class="user-actions">
-                <button class="btn btn-outline btn-small" onclick="getUserDetails(${user.id})">
+                <button class="btn btn-outline btn-small" onclick="getUserDetails('${user.uuid}')">
                     <i class="fas fa-eye"></i> View
                 </button>
                 <button class="btn btn-secondary btn-small" onclick="editUser(${user.id})">
@@ -169,7 +231,7 @@ async function handleAddUser(event) {
     }
 
     try {
-        const response = await fetch(`${API_BASE_URL}/api/users`, {
+        const response = await fetch(`${API_BASE_URL}/api/v1/users`, {
             method: 'POST',
             headers: {
                 'Content-Type': 'application/json',
@@ -185,6 +247,7 @@ async function handleAddUser(event) {
             
             // Reload users
             await loadUsers();
+            loadSignupChart();
             
             showToast('Success', `User "${userData.name}" created successfully!`, 'success');
             console.log('✅ User created:', data.data);
@@ -200,11 +263,11 @@ async function handleAddUser(event) {
 // Get User Details
 async function getUserDetails(userId) {
     try {
-        const response = await fetch(`${API_BASE_URL}/api/users/${userId}`);
+        const response = await fetch(`${API_BASE_URL}/api/v1/users/${userId}`);
         const data = await response.json();
         
         if (response.ok) {
-            displayResponse('GET', `/api/users/${userId}`, data, response.status);
+            displayResponse('GET', `/api/v1/users/${userId}`, data, response.status);
             showToast('Success', `User details loaded for ID: ${userId}`, 'success');
         } else {
             throw new Error(data.message || `HTTP ${response.status}`);
@@ -0,0 +1,145 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fetchTimeout bounds a single read from a secret backend
+const fetchTimeout = 10 * time.Second
+
+// Provider reads secrets from a backend. Fetch returns the fields stored at path and how long
+// they are valid; a lease of zero means the secret does not expire on its own.
+type Provider interface {
+	Fetch(ctx context.Context, path string) (values map[string]string, lease time.Duration, err error)
+}
+
+// providers maps reference schemes to their backend
+var providers = map[string]Provider{
+	"vault": &vaultProvider{},
+	"ssm":   &ssmProvider{},
+}
+
+// entry is one fetched secret path
+type entry struct {
+	values    map[string]string
+	lease     time.Duration
+	fetchedAt time.Time
+	stale     bool
+}
+
+var (
+	mu    sync.Mutex
+	cache = map[string]*entry{}
+)
+
+// IsReference tells whether a config value points at a secret backend instead of holding the
+// secret itself
+func IsReference(value string) bool {
+	scheme, _, ok := strings.Cut(value, ":")
+	_, known := providers[scheme]
+	return ok && known
+}
+
+// Resolve returns the secret a reference points at. References are "vault:<path>#<field>"
+// (e.g. vault:secret/data/hoctap#db_password) and "ssm:<parameter name>" (e.g.
+// ssm:/hoctap/prod/db_password). Values that are not references are returned unchanged with
+// ok set to false. Fields of the same path are fetched once and share a lease, so dynamic
+// credentials such as a Vault database role keep a matching user and password.
+func Resolve(value string) (secret string, ok bool, err error) {
+	if !IsReference(value) {
+		return value, false, nil
+	}
+
+	scheme, rest, _ := strings.Cut(value, ":")
+	path, field, _ := strings.Cut(rest, "#")
+	if path == "" {
+		return "", true, fmt.Errorf("invalid secret reference '%s': missing path", value)
+	}
+	if scheme == "vault" && field == "" {
+		return "", true, fmt.Errorf("invalid secret reference '%s': expected vault:<path>#<field>", value)
+	}
+
+	values, err := fetch(scheme, path)
+	if err != nil {
+		return "", true, err
+	}
+
+	secret, found := values[field]
+	if !found {
+		return "", true, fmt.Errorf("secret %s:%s has no field '%s'", scheme, path, field)
+	}
+	return secret, true, nil
+}
+
+// Helper function to read a path through the cache, refetching it when its lease is due
+func fetch(scheme, path string) (map[string]string, error) {
+	key := scheme + ":" + path
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if cached, exists := cache[key]; exists && !cached.stale && !cached.expiring(time.Now()) {
+		return cached.values, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	defer cancel()
+
+	values, lease, err := providers[scheme].Fetch(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch secret %s: %v", key, err)
+	}
+
+	cache[key] = &entry{values: values, lease: lease, fetchedAt: time.Now()}
+	return values, nil
+}
+
+// refreshAt is when a secret should be fetched again: after two thirds of its lease, leaving
+// time to retry before it expires, or after interval when it has no lease
+func (e *entry) refreshAt(interval time.Duration) time.Time {
+	if e.lease > 0 {
+		return e.fetchedAt.Add(e.lease * 2 / 3)
+	}
+	return e.fetchedAt.Add(interval)
+}
+
+// Helper function to tell whether a leased secret is past its refresh time
+func (e *entry) expiring(now time.Time) bool {
+	return e.lease > 0 && !now.Before(e.refreshAt(0))
+}
+
+// NextRefresh returns when the next fetched secret is due, treating secrets without a lease
+// as due every interval. It returns false when no secret has been fetched.
+func NextRefresh(interval time.Duration) (time.Time, bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var next time.Time
+	for _, cached := range cache {
+		at := cached.refreshAt(interval)
+		if cached.stale {
+			at = time.Now()
+		}
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// ExpireDue marks the secrets that are due so the next Resolve fetches them again
+func ExpireDue(interval time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	now := time.Now()
+	for _, cached := range cache {
+		if !now.Before(cached.refreshAt(interval)) {
+			cached.stale = true
+		}
+	}
+}
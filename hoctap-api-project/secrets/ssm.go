@@ -0,0 +1,46 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// ssmProvider reads AWS Systems Manager Parameter Store parameters. Credentials and region
+// come from the default AWS chain (AWS_REGION, AWS_PROFILE, instance or task roles, ...).
+type ssmProvider struct {
+	once   sync.Once
+	client *ssm.Client
+	err    error
+}
+
+// Fetch reads a parameter, decrypting SecureString values. Parameters have no lease, so the
+// value is stored under the empty field name.
+func (p *ssmProvider) Fetch(ctx context.Context, name string) (map[string]string, time.Duration, error) {
+	p.once.Do(func() {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			p.err = err
+			return
+		}
+		p.client = ssm.NewFromConfig(cfg)
+	})
+	if p.err != nil {
+		return nil, 0, fmt.Errorf("failed to load AWS config: %v", p.err)
+	}
+
+	output, err := p.client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return map[string]string{"": aws.ToString(output.Parameter.Value)}, 0, nil
+}
@@ -0,0 +1,53 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	vault "github.com/hashicorp/vault/api"
+)
+
+// vaultProvider reads HashiCorp Vault secrets. The client is configured by Vault's own
+// environment variables (VAULT_ADDR, VAULT_TOKEN, VAULT_NAMESPACE, VAULT_CACERT, ...).
+type vaultProvider struct {
+	once   sync.Once
+	client *vault.Client
+	err    error
+}
+
+// Fetch reads a path. KV version 2 paths (secret/data/...) return the fields of the latest
+// version; dynamic secrets such as database/creds/<role> return their fields and lease.
+func (p *vaultProvider) Fetch(ctx context.Context, path string) (map[string]string, time.Duration, error) {
+	p.once.Do(func() {
+		cfg := vault.DefaultConfig()
+		if cfg.Error != nil {
+			p.err = cfg.Error
+			return
+		}
+		p.client, p.err = vault.NewClient(cfg)
+	})
+	if p.err != nil {
+		return nil, 0, fmt.Errorf("failed to create Vault client: %v", p.err)
+	}
+
+	secret, err := p.client.Logical().ReadWithContext(ctx, path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, 0, fmt.Errorf("no secret at %s", path)
+	}
+
+	data := secret.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok && data["metadata"] != nil {
+		data = nested
+	}
+
+	values := make(map[string]string, len(data))
+	for field, value := range data {
+		values[field] = fmt.Sprint(value)
+	}
+	return values, time.Duration(secret.LeaseDuration) * time.Second, nil
+}
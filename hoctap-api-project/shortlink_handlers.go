@@ -0,0 +1,127 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+
+	"github.com/gorilla/mux"
+)
+
+// Global short link repository
+var shortLinkRepo *database.ShortLinkRepository
+
+// shortLinkCodePattern restricts custom codes to URL-safe characters
+var shortLinkCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,32}$`)
+
+// shortLinkInput is the request body for creating a short link
+type shortLinkInput struct {
+	URL       string     `json:"url"`
+	Code      string     `json:"code"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Create a short link
+func createShortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	var linkData shortLinkInput
+
+	if err := decodeRequestBody(r, &linkData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+		return
+	}
+
+	// Validation: absolute http(s) URLs or paths on this server
+	if !isValidShortLinkTarget(linkData.URL) {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("short_link_url_required"), nil)
+		return
+	}
+
+	if linkData.Code != "" && !shortLinkCodePattern.MatchString(linkData.Code) {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("short_link_invalid_code"), nil)
+		return
+	}
+
+	if linkData.ExpiresAt != nil && linkData.ExpiresAt.Before(time.Now()) {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("short_link_expiry_past"), nil)
+		return
+	}
+
+	link, err := shortLinkRepo.CreateShortLink(r.Context(), linkData.Code, linkData.URL, linkData.ExpiresAt)
+	if err != nil {
+		log.Printf("Error creating short link: %v", err)
+		if errors.Is(err, database.ErrShortLinkExists) {
+			sendJSONResponse(w, http.StatusConflict, i18n.M("short_link_exists", "code", linkData.Code), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("short_link_create_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusCreated, i18n.M("short_link_created"), link)
+}
+
+// Get all short links with their click counts
+func getShortLinksHandler(w http.ResponseWriter, r *http.Request) {
+	links, err := shortLinkRepo.GetAllShortLinks(r.Context())
+	if err != nil {
+		log.Printf("Error getting short links: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("short_links_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("short_links_retrieved"), links)
+}
+
+// Delete a short link
+func deleteShortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+
+	if err := shortLinkRepo.DeleteShortLink(r.Context(), code); err != nil {
+		log.Printf("Error deleting short link: %v", err)
+		if errors.Is(err, database.ErrShortLinkNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("short_link_not_found", "code", code), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("short_link_delete_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("short_link_deleted"), nil)
+}
+
+// Redirect a short code to its target, counting the click
+func redirectShortLinkHandler(w http.ResponseWriter, r *http.Request) {
+	link, err := shortLinkRepo.GetShortLinkByCode(r.Context(), mux.Vars(r)["code"])
+	if err != nil && !errors.Is(err, database.ErrShortLinkNotFound) {
+		log.Printf("Error getting short link: %v", err)
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if err != nil || link.Expired() {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := shortLinkRepo.RecordClick(r.Context(), link.ID); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, link.TargetURL, http.StatusFound)
+}
+
+// Helper function to validate a short link target
+func isValidShortLinkTarget(target string) bool {
+	if strings.HasPrefix(target, "/") && !strings.HasPrefix(target, "//") {
+		return true
+	}
+	parsed, err := url.Parse(target)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
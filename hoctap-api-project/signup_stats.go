@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+)
+
+// maxSignupBuckets caps the buckets of a signup series: a year of days
+const maxSignupBuckets = 366
+
+// signupIntervals are the bucket lengths of a signup series, with how many buckets a series
+// ending today has when no from date is given
+var signupIntervals = map[string]int{"day": 30, "week": 12, "month": 12}
+
+// signupSeries is the number of signups per day, week or month of a date range, in the time
+// zone of the request. Weeks start on Monday. From and To are the first and last day of the
+// buckets, so a range is widened to whole weeks or months.
+type signupSeries struct {
+	Interval string         `json:"interval"`
+	From     string         `json:"from"`
+	To       string         `json:"to"`
+	Buckets  []signupBucket `json:"buckets"`
+
+	// starts holds the start of every bucket and the end of the last one
+	starts []time.Time
+}
+
+// signupBucket is the number of signups of the day, week or month starting on Start
+type signupBucket struct {
+	Start string `json:"start"`
+	Count int    `json:"count"`
+}
+
+// Helper function to read the signup series asked for with ?interval=&from=&to= (dates as
+// YYYY-MM-DD, to included), or nil when none of them is given. Interval defaults to day and
+// to to today; from defaults to a month of days or a quarter of weeks, or a year of months.
+func signupSeriesParam(r *http.Request, location *time.Location, now time.Time) (*signupSeries, error) {
+	query := r.URL.Query()
+	interval, fromParam, toParam := query.Get("interval"), query.Get("from"), query.Get("to")
+	if interval == "" && fromParam == "" && toParam == "" {
+		return nil, nil
+	}
+
+	if interval == "" {
+		interval = "day"
+	}
+	defaultBuckets, ok := signupIntervals[interval]
+	if !ok {
+		return nil, i18n.NewError("invalid_interval", "interval", interval)
+	}
+
+	to := startOfDay(now, location).In(location)
+	if toParam != "" {
+		date, err := time.ParseInLocation(time.DateOnly, toParam, location)
+		if err != nil {
+			return nil, i18n.NewError("invalid_date", "date", toParam)
+		}
+		to = date
+	}
+
+	from := bucketStart(to, interval)
+	from = addBuckets(from, interval, 1-defaultBuckets)
+	if fromParam != "" {
+		date, err := time.ParseInLocation(time.DateOnly, fromParam, location)
+		if err != nil {
+			return nil, i18n.NewError("invalid_date", "date", fromParam)
+		}
+		if date.After(to) {
+			return nil, i18n.NewError("invalid_date_range", "from", fromParam, "to", to.Format(time.DateOnly))
+		}
+		from = bucketStart(date, interval)
+	}
+
+	series := &signupSeries{Interval: interval, Buckets: []signupBucket{}}
+	for start := from; !start.After(to); start = addBuckets(start, interval, 1) {
+		if len(series.starts) == maxSignupBuckets {
+			return nil, i18n.NewError("date_range_too_long", "max", maxSignupBuckets)
+		}
+		series.starts = append(series.starts, start)
+		series.Buckets = append(series.Buckets, signupBucket{Start: start.Format(time.DateOnly)})
+	}
+	end := addBuckets(series.starts[len(series.starts)-1], interval, 1)
+	series.starts = append(series.starts, end)
+
+	series.From = from.Format(time.DateOnly)
+	series.To = end.AddDate(0, 0, -1).Format(time.DateOnly)
+	return series, nil
+}
+
+// Helper function to add the signup counts of the slots of the series range to its buckets
+func (s *signupSeries) fill(counts []database.SignupCount) {
+	for _, count := range counts {
+		// The first start after the slot ends its bucket
+		i := sort.Search(len(s.starts), func(i int) bool { return s.starts[i].After(count.Start) }) - 1
+		if i >= 0 && i < len(s.Buckets) {
+			s.Buckets[i].Count += count.Count
+		}
+	}
+}
+
+// Helper function to return the start of the day, week or month a local midnight falls in
+func bucketStart(day time.Time, interval string) time.Time {
+	switch interval {
+	case "week":
+		// Monday is the first day of the week
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case "month":
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// Helper function to move a bucket start by n days, weeks or months
+func addBuckets(start time.Time, interval string, n int) time.Time {
+	switch interval {
+	case "week":
+		return start.AddDate(0, 0, 7*n)
+	case "month":
+		return start.AddDate(0, n, 0)
+	default:
+		return start.AddDate(0, 0, n)
+	}
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hoctap-api/config"
+)
+
+// smokeClient sends the requests of a smoke test to one deployment
+type smokeClient struct {
+	baseURL    string
+	adminToken string
+	http       *http.Client
+}
+
+// smokeUser is the part of a user the smoke test checks
+type smokeUser struct {
+	ID    int    `json:"id"`
+	UUID  string `json:"uuid"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Run `hoctap-api smoketest --base-url=...`: check the critical path of a deployed
+// environment (health, admin authentication, and the life cycle of a temporary user that
+// is always removed again), printing one line per check. It fails at the first failing
+// check, so a deploy pipeline can stop or roll back.
+func runSmokeTest(args []string) error {
+	flags := flag.NewFlagSet("smoketest", flag.ContinueOnError)
+	baseURL := flags.String("base-url", "", "URL of the deployment to test, e.g. https://api.example.com (required)")
+	adminToken := flags.String("admin-token", config.Current().Admin.APIToken, "admin token the deployment accepts; without it only the rejection of unauthenticated requests is checked")
+	timeout := flags.Duration("timeout", 10*time.Second, "timeout of each request")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	parsed, err := url.Parse(*baseURL)
+	if *baseURL == "" || err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("usage: hoctap-api smoketest --base-url=http(s)://host[:port] [--admin-token=...] [--timeout=10s]")
+	}
+
+	client := &smokeClient{
+		baseURL:    strings.TrimRight(*baseURL, "/"),
+		adminToken: *adminToken,
+		http:       &http.Client{Timeout: *timeout},
+	}
+	fmt.Printf("🔥 Smoke testing %s\n", client.baseURL)
+
+	start := time.Now()
+	if err := client.run(); err != nil {
+		return fmt.Errorf("smoke test failed: %v", err)
+	}
+	fmt.Printf("✅ Smoke test passed in %s\n", time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// run performs the checks in order, removing the temporary user whatever happens
+func (c *smokeClient) run() error {
+	if err := c.check("health", c.checkHealth); err != nil {
+		return err
+	}
+	if err := c.check("auth", c.checkAuth); err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 6)
+	rand.Read(suffix)
+	tag := hex.EncodeToString(suffix)
+	input := userInput{Name: "Smoke Test " + tag, Email: "smoketest-" + tag + "@example.com"}
+
+	var user smokeUser
+	err := c.check("create user", func() (string, error) {
+		if err := c.expect(http.MethodPost, "/api/v1/users", input, http.StatusCreated, &user); err != nil {
+			return "", err
+		}
+		if user.ID == 0 || user.UUID == "" || user.Email != input.Email {
+			return "", fmt.Errorf("created user %+v does not match %s", user, input.Email)
+		}
+		return fmt.Sprintf("user %d", user.ID), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	deleted := false
+	defer func() {
+		if deleted {
+			return
+		}
+		// Best effort: a failure here is reported, but the check that failed is the error
+		c.check("cleanup", func() (string, error) {
+			return fmt.Sprintf("user %d removed", user.ID), c.expect(http.MethodDelete, c.userPath(user.UUID), nil, http.StatusOK, nil)
+		})
+	}()
+
+	err = c.check("read user", func() (string, error) {
+		var read smokeUser
+		if err := c.expect(http.MethodGet, c.userPath(user.UUID), nil, http.StatusOK, &read); err != nil {
+			return "", err
+		}
+		if read != user {
+			return "", fmt.Errorf("read %+v, created %+v", read, user)
+		}
+		return fmt.Sprintf("user %d", user.ID), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.check("update user", func() (string, error) {
+		update := userInput{Name: input.Name + " (updated)", Email: input.Email}
+		var updated smokeUser
+		if err := c.expect(http.MethodPut, c.userPath(user.UUID), update, http.StatusOK, &updated); err != nil {
+			return "", err
+		}
+		if updated.Name != update.Name {
+			return "", fmt.Errorf("name is '%s' after the update, want '%s'", updated.Name, update.Name)
+		}
+		return fmt.Sprintf("user %d", user.ID), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.check("delete user", func() (string, error) {
+		if err := c.expect(http.MethodDelete, c.userPath(user.UUID), nil, http.StatusOK, nil); err != nil {
+			return "", err
+		}
+		deleted = true
+		if err := c.expect(http.MethodGet, c.userPath(user.UUID), nil, http.StatusNotFound, nil); err != nil {
+			return "", fmt.Errorf("user still readable after delete: %v", err)
+		}
+		return fmt.Sprintf("user %d", user.ID), nil
+	})
+}
+
+// check runs one named check and prints its outcome and duration
+func (c *smokeClient) check(name string, fn func() (string, error)) error {
+	start := time.Now()
+	detail, err := fn()
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		fmt.Printf("❌ %-12s %v (%s)\n", name, err, elapsed)
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	fmt.Printf("✅ %-12s %s (%s)\n", name, detail, elapsed)
+	return nil
+}
+
+// checkHealth requires /health to answer and report no failing required dependency
+func (c *smokeClient) checkHealth() (string, error) {
+	var health struct {
+		Status  string `json:"status"`
+		Version string `json:"version"`
+	}
+	if err := c.expect(http.MethodGet, "/health", nil, http.StatusOK, &health); err != nil {
+		return "", err
+	}
+	if health.Status == "unhealthy" {
+		return "", errors.New("status is unhealthy")
+	}
+	return fmt.Sprintf("%s, version %s", health.Status, health.Version), nil
+}
+
+// checkAuth requires admin endpoints to reject requests without the token (401, or 403
+// when the deployment disabled the admin API) and, when the token is known, to accept it
+func (c *smokeClient) checkAuth() (string, error) {
+	token := c.adminToken
+	c.adminToken = ""
+	status, message, err := c.do(http.MethodGet, "/api/v1/audit?limit=1", nil, nil)
+	c.adminToken = token
+	if err != nil {
+		return "", err
+	}
+	if status != http.StatusUnauthorized && status != http.StatusForbidden {
+		return "", fmt.Errorf("unauthenticated admin request got HTTP %d, want 401 or 403: %s", status, message)
+	}
+
+	if token == "" {
+		return "unauthenticated requests rejected (no admin token to check)", nil
+	}
+	if err := c.expect(http.MethodGet, "/api/v1/audit?limit=1", nil, http.StatusOK, nil); err != nil {
+		return "", fmt.Errorf("admin token: %v", err)
+	}
+	return "admin token accepted, unauthenticated requests rejected", nil
+}
+
+// expect sends a request with an optional JSON body and requires the status; on success the
+// data of the response envelope is decoded into out when it is not nil
+func (c *smokeClient) expect(method, path string, body interface{}, status int, out interface{}) error {
+	got, message, err := c.do(method, path, body, out)
+	if err != nil {
+		return err
+	}
+	if got != status {
+		return fmt.Errorf("%s %s: HTTP %d, want %d: %s", method, path, got, status, message)
+	}
+	return nil
+}
+
+// do sends a request with an optional JSON body, returning the status and message of the
+// response envelope. The data is decoded into out when it is not nil and the request
+// succeeded.
+func (c *smokeClient) do(method, path string, body interface{}, out interface{}) (int, string, error) {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return 0, "", err
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.adminToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.adminToken)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Message string          `json:"message"`
+		Data    json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&envelope); err != nil && err != io.EOF {
+		return resp.StatusCode, "", fmt.Errorf("%s %s: invalid response (HTTP %d): %v", method, path, resp.StatusCode, err)
+	}
+
+	if out != nil && resp.StatusCode < 300 {
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			return resp.StatusCode, envelope.Message, fmt.Errorf("%s %s: invalid data: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode, envelope.Message, nil
+}
+
+// Helper function to build the path of a user
+func (c *smokeClient) userPath(uuid string) string {
+	return "/api/v1/users/" + uuid
+}
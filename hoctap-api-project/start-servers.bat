@@ -3,7 +3,7 @@ cd /d "%~dp0"
 echo Starting HocTap API Dashboard...
 echo.
 echo Starting Go Server with integrated HTML dashboard on port 8080...
-start "HocTap Server" cmd /k "cd /d "%~dp0" && go run main.go"
+start "HocTap Server" cmd /k "cd /d "%~dp0" && go run . serve -seed"
 
 echo.
 echo Waiting for server to start...
@@ -0,0 +1,241 @@
+package storage
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// unsignedPayload skips hashing request bodies; S3 accepts it and TLS protects them
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Options configures an S3-compatible store
+type S3Options struct {
+	// Endpoint is the server URL, e.g. http://minio:9000; empty means AWS S3 in Region
+	Endpoint string
+	Region   string
+	Bucket   string
+	// AccessKey and SecretKey are static credentials; without them the default AWS chain
+	// (AWS_ACCESS_KEY_ID, AWS_PROFILE, instance or task roles, ...) is used
+	AccessKey string
+	SecretKey string
+	// PathStyle addresses objects as endpoint/bucket/key instead of bucket.endpoint/key,
+	// which MinIO and most on-prem servers need
+	PathStyle bool
+}
+
+// S3 stores objects in a bucket of AWS S3, MinIO or another S3-compatible server. Requests
+// are signed with Signature Version 4, so no SDK client is needed.
+type S3 struct {
+	options     S3Options
+	endpoint    *url.URL
+	credentials aws.CredentialsProvider
+	signer      *v4.Signer
+	client      *http.Client
+}
+
+// NewS3 returns a store for options.Bucket. Credentials are resolved here, but the bucket
+// is not contacted until the first request.
+func NewS3(ctx context.Context, options S3Options) (*S3, error) {
+	if options.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+
+	endpoint := options.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", options.Region)
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid endpoint '%s'", endpoint)
+	}
+
+	var provider aws.CredentialsProvider
+	if options.AccessKey != "" {
+		provider = credentials.NewStaticCredentialsProvider(options.AccessKey, options.SecretKey, "")
+	} else {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx, awsconfig.WithRegion(options.Region))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS config: %v", err)
+		}
+		provider = cfg.Credentials
+	}
+
+	return &S3{
+		options:     options,
+		endpoint:    parsed,
+		credentials: aws.NewCredentialsCache(provider),
+		// S3 object keys are escaped once, not twice like other services
+		signer: v4.NewSigner(func(o *v4.SignerOptions) { o.DisableURIPathEscaping = true }),
+		client: &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Put uploads the object with a single PUT request
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object; the caller closes the returned body
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Delete removes the object; S3 answers 204 whether or not it existed
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err == ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// SignedURL returns a presigned GET URL; S3 accepts at most 7 days
+func (s *S3) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return "", err
+	}
+	query := req.URL.Query()
+	query.Set("X-Amz-Expires", strconv.FormatInt(int64(expires/time.Second), 10))
+	req.URL.RawQuery = query.Encode()
+
+	creds, err := s.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get AWS credentials: %v", err)
+	}
+	signed, _, err := s.signer.PresignHTTP(ctx, creds, req, unsignedPayload, "s3", s.options.Region, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("failed to presign URL: %v", err)
+	}
+	return signed, nil
+}
+
+// Ping checks that the bucket exists and the credentials may access it
+func (s *S3) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.bucketURL(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req)
+	if err == ErrNotFound {
+		return fmt.Errorf("bucket '%s' does not exist", s.options.Bucket)
+	}
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3Error is the XML error document returned by S3
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// Helper function to sign and send a request. Responses other than 2xx are closed and
+// returned as errors, 404 as ErrNotFound.
+func (s *S3) do(req *http.Request) (*http.Response, error) {
+	creds, err := s.credentials.Retrieve(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get AWS credentials: %v", err)
+	}
+
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	if err := s.signer.SignHTTP(req.Context(), creds, req, unsignedPayload, "s3", s.options.Region, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to sign request: %v", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	var s3Err s3Error
+	if xml.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&s3Err) == nil && s3Err.Code != "" {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Path, s3Err.Code, s3Err.Message)
+	}
+	return nil, fmt.Errorf("%s %s: unexpected status %d", req.Method, req.URL.Path, resp.StatusCode)
+}
+
+// Helper function to build the URL of the bucket
+func (s *S3) bucketURL() string {
+	u := *s.endpoint
+	if s.options.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + s.options.Bucket
+	} else {
+		u.Host = s.options.Bucket + "." + u.Host
+	}
+	return u.String()
+}
+
+// Helper function to build the URL of an object
+func (s *S3) objectURL(key string) string {
+	return strings.TrimSuffix(s.bucketURL(), "/") + "/" + (&url.URL{Path: key}).EscapedPath()
+}
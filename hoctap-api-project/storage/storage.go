@@ -0,0 +1,39 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidKey is returned for keys that are not slash-separated relative paths
+var ErrInvalidKey = errors.New("invalid object key")
+
+// Blob stores uploaded files as objects under slash-separated keys such as
+// "avatars/3-e7e3ebacf5b49adc.jpg"
+type Blob interface {
+	// Put stores size bytes of body under key, replacing any existing object
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object stored under key
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key; a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL downloading the object without further authentication
+	// until expires has passed
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+	// Ping tells whether the backend is reachable and writable
+	Ping(ctx context.Context) error
+}
+
+// Helper function to reject keys escaping the store, such as "../x" or "/etc/passwd"
+func checkKey(key string) error {
+	if key == "." || !fs.ValidPath(key) {
+		return ErrInvalidKey
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hoctap-api/config"
+	"hoctap-api/i18n"
+	"hoctap-api/storage"
+
+	"github.com/gorilla/mux"
+)
+
+// storedFilePathPrefix is the URL prefix the local storage backend serves signed downloads under
+const storedFilePathPrefix = "/files/"
+
+var (
+	// blobStore holds uploaded files, in every mode
+	blobStore storage.Blob
+	// localStore is blobStore when it is the local backend, whose downloads this server serves
+	localStore *storage.Local
+)
+
+// Helper function to create the object storage configured by STORAGE_BACKEND
+func newBlobStore(cfg config.StorageConfig) (storage.Blob, error) {
+	switch cfg.Backend {
+	case "s3":
+		store, err := storage.NewS3(context.Background(), storage.S3Options{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure S3 storage: %v", err)
+		}
+		log.Printf("🪣 Storing uploads in bucket %s", cfg.S3Bucket)
+		return store, nil
+	default:
+		signingKey := []byte(cfg.SigningKey)
+		if len(signingKey) == 0 {
+			log.Println("⚠️ Warning: STORAGE_SIGNING_KEY is not set; signed download URLs stop working on restart")
+			signingKey = make([]byte, 32)
+			rand.Read(signingKey)
+		}
+
+		store, err := storage.NewLocal(cfg.Dir, storedFilePathPrefix, signingKey)
+		if err != nil {
+			return nil, err
+		}
+		localStore = store
+		log.Printf("🗄️ Storing uploads in %s", cfg.Dir)
+		return store, nil
+	}
+}
+
+// Serve a file of the local storage backend through a URL signed by its SignedURL. The URL
+// expires, so the file may be cached until then.
+func serveStoredFileHandler(w http.ResponseWriter, r *http.Request) {
+	if localStore == nil {
+		sendJSONResponse(w, http.StatusNotFound, i18n.M("file_not_found"), nil)
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	query := r.URL.Query()
+	if err := localStore.Verify(key, query.Get("expires"), query.Get("signature")); err != nil {
+		sendJSONResponse(w, http.StatusForbidden, i18n.M("download_link_invalid"), nil)
+		return
+	}
+
+	file, err := localStore.Open(key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		sendJSONResponse(w, http.StatusNotFound, i18n.M("file_not_found"), nil)
+		return
+	}
+	if err != nil {
+		log.Printf("Error opening stored file: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("file_read_failed"), nil)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		log.Printf("Error opening stored file: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("file_read_failed"), nil)
+		return
+	}
+
+	expiresAt, _ := strconv.ParseInt(query.Get("expires"), 10, 64)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", max(expiresAt-time.Now().Unix(), 0)))
+	http.ServeContent(w, r, info.Name(), info.ModTime(), file)
+}
+
+// Helper function to redirect to a signed download URL of a stored object. The redirect is
+// cached for half the URL lifetime, so a cached redirect never leads to an expired URL.
+func redirectToStoredObject(w http.ResponseWriter, r *http.Request, key string) {
+	ttl := config.Current().Storage.URLTTL
+	signed, err := blobStore.SignedURL(r.Context(), key, ttl)
+	if err != nil {
+		log.Printf("Error signing download URL: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("download_link_failed"), nil)
+		return
+	}
+
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", int((ttl/2).Seconds())))
+	http.Redirect(w, r, signed, http.StatusFound)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"hoctap-api/i18n"
+
+	// Embed the time zone database, so ?tz= works on hosts without one
+	_ "time/tzdata"
+)
+
+// Helper function to return the time zone a client wants human-facing aggregates (days,
+// "today") in: the IANA name in ?tz= or the X-Timezone header, such as Asia/Ho_Chi_Minh, or
+// UTC. Timestamps are returned in UTC whatever the time zone.
+func requestLocation(r *http.Request) (*time.Location, error) {
+	name := r.URL.Query().Get("tz")
+	if name == "" {
+		name = r.Header.Get("X-Timezone")
+	}
+	if name == "" {
+		return time.UTC, nil
+	}
+
+	// "Local" would be the server's zone, which clients cannot know
+	location, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return nil, i18n.NewError("invalid_timezone", "tz", name)
+	}
+	return location, nil
+}
+
+// Helper function to return the start of the day t falls on in location, in UTC
+func startOfDay(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location).UTC()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"hoctap-api/config"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// Helper function to enable HTTPS on server from TLS_CERT/TLS_KEY or AUTOCERT_DOMAINS. It
+// returns the plain HTTP listener redirecting to HTTPS when HTTP_REDIRECT_PORT is set, or nil.
+func configureTLS(server *http.Server, cfg config.ServerConfig) (*http.Server, error) {
+	if !cfg.TLSEnabled() {
+		return nil, nil
+	}
+
+	redirect := redirectToHTTPS(cfg.Port)
+	if len(cfg.AutocertDomains) > 0 {
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		server.TLSConfig = manager.TLSConfig()
+		// The redirect listener also answers the HTTP-01 challenges
+		redirect = manager.HTTPHandler(redirect)
+	} else {
+		// Load the key pair now, so a bad certificate stops startup instead of every handshake
+		certificate, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{certificate}}
+	}
+	server.TLSConfig.MinVersion = tls.VersionTLS12
+
+	if cfg.HTTPRedirectPort == "" {
+		return nil, nil
+	}
+	return &http.Server{
+		Handler:      redirect,
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 5 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}, nil
+}
+
+// Handler permanently redirecting every request to the same URL over HTTPS on httpsPort
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if name, _, err := net.SplitHostPort(host); err == nil {
+			host = name
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,105 @@
+package tracking
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"hoctap-api/database"
+)
+
+// Buffer collects tracked events in memory and writes them in batches,
+// either when the batch is full or when the flush interval elapses
+type Buffer struct {
+	repo      *database.TrackingRepository
+	batchSize int
+	maxQueued int
+
+	mu     sync.Mutex
+	events []database.TrackedEvent
+	flush  chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// NewBuffer creates a buffer and starts its background flusher
+func NewBuffer(repo *database.TrackingRepository, batchSize int, interval time.Duration) *Buffer {
+	b := &Buffer{
+		repo:      repo,
+		batchSize: batchSize,
+		maxQueued: batchSize * 20,
+		flush:     make(chan struct{}, 1),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	go b.run(interval)
+	return b
+}
+
+// Add queues events for insertion. It returns false when the buffer is saturated
+// (the database is not keeping up) and the events were dropped.
+func (b *Buffer) Add(events ...database.TrackedEvent) bool {
+	b.mu.Lock()
+	if len(b.events)+len(events) > b.maxQueued {
+		b.mu.Unlock()
+		return false
+	}
+	b.events = append(b.events, events...)
+	full := len(b.events) >= b.batchSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.flush <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+// Stop flushes the remaining events and stops the background flusher
+func (b *Buffer) Stop() {
+	close(b.stop)
+	<-b.done
+}
+
+// run flushes periodically, on demand, and once more on stop
+func (b *Buffer) run(interval time.Duration) {
+	defer close(b.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			b.write()
+		case <-b.flush:
+			b.write()
+		case <-b.stop:
+			b.write()
+			return
+		}
+	}
+}
+
+// write drains the buffer in batches of batchSize
+func (b *Buffer) write() {
+	b.mu.Lock()
+	pending := b.events
+	b.events = nil
+	b.mu.Unlock()
+
+	for len(pending) > 0 {
+		n := b.batchSize
+		if n > len(pending) {
+			n = len(pending)
+		}
+		if err := b.repo.InsertEvents(context.Background(), pending[:n]); err != nil {
+			log.Printf("⚠️ Warning: dropping %d tracked events: %v", n, err)
+		}
+		pending = pending[n:]
+	}
+}
@@ -0,0 +1,87 @@
+package tracking
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sampler keeps a random fraction of events
+type Sampler struct {
+	// rate holds the float64 bits of the rate, so it can be changed while in use
+	rate atomic.Uint64
+}
+
+// NewSampler creates a sampler keeping rate (0..1) of events
+func NewSampler(rate float64) *Sampler {
+	s := &Sampler{}
+	s.SetRate(rate)
+	return s
+}
+
+// SetRate changes the fraction (0..1) of events kept
+func (s *Sampler) SetRate(rate float64) {
+	if rate < 0 {
+		rate = 0
+	}
+	if rate > 1 {
+		rate = 1
+	}
+	s.rate.Store(math.Float64bits(rate))
+}
+
+// Keep reports whether the next event should be recorded
+func (s *Sampler) Keep() bool {
+	rate := math.Float64frombits(s.rate.Load())
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// Quota limits how many events each client may submit per window
+type Quota struct {
+	limit  int
+	window time.Duration
+
+	mu      sync.Mutex
+	started time.Time
+	counts  map[string]int
+}
+
+// NewQuota creates a quota of limit events per client per window; limit <= 0 disables it
+func NewQuota(limit int, window time.Duration) *Quota {
+	return &Quota{limit: limit, window: window, started: time.Now(), counts: make(map[string]int)}
+}
+
+// SetLimit changes the events allowed per client per window; limit <= 0 disables the quota
+func (q *Quota) SetLimit(limit int) {
+	q.mu.Lock()
+	q.limit = limit
+	q.mu.Unlock()
+}
+
+// Allow reserves n events for client, returning how many fit in the remaining quota
+func (q *Quota) Allow(client string, n int) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.limit <= 0 {
+		return n
+	}
+
+	// Fixed windows keep the bookkeeping to one counter per client
+	if time.Since(q.started) >= q.window {
+		q.started = time.Now()
+		q.counts = make(map[string]int)
+	}
+
+	remaining := q.limit - q.counts[client]
+	if remaining <= 0 {
+		return 0
+	}
+	if n > remaining {
+		n = remaining
+	}
+	q.counts[client] += n
+	return n
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/tracking"
+)
+
+// Event tracking limits
+const (
+	maxTrackedEventsPerRequest = 50
+	maxTrackedPropertiesBytes  = 4096
+)
+
+// trackedEventNamePattern keeps event names short and machine-friendly, e.g. "page_view"
+var trackedEventNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_.:-]{0,127}$`)
+
+// Global event tracking pipeline
+var (
+	trackingBuffer  *tracking.Buffer
+	trackingSampler *tracking.Sampler
+	trackingQuota   *tracking.Quota
+)
+
+// trackedEventInput is a single event as submitted by clients
+type trackedEventInput struct {
+	Name       string          `json:"name"`
+	Path       string          `json:"path"`
+	UserID     *int            `json:"user_id"`
+	SessionID  string          `json:"session_id"`
+	Properties json.RawMessage `json:"properties"`
+	Timestamp  *time.Time      `json:"timestamp"`
+}
+
+// Track client analytics events, sent either as a single event or as {"events": [...]}
+func trackEventsHandler(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		trackedEventInput
+		Events []trackedEventInput `json:"events"`
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, 256*1024)
+	if err := decodeRequestBody(r, &payload); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+		return
+	}
+
+	inputs := payload.Events
+	if len(inputs) == 0 && payload.Name != "" {
+		inputs = []trackedEventInput{payload.trackedEventInput}
+	}
+
+	// Validation
+	if len(inputs) == 0 {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("events_required"), nil)
+		return
+	}
+	if len(inputs) > maxTrackedEventsPerRequest {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("events_too_many", "max", maxTrackedEventsPerRequest), nil)
+		return
+	}
+
+	now := time.Now()
+	ip := clientIP(r)
+
+	events := make([]database.TrackedEvent, 0, len(inputs))
+	for i, in := range inputs {
+		if !trackedEventNamePattern.MatchString(in.Name) {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("event_invalid_name", "index", i), nil)
+			return
+		}
+		if len(in.Properties) > maxTrackedPropertiesBytes {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("event_properties_too_large", "index", i, "max", maxTrackedPropertiesBytes), nil)
+			return
+		}
+
+		occurredAt := now
+		// Client clocks drift, so only accept timestamps from the last day and not from the future
+		if in.Timestamp != nil && in.Timestamp.Before(now) && now.Sub(*in.Timestamp) < 24*time.Hour {
+			occurredAt = *in.Timestamp
+		}
+
+		if !trackingSampler.Keep() {
+			continue
+		}
+
+		events = append(events, database.TrackedEvent{
+			Name:       in.Name,
+			Path:       in.Path,
+			UserID:     in.UserID,
+			SessionID:  in.SessionID,
+			Properties: string(in.Properties),
+			ClientIP:   ip,
+			OccurredAt: occurredAt,
+		})
+	}
+
+	allowed := trackingQuota.Allow(ip, len(events))
+	if allowed == 0 && len(events) > 0 {
+		sendJSONResponse(w, http.StatusTooManyRequests, i18n.M("event_quota_exceeded"), nil)
+		return
+	}
+	events = events[:allowed]
+
+	if !trackingBuffer.Add(events...) {
+		sendJSONResponse(w, http.StatusServiceUnavailable, i18n.M("event_ingestion_overloaded"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusAccepted, i18n.M("events_accepted"), map[string]interface{}{
+		"received": len(inputs),
+		"accepted": len(events),
+	})
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"context"
+	"net"
+
+	"hoctap-api/config"
+	"hoctap-api/emailaddr"
+)
+
+// Helper function to normalize the email of a user being created or updated, and with
+// EMAIL_CHECK_MX, check that its domain receives mail. The error is meant for the client.
+func checkUserEmail(ctx context.Context, email string) (string, error) {
+	cfg := config.Current().Email
+	if !cfg.CheckMX {
+		return emailaddr.Normalize(email)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.MXTimeout)
+	defer cancel()
+	return emailaddr.Verify(ctx, net.DefaultResolver, email)
+}
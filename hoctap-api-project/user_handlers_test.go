@@ -0,0 +1,359 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hoctap-api/database"
+	"hoctap-api/database/mocks"
+	"hoctap-api/realtime"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/mock/gomock"
+)
+
+// Users of the handler tests: testUserUUID is the user with ID 7, unknownUserUUID is no one's.
+// Paths use UUIDs, as numeric IDs are refused past their sunset date.
+const (
+	testUserUUID    = "0b6c3b1e-5f4a-4c57-9a3e-2d1f0c9b8a7e"
+	unknownUserUUID = "5d0f7a92-1c3b-4e8d-b6a4-9f2e7c1d0a35"
+)
+
+// Helper function to replace the global user store with a mock for the duration of a test
+func newMockUserStore(t *testing.T) *mocks.MockUserStore {
+	t.Helper()
+
+	store := mocks.NewMockUserStore(gomock.NewController(t))
+	store.EXPECT().WithActor(gomock.Any()).Return(store).AnyTimes()
+	store.EXPECT().GetUserIDByUUID(gomock.Any(), testUserUUID).Return(7, nil).AnyTimes()
+
+	previousRepo, previousHub := userRepo, liveHub
+	userRepo, liveHub = store, realtime.NewHub()
+	t.Cleanup(func() {
+		userRepo, liveHub = previousRepo, previousHub
+	})
+
+	return store
+}
+
+// Helper function to call a user handler with the {id} path variable and an optional JSON body
+func serveUserHandler(handler http.HandlerFunc, method, id, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/api/v1/users/"+id, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if id != "" {
+		r = mux.SetURLVars(r, map[string]string{"id": id})
+	}
+
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// Helper function to check the status and message of a handler response
+func assertResponse(t *testing.T, w *httptest.ResponseRecorder, status int, message string) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d (body %s)", w.Code, status, w.Body.String())
+	}
+
+	var response Response
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if response.Message != message {
+		t.Errorf("message = %q, want %q", response.Message, message)
+	}
+}
+
+func TestGetUserByIDHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		setup   func(store *mocks.MockUserStore)
+		status  int
+		message string
+	}{
+		{
+			name: "found",
+			id:   testUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().GetUserByID(gomock.Any(), 7).Return(&database.User{ID: 7, Name: "Lan"}, nil)
+			},
+			status:  http.StatusOK,
+			message: "User found",
+		},
+		{
+			name:    "invalid id",
+			id:      "12-ab",
+			status:  http.StatusBadRequest,
+			message: "Invalid user ID",
+		},
+		{
+			name: "unknown user",
+			id:   testUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().GetUserByID(gomock.Any(), 7).Return(nil, fmt.Errorf("user with ID %d: %w", 7, database.ErrUserNotFound))
+			},
+			status:  http.StatusNotFound,
+			message: "User not found",
+		},
+		{
+			name: "lookup failure",
+			id:   testUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().GetUserByID(gomock.Any(), 7).Return(nil, errors.New("connection refused"))
+			},
+			status:  http.StatusInternalServerError,
+			message: "Failed to look up user",
+		},
+		{
+			name: "unknown uuid",
+			id:   unknownUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().GetUserIDByUUID(gomock.Any(), unknownUserUUID).
+					Return(0, fmt.Errorf("user with UUID '%s': %w", unknownUserUUID, database.ErrUserNotFound))
+			},
+			status:  http.StatusNotFound,
+			message: "User not found",
+		},
+		{
+			name: "uuid lookup failure",
+			id:   unknownUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().GetUserIDByUUID(gomock.Any(), unknownUserUUID).Return(0, errors.New("connection refused"))
+			},
+			status:  http.StatusInternalServerError,
+			message: "Failed to look up user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockUserStore(t)
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+
+			w := serveUserHandler(getUserByIDHandler, http.MethodGet, tt.id, "")
+			assertResponse(t, w, tt.status, tt.message)
+		})
+	}
+}
+
+func TestCreateUserHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		setup   func(store *mocks.MockUserStore)
+		status  int
+		message string
+	}{
+		{
+			name: "created",
+			body: `{"name":"Lan","email":"Lan@Example.com"}`,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().CreateUser(gomock.Any(), "Lan", "Lan@example.com").
+					Return(&database.User{ID: 7, Name: "Lan", Email: "Lan@example.com"}, nil)
+			},
+			status:  http.StatusCreated,
+			message: "User created successfully",
+		},
+		{
+			name:    "malformed body",
+			body:    `{"name":`,
+			status:  http.StatusBadRequest,
+			message: "Invalid request body format",
+		},
+		{
+			name:    "missing email",
+			body:    `{"name":"Lan"}`,
+			status:  http.StatusBadRequest,
+			message: "Name and email are required",
+		},
+		{
+			name: "duplicate email",
+			body: `{"name":"Lan","email":"lan@example.com"}`,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().CreateUser(gomock.Any(), "Lan", "lan@example.com").
+					Return(nil, &database.EmailExistsError{Email: "lan@example.com"})
+			},
+			status:  http.StatusConflict,
+			message: "user with email 'lan@example.com' already exists",
+		},
+		{
+			name: "store failure",
+			body: `{"name":"Lan","email":"lan@example.com"}`,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().CreateUser(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			status:  http.StatusInternalServerError,
+			message: "Failed to create user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockUserStore(t)
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+
+			w := serveUserHandler(createUserHandler, http.MethodPost, "", tt.body)
+			assertResponse(t, w, tt.status, tt.message)
+		})
+	}
+}
+
+func TestUpdateUserHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		body    string
+		setup   func(store *mocks.MockUserStore)
+		status  int
+		message string
+	}{
+		{
+			name: "updated",
+			id:   testUserUUID,
+			body: `{"name":"Lan","email":"lan@example.com"}`,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), 7, "Lan", "lan@example.com").
+					Return(&database.User{ID: 7, Name: "Lan", Email: "lan@example.com"}, nil)
+			},
+			status:  http.StatusOK,
+			message: "User updated successfully",
+		},
+		{
+			name:    "invalid id",
+			id:      "12-ab",
+			body:    `{"name":"Lan","email":"lan@example.com"}`,
+			status:  http.StatusBadRequest,
+			message: "Invalid user ID",
+		},
+		{
+			name:    "missing name",
+			id:      testUserUUID,
+			body:    `{"email":"lan@example.com"}`,
+			status:  http.StatusBadRequest,
+			message: "Name and email are required",
+		},
+		{
+			name: "unknown user",
+			id:   testUserUUID,
+			body: `{"name":"Lan","email":"lan@example.com"}`,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), 7, gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("user with ID %d: %w", 7, database.ErrUserNotFound))
+			},
+			status:  http.StatusNotFound,
+			message: "user with ID 7 not found",
+		},
+		{
+			name: "duplicate email",
+			id:   testUserUUID,
+			body: `{"name":"Lan","email":"lan@example.com"}`,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), 7, gomock.Any(), gomock.Any()).
+					Return(nil, &database.EmailExistsError{Email: "lan@example.com"})
+			},
+			status:  http.StatusConflict,
+			message: "user with email 'lan@example.com' already exists",
+		},
+		{
+			name: "store failure",
+			id:   testUserUUID,
+			body: `{"name":"Lan","email":"lan@example.com"}`,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().UpdateUser(gomock.Any(), 7, gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+			},
+			status:  http.StatusInternalServerError,
+			message: "Failed to update user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockUserStore(t)
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+
+			w := serveUserHandler(updateUserHandler, http.MethodPut, tt.id, tt.body)
+			assertResponse(t, w, tt.status, tt.message)
+		})
+	}
+}
+
+func TestDeleteUserHandler(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		setup   func(store *mocks.MockUserStore)
+		status  int
+		message string
+	}{
+		{
+			name: "deleted",
+			id:   testUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().DeleteUser(gomock.Any(), 7).Return(nil)
+			},
+			status:  http.StatusOK,
+			message: "User deleted successfully",
+		},
+		{
+			name:    "invalid id",
+			id:      "12-ab",
+			status:  http.StatusBadRequest,
+			message: "Invalid user ID",
+		},
+		{
+			name: "unknown user",
+			id:   testUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().DeleteUser(gomock.Any(), 7).Return(fmt.Errorf("user with ID %d: %w", 7, database.ErrUserNotFound))
+			},
+			status:  http.StatusNotFound,
+			message: "user with ID 7 not found",
+		},
+		{
+			name: "legal hold",
+			id:   testUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().DeleteUser(gomock.Any(), 7).Return(fmt.Errorf("user with ID %d: %w", 7, database.ErrUserUnderLegalHold))
+			},
+			status:  http.StatusConflict,
+			message: "user with ID 7 is under legal hold",
+		},
+		{
+			name: "store failure",
+			id:   testUserUUID,
+			setup: func(store *mocks.MockUserStore) {
+				store.EXPECT().DeleteUser(gomock.Any(), 7).Return(errors.New("connection refused"))
+			},
+			status:  http.StatusInternalServerError,
+			message: "Failed to delete user",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMockUserStore(t)
+			if tt.setup != nil {
+				tt.setup(store)
+			}
+
+			w := serveUserHandler(deleteUserHandler, http.MethodDelete, tt.id, "")
+			assertResponse(t, w, tt.status, tt.message)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+
+	"github.com/gorilla/mux"
+)
+
+// userIDPattern is the route pattern of the {id} of user paths: the user's UUID, or the
+// deprecated numeric ID. userIDParam tells them apart and rejects anything else.
+const userIDPattern = `[0-9a-fA-F-]+`
+
+// Helper function to read the user of the path, given by UUID or by numeric ID, and return
+// its numeric ID. It answers 404 for an unknown UUID, and 400 for a numeric ID past the
+// API_NUMERIC_USER_ID_SUNSET date; before it, responses to numeric IDs carry Deprecation and
+// Sunset headers.
+func userIDParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := mux.Vars(r)["id"]
+
+	if database.IsUUID(value) {
+		userID, err := userRepo.GetUserIDByUUID(r.Context(), value)
+		if err != nil {
+			sendUserLookupError(w, value, err)
+			return 0, false
+		}
+		return userID, true
+	}
+
+	userID, err := strconv.Atoi(value)
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_user_id"), nil)
+		return 0, false
+	}
+
+	if !time.Now().Before(apiLifecycle.NumericUserIDSunset) {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("numeric_user_id_retired"), nil)
+		return 0, false
+	}
+	w.Header().Set("Deprecation", "@"+strconv.FormatInt(apiLifecycle.NumericUserIDDeprecatedAt.Unix(), 10))
+	w.Header().Set("Sunset", apiLifecycle.NumericUserIDSunset.Format(http.TimeFormat))
+	return userID, true
+}
+
+// Helper function to answer a failed lookup of the user of the path, given by its ID or UUID:
+// 404 when the user does not exist, 500 when the lookup itself failed
+func sendUserLookupError(w http.ResponseWriter, user interface{}, err error) {
+	if errors.Is(err, database.ErrUserNotFound) {
+		sendJSONResponse(w, http.StatusNotFound, i18n.M("user_not_found"), nil)
+		return
+	}
+	log.Printf("Error getting user %v: %v", user, err)
+	sendJSONResponse(w, http.StatusInternalServerError, i18n.M("user_lookup_failed"), nil)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hoctap-api/i18n"
+)
+
+// Result limits of GET /users/search
+const (
+	defaultUserSearchLimit = 10
+	maxUserSearchLimit     = 50
+)
+
+// userSuggestion is the slim user of the typeahead search
+type userSuggestion struct {
+	ID    int    `json:"id"`
+	UUID  string `json:"uuid"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// Search users for autocomplete (?q= and optionally ?limit=): users whose name or email starts
+// with q, ignoring case, ordered by name, with only their IDs, name and email
+func searchUsersHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	prefix := strings.TrimSpace(query.Get("q"))
+	if prefix == "" {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("user_search_query_required"), nil)
+		return
+	}
+
+	limit := defaultUserSearchLimit
+	if value := query.Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > maxUserSearchLimit {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_limit_50"), nil)
+			return
+		}
+	}
+
+	users, err := userRepo.SearchUsers(r.Context(), prefix, limit)
+	if err != nil {
+		log.Printf("Error searching users: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("user_search_failed"), nil)
+		return
+	}
+
+	suggestions := make([]userSuggestion, 0, len(users))
+	for _, user := range users {
+		suggestions = append(suggestions, userSuggestion{ID: user.ID, UUID: user.UUID, Name: user.Name, Email: user.Email})
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("users_found"), suggestions)
+}
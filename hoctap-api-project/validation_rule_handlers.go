@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/rules"
+
+	"github.com/gorilla/mux"
+)
+
+// Global validation rule repository and engine
+var (
+	validationRuleRepo *database.ValidationRuleRepository
+	ruleEngine         = rules.NewEngine()
+)
+
+// errorCodeValidationRule marks a write rejected by an admin-defined validation rule, whose
+// message is the rule's own and is not translated
+const errorCodeValidationRule = "validation_rule_violation"
+
+// Helper function to give the message of a validation rule violation: the one its script
+// chose, or the catalog message of a rule that failed without one
+func violationMessage(violation *rules.Violation) i18n.Message {
+	if violation.Code == "" {
+		return i18n.Text(violation.Message)
+	}
+	return i18n.M(violation.Code, "rule", violation.Rule)
+}
+
+// validationRuleInput is the request body for creating a validation rule
+type validationRuleInput struct {
+	Entity  string `json:"entity"`
+	Name    string `json:"name"`
+	Script  string `json:"script"`
+	Enabled *bool  `json:"enabled"`
+}
+
+// Create a validation rule; it applies as soon as it is stored
+func createValidationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var ruleData validationRuleInput
+
+	if err := decodeRequestBody(r, &ruleData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+		return
+	}
+
+	// Validation
+	if ruleData.Entity == "" {
+		ruleData.Entity = database.ValidationRuleEntityUser
+	}
+	if ruleData.Entity != database.ValidationRuleEntityUser {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("validation_rule_unsupported_entity",
+			"entity", ruleData.Entity, "entities", database.ValidationRuleEntityUser), nil)
+		return
+	}
+
+	ruleData.Name = strings.TrimSpace(ruleData.Name)
+	if ruleData.Name == "" || ruleData.Script == "" {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("validation_rule_name_script_required"), nil)
+		return
+	}
+
+	if _, err := rules.Compile(ruleData.Name, ruleData.Script); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.Text(err.Error()), nil)
+		return
+	}
+
+	enabled := ruleData.Enabled == nil || *ruleData.Enabled
+	rule, err := validationRuleRepo.CreateRule(r.Context(), ruleData.Entity, ruleData.Name, ruleData.Script, enabled)
+	if err != nil {
+		log.Printf("Error creating validation rule: %v", err)
+		if errors.Is(err, database.ErrValidationRuleExists) {
+			sendJSONResponse(w, http.StatusConflict, i18n.M("validation_rule_exists", "name", ruleData.Name), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("validation_rule_create_failed"), nil)
+		}
+		return
+	}
+
+	if err := reloadValidationRules(r.Context()); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+
+	sendJSONResponse(w, http.StatusCreated, i18n.M("validation_rule_created"), rule)
+}
+
+// Get all validation rules
+func getValidationRulesHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := validationRuleRepo.GetAllRules(r.Context())
+	if err != nil {
+		log.Printf("Error getting validation rules: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("validation_rules_retrieve_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("validation_rules_retrieved"), list)
+}
+
+// Delete a validation rule
+func deleteValidationRuleHandler(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_validation_rule_id"), nil)
+		return
+	}
+
+	if err := validationRuleRepo.DeleteRule(r.Context(), ruleID); err != nil {
+		log.Printf("Error deleting validation rule: %v", err)
+		if errors.Is(err, database.ErrValidationRuleNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("validation_rule_not_found", "id", ruleID), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("validation_rule_delete_failed"), nil)
+		}
+		return
+	}
+
+	if err := reloadValidationRules(r.Context()); err != nil {
+		log.Printf("⚠️ Warning: %v", err)
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("validation_rule_deleted"), nil)
+}
+
+// Helper function to load the stored rules into the engine
+func reloadValidationRules(ctx context.Context) error {
+	list, err := validationRuleRepo.GetAllRules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load validation rules: %v", err)
+	}
+
+	ruleEngine.Load(list)
+	return nil
+}
+
+// ruleCheckedUserStore runs the user validation rules before creating or updating a user,
+// so REST, GraphQL and gRPC writes are all checked
+type ruleCheckedUserStore struct {
+	database.UserStore
+}
+
+func (s ruleCheckedUserStore) WithActor(actor database.AuditActor) database.UserStore {
+	return ruleCheckedUserStore{s.UserStore.WithActor(actor)}
+}
+
+func (s ruleCheckedUserStore) WithTx(ctx context.Context, fn func(tx database.UserStore) error) error {
+	return s.UserStore.WithTx(ctx, func(tx database.UserStore) error {
+		return fn(ruleCheckedUserStore{tx})
+	})
+}
+
+func (s ruleCheckedUserStore) CreateUser(ctx context.Context, name, email string) (*database.User, error) {
+	if err := ruleEngine.Validate(ctx, database.ValidationRuleEntityUser, map[string]string{"name": name, "email": email}); err != nil {
+		return nil, err
+	}
+	return s.UserStore.CreateUser(ctx, name, email)
+}
+
+func (s ruleCheckedUserStore) UpdateUser(ctx context.Context, id int, name, email string) (*database.User, error) {
+	record := map[string]string{"id": strconv.Itoa(id), "name": name, "email": email}
+	if err := ruleEngine.Validate(ctx, database.ValidationRuleEntityUser, record); err != nil {
+		return nil, err
+	}
+	return s.UserStore.UpdateUser(ctx, id, name, email)
+}
@@ -0,0 +1,166 @@
+package watchdog
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+	"time"
+)
+
+// Sample is one measurement of the resources that grow when something leaks. OpenFDs is -1
+// where /proc/self/fd is not available.
+type Sample struct {
+	At                time.Time `json:"at"`
+	Goroutines        int       `json:"goroutines"`
+	DBOpenConnections int       `json:"db_open_connections"`
+	OpenFDs           int       `json:"open_fds"`
+}
+
+// Status is the latest sample with the number of leak warnings so far
+type Status struct {
+	Sample
+	LeakWarnings int `json:"leak_warnings"`
+}
+
+// Watchdog samples the resources every interval and warns, with a goroutine dump, when one
+// of them grew at every sample of the last window. Leaking streams and WebSocket handlers
+// show up as goroutine and file descriptor counts that never come down.
+type Watchdog struct {
+	window  int
+	dbConns func() int
+
+	mu       sync.Mutex
+	samples  []Sample
+	warnings int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// New creates a watchdog looking for growth across window samples. dbConns returns the
+// open database connections, or 0 without a database.
+func New(window int, dbConns func() int) *Watchdog {
+	return &Watchdog{
+		window:  window,
+		dbConns: dbConns,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Start samples right away and then every interval until Stop
+func (w *Watchdog) Start(interval time.Duration) {
+	w.check()
+	go w.run(interval)
+}
+
+// Stop ends the sampling
+func (w *Watchdog) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// Status returns the latest sample
+func (w *Watchdog) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := Status{LeakWarnings: w.warnings}
+	if n := len(w.samples); n > 0 {
+		status.Sample = w.samples[n-1]
+	}
+	return status
+}
+
+// run samples every interval
+func (w *Watchdog) run(interval time.Duration) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.check()
+		case <-w.stop:
+			return
+		}
+	}
+}
+
+// check takes a sample and warns about the resources that grew across the whole window.
+// The window starts over after a warning, so a steady leak is reported once per window.
+func (w *Watchdog) check() {
+	sample := Sample{
+		At:                time.Now(),
+		Goroutines:        runtime.NumGoroutine(),
+		DBOpenConnections: w.dbConns(),
+		OpenFDs:           countOpenFDs(),
+	}
+
+	w.mu.Lock()
+	w.samples = append(w.samples, sample)
+	if len(w.samples) > w.window {
+		w.samples = w.samples[len(w.samples)-w.window:]
+	}
+	if len(w.samples) < w.window {
+		w.mu.Unlock()
+		return
+	}
+
+	var growing []string
+	for _, resource := range []struct {
+		name  string
+		value func(Sample) int
+	}{
+		{"goroutines", func(s Sample) int { return s.Goroutines }},
+		{"database connections", func(s Sample) int { return s.DBOpenConnections }},
+		{"file descriptors", func(s Sample) int { return s.OpenFDs }},
+	} {
+		if grewSteadily(w.samples, resource.value) {
+			growing = append(growing, resource.name)
+		}
+	}
+	first := w.samples[0]
+	if len(growing) > 0 {
+		w.warnings++
+		w.samples = []Sample{sample}
+	}
+	w.mu.Unlock()
+
+	if len(growing) == 0 {
+		return
+	}
+
+	var dump bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&dump, 1)
+	log.Printf("⚠️ Warning: possible leak: %v grew at each of the last %d samples since %s (goroutines %d -> %d, database connections %d -> %d, file descriptors %d -> %d); goroutine dump:\n%s",
+		growing, w.window, first.At.Format(time.RFC3339),
+		first.Goroutines, sample.Goroutines, first.DBOpenConnections, sample.DBOpenConnections,
+		first.OpenFDs, sample.OpenFDs, dump.String())
+}
+
+// Helper function to tell whether value increased from every sample to the next
+func grewSteadily(samples []Sample, value func(Sample) int) bool {
+	for i := 1; i < len(samples); i++ {
+		if value(samples[i]) <= value(samples[i-1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper function to count the open file descriptors of the process, or -1 where
+// /proc/self/fd is not available
+func countOpenFDs() int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	// The directory being read holds one descriptor itself
+	return len(entries) - 1
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"hoctap-api/database"
+	"hoctap-api/i18n"
+	"hoctap-api/webhooks"
+
+	"github.com/gorilla/mux"
+)
+
+// Global webhook repository and dispatcher
+var (
+	webhookRepo       *database.WebhookRepository
+	webhookDispatcher *webhooks.Dispatcher
+)
+
+// webhookInput is the request body for registering a webhook
+type webhookInput struct {
+	URL    string   `json:"url"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events"`
+}
+
+// Register a webhook endpoint
+func createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var webhookData webhookInput
+
+	if err := decodeRequestBody(r, &webhookData); err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_request_body"), nil)
+		return
+	}
+
+	// Validation
+	parsed, err := url.Parse(webhookData.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("webhook_url_required"), nil)
+		return
+	}
+
+	for _, event := range webhookData.Events {
+		if !isSupportedWebhookEvent(event) {
+			sendJSONResponse(w, http.StatusBadRequest, i18n.M("webhook_unsupported_event",
+				"event", event, "events", strings.Join(webhooks.SupportedEvents, ", ")), nil)
+			return
+		}
+	}
+
+	if webhookData.Secret == "" {
+		webhookData.Secret = webhooks.NewSecret()
+	}
+
+	webhook, err := webhookRepo.CreateWebhook(r.Context(), webhookData.URL, webhookData.Secret, webhookData.Events)
+	if err != nil {
+		log.Printf("Error creating webhook: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("webhook_create_failed"), nil)
+		return
+	}
+
+	// The secret is only returned once, at registration time
+	sendJSONResponse(w, http.StatusCreated, i18n.M("webhook_created"), webhook)
+}
+
+// Get all webhooks
+func getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	list, err := webhookRepo.GetAllWebhooks(r.Context())
+	if err != nil {
+		log.Printf("Error getting webhooks: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("webhooks_retrieve_failed"), nil)
+		return
+	}
+
+	for i := range list {
+		list[i].Secret = ""
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("webhooks_retrieved"), list)
+}
+
+// Delete a webhook
+func deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_webhook_id"), nil)
+		return
+	}
+
+	if err := webhookRepo.DeleteWebhook(r.Context(), webhookID); err != nil {
+		log.Printf("Error deleting webhook: %v", err)
+		if errors.Is(err, database.ErrWebhookNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("webhook_id_not_found", "id", webhookID), nil)
+		} else {
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("webhook_delete_failed"), nil)
+		}
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("webhook_deleted"), nil)
+}
+
+// Get the delivery log of a webhook
+func getWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	webhookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		sendJSONResponse(w, http.StatusBadRequest, i18n.M("invalid_webhook_id"), nil)
+		return
+	}
+
+	if _, err := webhookRepo.GetWebhookByID(r.Context(), webhookID); err != nil {
+		if errors.Is(err, database.ErrWebhookNotFound) {
+			sendJSONResponse(w, http.StatusNotFound, i18n.M("webhook_not_found"), nil)
+		} else {
+			log.Printf("Error getting webhook: %v", err)
+			sendJSONResponse(w, http.StatusInternalServerError, i18n.M("webhook_deliveries_failed"), nil)
+		}
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	deliveries, err := webhookRepo.GetDeliveries(r.Context(), webhookID, limit)
+	if err != nil {
+		log.Printf("Error getting webhook deliveries: %v", err)
+		sendJSONResponse(w, http.StatusInternalServerError, i18n.M("webhook_deliveries_failed"), nil)
+		return
+	}
+
+	sendJSONResponse(w, http.StatusOK, i18n.M("webhook_deliveries_retrieved"), deliveries)
+}
+
+// Helper function to check an event name against the supported webhook events
+func isSupportedWebhookEvent(event string) bool {
+	for _, supported := range webhooks.SupportedEvents {
+		if event == supported {
+			return true
+		}
+	}
+	return false
+}
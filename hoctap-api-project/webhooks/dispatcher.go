@@ -0,0 +1,189 @@
+package webhooks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"hoctap-api/database"
+	"hoctap-api/jobs"
+)
+
+// User lifecycle events delivered to webhooks
+const (
+	EventUserCreated = database.EventUserCreated
+	EventUserUpdated = database.EventUserUpdated
+	EventUserDeleted = database.EventUserDeleted
+)
+
+// SupportedEvents lists every event a webhook can subscribe to
+var SupportedEvents = []string{EventUserCreated, EventUserUpdated, EventUserDeleted}
+
+// Delivery settings
+const (
+	// JobKind is the kind of the background job delivering one event to one webhook
+	JobKind        = "webhook.deliver"
+	maxAttempts    = 5
+	initialBackoff = 2 * time.Second
+	requestTimeout = 10 * time.Second
+)
+
+// Payload is the JSON body POSTed to webhook endpoints
+type Payload struct {
+	ID        string      `json:"id"`
+	Event     string      `json:"event"`
+	CreatedAt string      `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// delivery is the job payload of a single event addressed to a single webhook. Body is
+// the encoded Payload, kept as sent so every attempt carries the same signature.
+type delivery struct {
+	WebhookID int    `json:"webhook_id"`
+	EventID   string `json:"event_id"`
+	Event     string `json:"event"`
+	Body      string `json:"body"`
+}
+
+// Dispatcher delivers events to registered webhooks as background jobs, so deliveries
+// are retried with exponential backoff and survive restarts
+type Dispatcher struct {
+	repo   *database.WebhookRepository
+	queue  *jobs.Queue
+	client *http.Client
+}
+
+// NewDispatcher creates a dispatcher and registers its delivery job with queue
+func NewDispatcher(repo *database.WebhookRepository, queue *jobs.Queue) *Dispatcher {
+	d := &Dispatcher{
+		repo:   repo,
+		queue:  queue,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+	queue.Register(JobKind, jobs.Options{MaxAttempts: maxAttempts, Backoff: initialBackoff}, d.deliver)
+	return d
+}
+
+// Publish enqueues a delivery of an outbox event for every active webhook subscribed to it.
+// It never blocks the caller on network I/O. Publishing an event again, after an error or
+// a crash, delivers it again with the same ID, which receivers can use to drop duplicates.
+func (d *Dispatcher) Publish(ctx context.Context, event database.OutboxEvent) error {
+	webhooks, err := d.repo.GetActiveWebhooks(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load webhooks for %s: %v", event.Event, err)
+	}
+
+	payload := Payload{
+		ID:        event.EventID,
+		Event:     event.Event,
+		CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		Data:      event.Payload,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s payload: %v", event.Event, err)
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event.Event) {
+			continue
+		}
+		job := delivery{WebhookID: webhook.ID, EventID: payload.ID, Event: event.Event, Body: string(body)}
+		if _, err := d.queue.Enqueue(ctx, JobKind, job); err != nil {
+			return fmt.Errorf("failed to enqueue %s for webhook %d: %v", event.Event, webhook.ID, err)
+		}
+	}
+	return nil
+}
+
+// Sign computes the signature sent in the X-Webhook-Signature header
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewSecret generates a random signing secret for a new webhook
+func NewSecret() string {
+	buf := make([]byte, 32)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// deliver runs a delivery job: one attempt, logged with the job's attempt number. A webhook
+// deleted or deactivated since the event was published no longer gets it.
+func (d *Dispatcher) deliver(ctx context.Context, job *database.Job) error {
+	var payload delivery
+	if err := json.Unmarshal(job.Payload, &payload); err != nil {
+		return jobs.Permanent(fmt.Errorf("invalid delivery: %v", err))
+	}
+
+	webhook, err := d.repo.GetWebhookByID(ctx, payload.WebhookID)
+	if err != nil {
+		if errors.Is(err, database.ErrWebhookNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !webhook.Active {
+		return nil
+	}
+
+	statusCode, duration, err := d.send(ctx, webhook, payload)
+
+	record := database.WebhookDelivery{
+		WebhookID:  webhook.ID,
+		EventID:    payload.EventID,
+		Event:      payload.Event,
+		Attempt:    job.Attempts,
+		StatusCode: statusCode,
+		Success:    err == nil,
+		DurationMs: duration.Milliseconds(),
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	if recordErr := d.repo.RecordDelivery(ctx, record); recordErr != nil {
+		log.Printf("⚠️ Warning: %v", recordErr)
+	}
+
+	return err
+}
+
+// send performs a single signed POST to the webhook endpoint
+func (d *Dispatcher) send(ctx context.Context, webhook *database.Webhook, job delivery) (int, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, strings.NewReader(job.Body))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to build request: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "HocTap-Webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", job.Event)
+	req.Header.Set("X-Webhook-ID", job.EventID)
+	req.Header.Set("X-Webhook-Signature", Sign(webhook.Secret, []byte(job.Body)))
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	duration := time.Since(start)
+	if err != nil {
+		return 0, duration, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, duration, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, duration, nil
+}